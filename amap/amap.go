@@ -2,8 +2,12 @@ package amap
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/MangoMilk/go-kit/net"
+	"github.com/MangoMilk/go-sdk/coordinate"
+	"net/url"
+	"strings"
 )
 
 /*
@@ -11,9 +15,25 @@ import (
 */
 
 const (
-	geoUrl = "https://restapi.amap.com/v3/geocode/geo"
+	geoUrl               = "https://restapi.amap.com/v3/geocode/geo"
+	convertCoordinateUrl = "https://restapi.amap.com/v3/assistant/coordinate/convert"
 )
 
+var (
+	ErrTooManyLocations = errors.New("amap: at most 40 locations per request")
+	ErrMixedSystem      = errors.New("amap: locations must be in the same coordinate system")
+)
+
+// Error 高德接口返回 status 不为 1 时的错误
+type Error struct {
+	Info     string // 返回状态说明
+	Infocode string // 返回状态码，10000 为正确
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("amap: %s (infocode %s)", e.Info, e.Infocode)
+}
+
 type Amap struct {
 	Key string
 }
@@ -50,24 +70,105 @@ type geo struct {
 	//},
 	//	street: [ ],// slice or string？
 	//	number: [ ],
-	Adcode   string `json:"adcode"`   // 区域编码, 110101
-	Location string `json:"location"` // 坐标点, 经度，纬度=>"113.321124,23.119252"
-	Level    string `json:"level"`    // 匹配级别
+	Adcode   string                `json:"adcode"`   // 区域编码, 110101
+	Location coordinate.Coordinate `json:"location"` // 坐标点, 经度，纬度=>"113.321124,23.119252"，GCJ02 坐标系
+	Level    string                `json:"level"`    // 匹配级别
 }
 
-func (a *Amap) GetGeo(address string, city string) (*getGeoRes,error) {
+func (a *Amap) GetGeo(address string, city string) (*getGeoRes, error) {
 	query := fmt.Sprintf("?key=%s&address=%s&city=%s", a.Key, address, city)
 
-	data,err := net.HttpGet(geoUrl+query, nil)
-	if err!=nil{
-		return nil,err
+	data, err := net.HttpGet(geoUrl+query, nil)
+	if err != nil {
+		return nil, err
 	}
 
 	var amapRes getGeoRes
 
-	if jsonErr:=json.Unmarshal(data,&amapRes);jsonErr!=nil {
-		return nil,jsonErr
+	if jsonErr := json.Unmarshal(data, &amapRes); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	for i := range amapRes.Geocodes {
+		amapRes.Geocodes[i].Location.System = coordinate.GCJ02
+	}
+
+	return &amapRes, nil
+}
+
+// ==================== 坐标转换 ====================
+// coordsys 原坐标系
+var coordsys = map[coordinate.System]string{
+	coordinate.WGS84: "gps",
+	coordinate.BD09:  "baidu",
+	coordinate.GCJ02: "autonavi",
+}
+
+type convertCoordinateRes struct {
+	Status    string `json:"status"`    // 返回状态, 1：成功；0：失败
+	Info      string `json:"info"`      // 返回状态说明
+	Infocode  string `json:"infocode"`  // 返回状态码
+	Locations string `json:"locations"` // 转换之后的坐标，多个坐标以";"分隔
+}
+
+// ConvertCoordinate 调用高德坐标转换接口，将 WGS84、BD09 坐标转换为高德坐标（GCJ02）；
+// 一次最多 40 个坐标且需使用相同的坐标系，不依赖网络时可直接使用 coordinate.Coordinate.GCJ02
+func (a *Amap) ConvertCoordinate(locations ...coordinate.Coordinate) ([]coordinate.Coordinate, error) {
+	if len(locations) == 0 {
+		return nil, nil
+	}
+	if len(locations) > 40 {
+		return nil, ErrTooManyLocations
+	}
+
+	system := locations[0].System
+	points := make([]string, len(locations))
+	for i, location := range locations {
+		if location.System != system {
+			return nil, ErrMixedSystem
+		}
+		points[i] = location.String()
+	}
+
+	// 未设置坐标系时视为 GCJ02
+	if system == "" {
+		system = coordinate.GCJ02
+	}
+	sys, ok := coordsys[system]
+	if !ok {
+		return nil, coordinate.ErrUnknownSystem
+	}
+
+	query := url.Values{}
+	query.Set("key", a.Key)
+	query.Set("locations", strings.Join(points, "|"))
+	query.Set("coordsys", sys)
+
+	data, err := net.HttpGet(convertCoordinateUrl+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var amapRes convertCoordinateRes
+	if jsonErr := json.Unmarshal(data, &amapRes); jsonErr != nil {
+		return nil, jsonErr
+	}
+	if amapRes.Status != "1" {
+		return nil, &Error{Info: amapRes.Info, Infocode: amapRes.Infocode}
+	}
+
+	return parseLocations(amapRes.Locations)
+}
+
+func parseLocations(s string) ([]coordinate.Coordinate, error) {
+	var res []coordinate.Coordinate
+	for _, point := range strings.Split(s, ";") {
+		location, err := coordinate.Parse(point, coordinate.GCJ02)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, location)
 	}
 
-	return &amapRes,nil
+	return res, nil
 }
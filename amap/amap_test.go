@@ -2,6 +2,7 @@ package amap
 
 import (
 	"fmt"
+	"github.com/MangoMilk/go-sdk/coordinate"
 	"testing"
 )
 
@@ -35,3 +36,29 @@ func TestAmapGetGeo(t *testing.T) {
 	}
 
 }
+
+func TestConvertCoordinate(t *testing.T) {
+	res, err := amap.ConvertCoordinate(coordinate.New(116.481499, 39.990475, coordinate.WGS84))
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Println(res)
+	}
+
+	if _, err := amap.ConvertCoordinate(coordinate.New(116.481499, 39.990475, coordinate.WGS84), coordinate.New(116.481499, 39.990475, coordinate.BD09)); err != ErrMixedSystem {
+		t.Fatalf("got %v, want ErrMixedSystem", err)
+	}
+	if _, err := amap.ConvertCoordinate(coordinate.New(116.481499, 39.990475, coordinate.System("mapbar"))); err != coordinate.ErrUnknownSystem {
+		t.Fatalf("got %v, want ErrUnknownSystem", err)
+	}
+}
+
+func TestParseLocations(t *testing.T) {
+	res, err := parseLocations("116.487585177952,39.991754014757;116.487585177952,39.991653917072")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || res[1] != coordinate.New(116.487585177952, 39.991653917072, coordinate.GCJ02) {
+		t.Fatalf("got %v", res)
+	}
+}
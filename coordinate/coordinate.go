@@ -0,0 +1,225 @@
+package coordinate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+/*
+ 坐标系转换
+
+ WGS84：GPS 使用的国际标准坐标系；
+ GCJ02：国测局坐标系（火星坐标系），高德地图、腾讯地图、微信及达达等国内服务使用；
+ BD09：百度坐标系，在 GCJ02 的基础上再次加偏。
+ WGS84 与 GCJ02 的偏移只在中国境内生效，境外坐标两者相同；GCJ02、BD09 的逆转换通过迭代求解，误差小于 1e-7 度。
+*/
+
+var (
+	ErrInvalidCoordinate = errors.New("invalid coordinate, want \"lng,lat\"")
+	ErrUnknownSystem     = errors.New("unknown coordinate system")
+)
+
+type System string
+
+const (
+	WGS84 = System("wgs84") // GPS
+	GCJ02 = System("gcj02") // 高德、腾讯
+	BD09  = System("bd09")  // 百度
+)
+
+// Valid 是否为支持的坐标系
+func (s System) Valid() bool {
+	switch s {
+	case WGS84, GCJ02, BD09:
+		return true
+	}
+
+	return false
+}
+
+// Coordinate 经纬度坐标，单位：度
+type Coordinate struct {
+	Lng    float64 // 经度
+	Lat    float64 // 纬度
+	System System  // 坐标系
+}
+
+func New(lng, lat float64, system System) Coordinate {
+	return Coordinate{Lng: lng, Lat: lat, System: system}
+}
+
+// Parse 解析 "经度,纬度" 格式的坐标，如 "113.321124,23.119252"
+func Parse(s string, system System) (Coordinate, error) {
+	parts := strings.Split(strings.TrimSpace(s), ",")
+	if len(parts) != 2 {
+		return Coordinate{}, ErrInvalidCoordinate
+	}
+
+	lng, lngErr := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if lngErr != nil || latErr != nil {
+		return Coordinate{}, ErrInvalidCoordinate
+	}
+
+	return New(lng, lat, system), nil
+}
+
+// String 返回 "经度,纬度"，保留 6 位小数
+func (c Coordinate) String() string {
+	return fmt.Sprintf("%.6f,%.6f", c.Lng, c.Lat)
+}
+
+func (c Coordinate) IsZero() bool {
+	return c.Lng == 0 && c.Lat == 0
+}
+
+// MarshalText 编码为 "经度,纬度"，坐标系不参与编码
+func (c Coordinate) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText 解析 "经度,纬度"，保留原有的坐标系
+func (c *Coordinate) UnmarshalText(data []byte) error {
+	if len(data) == 0 {
+		*c = Coordinate{System: c.System}
+		return nil
+	}
+
+	parsed, err := Parse(string(data), c.System)
+	if err != nil {
+		return err
+	}
+
+	*c = parsed
+	return nil
+}
+
+// To 转换到 system 坐标系，未设置坐标系时视为 system；源或目标坐标系不支持时返回 ErrUnknownSystem
+func (c Coordinate) To(system System) (Coordinate, error) {
+	if !system.Valid() || (c.System != "" && !c.System.Valid()) {
+		return Coordinate{}, ErrUnknownSystem
+	}
+	if c.System == "" || c.System == system {
+		return New(c.Lng, c.Lat, system), nil
+	}
+
+	// 以 GCJ02 为中转
+	gcj := c
+	switch c.System {
+	case WGS84:
+		gcj = wgs84ToGcj02(c)
+	case BD09:
+		gcj = bd09ToGcj02(c)
+	}
+
+	switch system {
+	case WGS84:
+		return gcj02ToWgs84(gcj), nil
+	case BD09:
+		return gcj02ToBd09(gcj), nil
+	}
+
+	return New(gcj.Lng, gcj.Lat, GCJ02), nil
+}
+
+func (c Coordinate) WGS84() (Coordinate, error) {
+	return c.To(WGS84)
+}
+
+func (c Coordinate) GCJ02() (Coordinate, error) {
+	return c.To(GCJ02)
+}
+
+func (c Coordinate) BD09() (Coordinate, error) {
+	return c.To(BD09)
+}
+
+// ==================== WGS84 <-> GCJ02 ====================
+const (
+	krasovskyA  = 6378245.0             // 克拉索夫斯基椭球长半轴
+	krasovskyEE = 0.0066934216229659433 // 克拉索夫斯基椭球第一偏心率的平方
+)
+
+// OutOfChina 坐标是否在中国境外（按矩形范围粗略判断），境外不做 WGS84 与 GCJ02 的偏移
+func OutOfChina(lng, lat float64) bool {
+	return lng < 72.004 || lng > 137.8347 || lat < 0.8293 || lat > 55.8271
+}
+
+func transformLat(x, y float64) float64 {
+	ret := -100.0 + 2.0*x + 3.0*y + 0.2*y*y + 0.1*x*y + 0.2*math.Sqrt(math.Abs(x))
+	ret += (20.0*math.Sin(6.0*x*math.Pi) + 20.0*math.Sin(2.0*x*math.Pi)) * 2.0 / 3.0
+	ret += (20.0*math.Sin(y*math.Pi) + 40.0*math.Sin(y/3.0*math.Pi)) * 2.0 / 3.0
+	ret += (160.0*math.Sin(y/12.0*math.Pi) + 320*math.Sin(y*math.Pi/30.0)) * 2.0 / 3.0
+	return ret
+}
+
+func transformLng(x, y float64) float64 {
+	ret := 300.0 + x + 2.0*y + 0.1*x*x + 0.1*x*y + 0.1*math.Sqrt(math.Abs(x))
+	ret += (20.0*math.Sin(6.0*x*math.Pi) + 20.0*math.Sin(2.0*x*math.Pi)) * 2.0 / 3.0
+	ret += (20.0*math.Sin(x*math.Pi) + 40.0*math.Sin(x/3.0*math.Pi)) * 2.0 / 3.0
+	ret += (150.0*math.Sin(x/12.0*math.Pi) + 300.0*math.Sin(x/30.0*math.Pi)) * 2.0 / 3.0
+	return ret
+}
+
+func wgs84ToGcj02(c Coordinate) Coordinate {
+	if OutOfChina(c.Lng, c.Lat) {
+		return New(c.Lng, c.Lat, GCJ02)
+	}
+
+	dLat := transformLat(c.Lng-105.0, c.Lat-35.0)
+	dLng := transformLng(c.Lng-105.0, c.Lat-35.0)
+	radLat := c.Lat / 180.0 * math.Pi
+	magic := math.Sin(radLat)
+	magic = 1 - krasovskyEE*magic*magic
+	sqrtMagic := math.Sqrt(magic)
+	dLat = (dLat * 180.0) / ((krasovskyA * (1 - krasovskyEE)) / (magic * sqrtMagic) * math.Pi)
+	dLng = (dLng * 180.0) / (krasovskyA / sqrtMagic * math.Cos(radLat) * math.Pi)
+
+	return New(c.Lng+dLng, c.Lat+dLat, GCJ02)
+}
+
+func gcj02ToWgs84(c Coordinate) Coordinate {
+	if OutOfChina(c.Lng, c.Lat) {
+		return New(c.Lng, c.Lat, WGS84)
+	}
+
+	return invert(c, WGS84, wgs84ToGcj02)
+}
+
+// ==================== GCJ02 <-> BD09 ====================
+const xPi = math.Pi * 3000.0 / 180.0
+
+func gcj02ToBd09(c Coordinate) Coordinate {
+	z := math.Sqrt(c.Lng*c.Lng+c.Lat*c.Lat) + 0.00002*math.Sin(c.Lat*xPi)
+	theta := math.Atan2(c.Lat, c.Lng) + 0.000003*math.Cos(c.Lng*xPi)
+
+	return New(z*math.Cos(theta)+0.0065, z*math.Sin(theta)+0.006, BD09)
+}
+
+func bd09ToGcj02(c Coordinate) Coordinate {
+	return invert(c, GCJ02, gcj02ToBd09)
+}
+
+// invert 求 forward 的逆：forward 的偏移量很小且变化平缓，从 target 出发反复按残差修正即可收敛
+func invert(target Coordinate, system System, forward func(Coordinate) Coordinate) Coordinate {
+	const (
+		threshold = 1e-9
+		maxRounds = 30
+	)
+
+	c := New(target.Lng, target.Lat, system)
+	for i := 0; i < maxRounds; i++ {
+		f := forward(c)
+		dLng, dLat := target.Lng-f.Lng, target.Lat-f.Lat
+		c.Lng += dLng
+		c.Lat += dLat
+		if math.Abs(dLng) < threshold && math.Abs(dLat) < threshold {
+			break
+		}
+	}
+
+	return c
+}
@@ -0,0 +1,84 @@
+package coordinate
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func near(a, b Coordinate, tolerance float64) bool {
+	return a.System == b.System && math.Abs(a.Lng-b.Lng) < tolerance && math.Abs(a.Lat-b.Lat) < tolerance
+}
+
+func mustTo(t *testing.T, c Coordinate, system System) Coordinate {
+	t.Helper()
+
+	res, err := c.To(system)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+func TestConvert(t *testing.T) {
+	c := New(128.543, 37.065, WGS84)
+	if got, want := mustTo(t, c, GCJ02), New(128.54820547949757, 37.065651049489816, GCJ02); !near(got, want, 1e-9) {
+		t.Fatalf("wgs84 to gcj02: got %v, want %v", got, want)
+	}
+
+	gcj := New(128.543, 37.065, GCJ02)
+	if got, want := mustTo(t, gcj, BD09), New(128.54944656269413, 37.07113427883019, BD09); !near(got, want, 1e-9) {
+		t.Fatalf("gcj02 to bd09: got %v, want %v", got, want)
+	}
+
+	// 国外坐标不偏移
+	paris := New(2.3522, 48.8566, WGS84)
+	if got := mustTo(t, paris, GCJ02); got.Lng != paris.Lng || got.Lat != paris.Lat {
+		t.Fatalf("out of china should not shift, got %v", got)
+	}
+
+	points := []Coordinate{
+		New(113.321124, 23.119252, GCJ02),
+		New(116.397128, 39.916527, WGS84),
+		New(121.480539, 31.235929, BD09),
+	}
+	for _, p := range points {
+		for _, system := range []System{WGS84, GCJ02, BD09} {
+			if back := mustTo(t, mustTo(t, p, system), p.System); !near(back, p, 1e-7) {
+				t.Fatalf("%v(%s) via %s: got %v", p, p.System, system, back)
+			}
+		}
+	}
+
+	if _, err := New(116.397128, 39.916527, System("wgs-84")).GCJ02(); err != ErrUnknownSystem {
+		t.Fatalf("unknown source system: got %v, want ErrUnknownSystem", err)
+	}
+	if _, err := c.To(System("gcj")); err != ErrUnknownSystem {
+		t.Fatalf("unknown target system: got %v, want ErrUnknownSystem", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	c, err := Parse("113.321124,23.119252", GCJ02)
+	if err != nil || c != New(113.321124, 23.119252, GCJ02) {
+		t.Fatalf("got %v %v", c, err)
+	}
+	if c.String() != "113.321124,23.119252" {
+		t.Fatalf("got %s", c.String())
+	}
+	if _, err := Parse("113.321124", GCJ02); err != ErrInvalidCoordinate {
+		t.Fatalf("got %v, want ErrInvalidCoordinate", err)
+	}
+
+	var res struct {
+		Location Coordinate `json:"location"`
+	}
+	res.Location.System = GCJ02
+	if err := json.Unmarshal([]byte(`{"location":"113.321124,23.119252"}`), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Location != c {
+		t.Fatalf("got %v", res.Location)
+	}
+}
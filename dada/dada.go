@@ -1,13 +1,18 @@
 package dada
 
 import (
+	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/MangoMilk/go-kit/encode"
 	"github.com/MangoMilk/go-kit/encrypt"
-	"github.com/MangoMilk/go-kit/net"
+	"github.com/MangoMilk/go-sdk/money"
+	"github.com/MangoMilk/go-sdk/validate"
+	"io/ioutil"
+	"net/http"
 	"reflect"
 	"sort"
 	"strconv"
@@ -18,25 +23,35 @@ import (
 // 在测试环境，使用统一商户和门店进行发单。
 // 其中，商户id(source_id)：73753，门店编号：11047059。
 const (
-	scheme = "http://"
+	defaultScheme = "https"
 
 	//测试域名
 	testDomain = "newopen.qa.imdada.cn"
 	//线上域名
 	onlineDomain = "newopen.imdada.cn"
 
-	getCityUrl           = "/api/cityCode/list"
-	addOrderUrl          = "/api/order/addOrder"
-	reAddOrderUrl        = "/api/order/reAddOrder"
-	queryDeliverFeeUrl   = "/api/order/queryDeliverFee"
-	addAfterQueryUrl     = "/api/order/addAfterQuery"
-	queryOrderUrl        = "/api/order/status/query"
-	cancelOrderUrl       = "/api/order/formalCancel"
-	confirmOrderGoodsUrl = "/api/order/confirm/goods"
-	addMerchantUrl       = "/merchantApi/merchant/add"
-	addShopUrl           = "/api/shop/add"
-	updateShopUrl        = "/api/shop/update"
-	confirmMessageUrl    = "/api/message/confirm"
+	getCityUrl            = "/api/cityCode/list"
+	addOrderUrl           = "/api/order/addOrder"
+	reAddOrderUrl         = "/api/order/reAddOrder"
+	queryDeliverFeeUrl    = "/api/order/queryDeliverFee"
+	addAfterQueryUrl      = "/api/order/addAfterQuery"
+	queryOrderUrl         = "/api/order/status/query"
+	cancelOrderUrl        = "/api/order/formalCancel"
+	confirmOrderGoodsUrl  = "/api/order/confirm/goods"
+	addMerchantUrl        = "/merchantApi/merchant/add"
+	addShopUrl            = "/api/shop/add"
+	updateShopUrl         = "/api/shop/update"
+	confirmMessageUrl     = "/api/message/confirm"
+	addTipUrl             = "/api/order/addTip"
+	complaintReasonsUrl   = "/api/complaint/reasons"
+	complaintDadaUrl      = "/api/complaint/dada"
+	cancelReasonsUrl      = "/api/order/cancel/reasons"
+	queryBalanceUrl       = "/api/balance/query"
+	rechargeUrl           = "/api/recharge"
+	queryShopUrl          = "/api/shop/detail"
+	appointOrderUrl       = "/api/order/appoint/exist"
+	cancelAppointUrl      = "/api/order/appoint/cancel"
+	appointTransporterUrl = "/api/order/appoint/list/transporter"
 )
 
 type CancelFrom int64
@@ -59,7 +74,9 @@ type Dada struct {
 	AppSecret  string
 	HttpHeader map[string]string
 	SourceID   string
-	Env        Env
+	Env        Env    // 预设环境，决定默认访问的域名
+	Scheme     string // 访问 Env 对应域名使用的协议，为空时使用 https
+	BaseUrl    string // 不为空时替代 Scheme 与 Env 对应的域名，如代理地址或 dadatest.Server.URL
 }
 
 func NewDada(appKey string, appSecret string, sourceID string, env Env) *Dada {
@@ -76,14 +93,22 @@ func (dd *Dada) SetHttpHeader(k string, v string) {
 	dd.HttpHeader[k] = v
 }
 
-func (dd *Dada) genUrl(api string) (url string) {
+func (dd *Dada) genUrl(api string) string {
+	if dd.BaseUrl != "" {
+		return strings.TrimRight(dd.BaseUrl, "/") + api
+	}
+
+	scheme := dd.Scheme
+	if scheme == "" {
+		scheme = defaultScheme
+	}
+
+	domain := onlineDomain
 	if dd.Env == TestEnv {
-		url = scheme + testDomain + api
-	} else {
-		url = scheme + onlineDomain + api
+		domain = testDomain
 	}
 
-	return
+	return scheme + "://" + domain + api
 }
 
 func (dd *Dada) genSign(body interface{}, appSecret string) string {
@@ -147,46 +172,148 @@ func (dd *Dada) genBaseReq(req interface{}) (*baseReq, error) {
 	return &r, nil
 }
 
-type baseRes struct {
-	Status    string      `json:"status"`    // 响应状态，成功为"success"，失败为"fail"
-	Code      int64       `json:"code"`      // 响应返回码，参考接口返回码
-	Msg       string      `json:"msg"`       // 响应描述
-	Result    interface{} `json:"result"`    // 响应结果，JSON对象，详见具体的接口描述
-	ErrorCode int64       `json:"errorCode"` // 错误编码，与code一致
+// Error 达达接口返回 status 不为 success 时的错误
+type Error struct {
+	Status string // 响应状态
+	Code   int64  // 响应返回码，参考接口返回码
+	Msg    string // 响应描述
 }
 
-// ==================== 城市信息 ====================
-type getCityRes struct {
-	CityName string `map:"cityName"`
-	CityCode string `map:"cityCode"`
+func (e *Error) Error() string {
+	return fmt.Sprintf("dada: %s (code %d)", e.Msg, e.Code)
 }
 
-func (dd *Dada) GetCity() (*baseRes, []*getCityRes, error) {
-	ddReq, reqErr := dd.genBaseReq("")
+// 需要特殊处理的接口返回码
+const (
+	CodeOrderNotExist = 2106 // 订单不存在
+	CodeQuoteExpired  = 2108 // 预发布订单号（deliveryNo）不存在或已过期
+)
+
+// isCode err 是否为返回码为 code 的 *Error
+func isCode(err error, code int64) bool {
+	var ddErr *Error
+	return errors.As(err, &ddErr) && ddErr.Code == code
+}
+
+// Response 达达接口响应，T 为 result 的类型
+type Response[T any] struct {
+	Status    string `json:"status"`    // 响应状态，成功为"success"，失败为"fail"
+	Code      int64  `json:"code"`      // 响应返回码，参考接口返回码
+	Msg       string `json:"msg"`       // 响应描述
+	Result    T      `json:"result"`    // 响应结果，JSON对象，详见具体的接口描述
+	ErrorCode int64  `json:"errorCode"` // 错误编码，与code一致
+}
+
+// decodeResponse 解析响应，status 不为 success 时返回 *Error，否则返回原始的 result
+func decodeResponse(data []byte) (json.RawMessage, error) {
+	var ddRes Response[json.RawMessage]
+	if jsonErr := json.Unmarshal(data, &ddRes); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	if ddRes.Status != "success" {
+		return nil, &Error{Status: ddRes.Status, Code: ddRes.Code, Msg: ddRes.Msg}
+	}
+
+	return ddRes.Result, nil
+}
+
+type validator interface {
+	Validate() error
+}
+
+// call 校验请求参数后调用接口：实现了 Validate 的请求调用 Validate，其余按 validate 标签校验
+func (dd *Dada) call(api string, req interface{}) (json.RawMessage, error) {
+	return dd.callContext(context.Background(), api, req)
+}
+
+// callContext 同 call，请求受 ctx 的取消和截止时间约束
+func (dd *Dada) callContext(ctx context.Context, api string, req interface{}) (json.RawMessage, error) {
+	if v, ok := req.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	} else if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	ddReq, reqErr := dd.genBaseReq(req)
 	if reqErr != nil {
-		return nil, nil, reqErr
+		return nil, reqErr
 	}
 
-	data, httpErr := net.HttpPost(dd.genUrl(getCityUrl), *ddReq, dd.HttpHeader, false, "", "")
+	data, httpErr := dd.post(ctx, dd.genUrl(api), ddReq)
 	if httpErr != nil {
-		return nil, nil, httpErr
+		return nil, httpErr
 	}
 
-	var ddRes baseRes
-	if jsonErr := json.Unmarshal(data, &ddRes); jsonErr != nil {
-		return nil, nil, jsonErr
+	return decodeResponse(data)
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+func (dd *Dada) post(ctx context.Context, api string, ddReq *baseReq) ([]byte, error) {
+	body, jsonErr := json.Marshal(ddReq)
+	if jsonErr != nil {
+		return nil, jsonErr
 	}
 
-	result := ddRes.Result.([]interface{})
-	arrLen := len(result)
-	var res = make([]*getCityRes, arrLen)
-	for i := 0; i < arrLen; i++ {
-		var unit getCityRes
-		encode.Map2struct(result[i], &unit)
-		res[i] = &unit
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodPost, api, bytes.NewReader(body))
+	if reqErr != nil {
+		return nil, reqErr
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range dd.HttpHeader {
+		req.Header.Set(k, v)
 	}
 
-	return &ddRes, res, nil
+	resp, httpErr := httpClient.Do(req)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	defer resp.Body.Close()
+
+	res, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		return nil, readErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return res, fmt.Errorf("request fail: http status code is %d", resp.StatusCode)
+	}
+
+	return res, nil
+}
+
+// post 调用接口并将 result 解析为 T
+func post[T any](dd *Dada, api string, req interface{}) (*T, error) {
+	result, callErr := dd.call(api, req)
+	if callErr != nil {
+		return nil, callErr
+	}
+
+	var res T
+	if len(result) > 0 && string(result) != "null" {
+		if jsonErr := json.Unmarshal(result, &res); jsonErr != nil {
+			return nil, jsonErr
+		}
+	}
+
+	return &res, nil
+}
+
+// ==================== 城市信息 ====================
+type GetCityRes struct {
+	CityName string `json:"cityName"` // 城市名称
+	CityCode string `json:"cityCode"` // 城市编码
+}
+
+func (dd *Dada) GetCity() ([]*GetCityRes, error) {
+	res, err := post[[]*GetCityRes](dd, getCityUrl, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return *res, nil
 }
 
 // ==================== 新增订单 ====================
@@ -198,26 +325,26 @@ const (
 )
 
 type AddOrderReq struct {
-	ShopNo          string  `json:"shop_no"`          // 是	门店编号，门店创建后可在门店列表和单页查看
-	OriginID        string  `json:"origin_id"`        // 是	第三方订单ID
-	CityCode        string  `json:"city_code"`        // 是	订单所在城市的code（查看各城市对应的code值）
-	CargoPrice      float64 `json:"cargo_price"`      // 是	订单金额（单位：元）
-	IsPrepay        int64   `json:"is_prepay"`        // 是	是否需要垫付 1:是 0:否 (垫付订单金额，非运费)
-	ReceiverName    string  `json:"receiver_name"`    // 是	收货人姓名
-	ReceiverAddress string  `json:"receiver_address"` // 是	收货人地址
-	ReceiverLat     float64 `json:"receiver_lat"`     // 是	收货人地址纬度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器）
-	ReceiverLng     float64 `json:"receiver_lng"`     // 是	收货人地址经度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器)
-	Callback        string  `json:"callback"`         // 是	回调URL（查看回调说明）
-	CargoWeight     float64 `json:"cargo_weight"`     // 是	订单重量（单位：Kg）
-	ReceiverPhone   string  `json:"receiver_phone"`   // 是	收货人手机号（手机号和座机号必填一项）
-	ReceiverTel     string  `json:"receiver_tel"`     // 否	收货人座机号（手机号和座机号必填一项）
-	Tips            float64 `json:"tips"`             // 否	小费（单位：元，精确小数点后一位）
-	Info            string  `json:"info"`             // 否	订单备注
-	CargoType       int64   `json:"cargo_type"`       // 否	订单商品类型：食品小吃-1,饮料-2,鲜花绿植-3,文印票务-8,便利店-9,水果生鲜-13,同城电商-19, 医药-20,蛋糕-21,酒品-24,小商品市场-25,服装-26,汽修零配-27,数码家电-28,小龙虾-29,个人-50,火锅-51,个护美妆-53、母婴-55,家居家纺-57,手机-59,家装-61,其他-5
-	CargoNum        int64   `json:"cargo_num"`        // 否	订单商品数量
-	InvoiceTitle    string  `json:"invoice_title"`    // 否	发票抬头
-	OriginMark      string  `json:"origin_mark"`      // 否	订单来源标示（只支持字母，最大长度为10）
-	OriginMarkNo    string  `json:"origin_mark_no"`
+	ShopNo          string     `json:"shop_no" validate:"required"`          // 是	门店编号，门店创建后可在门店列表和单页查看
+	OriginID        string     `json:"origin_id" validate:"required"`        // 是	第三方订单ID
+	CityCode        string     `json:"city_code" validate:"required"`        // 是	订单所在城市的code（查看各城市对应的code值）
+	CargoPrice      money.Yuan `json:"cargo_price" validate:"required"`      // 是	订单金额（单位：元）
+	IsPrepay        int64      `json:"is_prepay"`                            // 是	是否需要垫付 1:是 0:否 (垫付订单金额，非运费)
+	ReceiverName    string     `json:"receiver_name" validate:"required"`    // 是	收货人姓名
+	ReceiverAddress string     `json:"receiver_address" validate:"required"` // 是	收货人地址
+	ReceiverLat     float64    `json:"receiver_lat" validate:"required"`     // 是	收货人地址纬度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器）
+	ReceiverLng     float64    `json:"receiver_lng" validate:"required"`     // 是	收货人地址经度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器)
+	Callback        string     `json:"callback" validate:"required"`         // 是	回调URL（查看回调说明）
+	CargoWeight     float64    `json:"cargo_weight" validate:"required"`     // 是	订单重量（单位：Kg）
+	ReceiverPhone   string     `json:"receiver_phone"`                       // 是	收货人手机号（手机号和座机号必填一项）
+	ReceiverTel     string     `json:"receiver_tel"`                         // 否	收货人座机号（手机号和座机号必填一项）
+	Tips            money.Yuan `json:"tips"`                                 // 否	小费（单位：元，精确小数点后一位）
+	Info            string     `json:"info"`                                 // 否	订单备注
+	CargoType       int64      `json:"cargo_type"`                           // 否	订单商品类型：食品小吃-1,饮料-2,鲜花绿植-3,文印票务-8,便利店-9,水果生鲜-13,同城电商-19, 医药-20,蛋糕-21,酒品-24,小商品市场-25,服装-26,汽修零配-27,数码家电-28,小龙虾-29,个人-50,火锅-51,个护美妆-53、母婴-55,家居家纺-57,手机-59,家装-61,其他-5
+	CargoNum        int64      `json:"cargo_num"`                            // 否	订单商品数量
+	InvoiceTitle    string     `json:"invoice_title"`                        // 否	发票抬头
+	OriginMark      string     `json:"origin_mark" validate:"max=10"`        // 否	订单来源标示（只支持字母，最大长度为10）
+	OriginMarkNo    string     `json:"origin_mark_no" validate:"max=30"`
 	/* 否	订单来源编号，最大长度为30，该字段可以显示在骑士APP订单详情页面，示例：
 	origin_mark_no:"#京东到家#1"
 	达达骑士APP看到的是：#京东到家#1
@@ -236,67 +363,65 @@ type AddOrderReq struct {
 	PickUpPos          string             `json:"pick_up_pos"`           // 否	货架信息,该字段可在骑士APP订单备注中展示
 }
 
-type Product struct {
-	SkuName      string  `json:"sku_name"`       // 是	商品名称，限制长度128
-	SrcProductNo string  `json:"src_product_no"` // 是	商品编码，限制长度64
-	Count        float64 `json:"count"`          // 是	商品数量，精确到小数点后两位
-	Unit         string  `json:"unit"`           // 否	商品单位，默认：件
-}
+// Validate 校验必填参数和长度，收货人手机号和座机号必填一项
+func (req *AddOrderReq) Validate() error {
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
 
-type addOrderRes struct {
-	Distance     float64 `map:"distance"`     // 是 配送距离(单位：米)
-	Fee          float64 `map:"fee"`          // 是 实际运费(单位：元)，运费减去优惠券费用
-	DeliverFee   float64 `map:"deliverFee"`   // 是 运费(单位：元)
-	CouponFee    float64 `map:"couponFee"`    // 否 优惠券费用(单位：元)
-	Tips         float64 `map:"tips"`         // 否 小费(单位：元)
-	InsuranceFee float64 `map:"insuranceFee"` // 否 保价费(单位：元)
+	return checkReceiverContact(req.ReceiverPhone, req.ReceiverTel)
 }
 
-func (dd *Dada) AddOrder(req *AddOrderReq) (*baseRes, *addOrderRes, error) {
-
-	ddReq, reqErr := dd.genBaseReq(req)
-	if reqErr != nil {
-		return nil, nil, reqErr
+func checkReceiverContact(phone, tel string) error {
+	if phone == "" && tel == "" {
+		return &validate.FieldError{Field: "receiver_phone", Rule: "required_without=receiver_tel"}
 	}
 
-	data, httpErr := net.HttpPost(dd.genUrl(addOrderUrl), *ddReq, dd.HttpHeader, false, "", "")
-	if httpErr != nil {
-		return nil, nil, httpErr
-	}
+	return nil
+}
 
-	var ddRes baseRes
-	if jsonErr := json.Unmarshal(data, &ddRes); jsonErr != nil {
-		return nil, nil, jsonErr
-	}
+type Product struct {
+	SkuName      string  `json:"sku_name" validate:"required,max=128"`      // 是	商品名称，限制长度128
+	SrcProductNo string  `json:"src_product_no" validate:"required,max=64"` // 是	商品编码，限制长度64
+	Count        float64 `json:"count" validate:"required"`                 // 是	商品数量，精确到小数点后两位
+	Unit         string  `json:"unit"`                                      // 否	商品单位，默认：件
+}
 
-	var res addOrderRes
-	encode.Map2struct(ddRes.Result, &res)
+type AddOrderRes struct {
+	Distance     float64    `json:"distance"`     // 是 配送距离(单位：米)
+	Fee          money.Yuan `json:"fee"`          // 是 实际运费(单位：元)，运费减去优惠券费用
+	DeliverFee   money.Yuan `json:"deliverFee"`   // 是 运费(单位：元)
+	CouponFee    money.Yuan `json:"couponFee"`    // 否 优惠券费用(单位：元)
+	Tips         money.Yuan `json:"tips"`         // 否 小费(单位：元)
+	InsuranceFee money.Yuan `json:"insuranceFee"` // 否 保价费(单位：元)
+}
 
-	return &ddRes, &res, nil
+func (dd *Dada) AddOrder(req *AddOrderReq) (*AddOrderRes, error) {
+	return post[AddOrderRes](dd, addOrderUrl, req)
 }
 
 // ==================== 订单重发 ====================
 type ReAddOrderReq struct {
-	ShopNo          string  `json:"shop_no"`          // 是	门店编号，门店创建后可在门店列表和单页查看
-	OriginID        string  `json:"origin_id"`        // 是	第三方订单ID
-	CityCode        string  `json:"city_code"`        // 是	订单所在城市的code（查看各城市对应的code值）
-	CargoPrice      float64 `json:"cargo_price"`      // 是	订单金额（单位：元）
-	IsPrepay        int64   `json:"is_prepay"`        // 是	是否需要垫付 1:是 0:否 (垫付订单金额，非运费)
-	ReceiverName    string  `json:"receiver_name"`    // 是	收货人姓名
-	ReceiverAddress string  `json:"receiver_address"` // 是	收货人地址
-	ReceiverLat     float64 `json:"receiver_lat"`     // 是	收货人地址纬度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器）
-	ReceiverLng     float64 `json:"receiver_lng"`     // 是	收货人地址经度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器)
-	Callback        string  `json:"callback"`         // 是	回调URL（查看回调说明）
-	CargoWeight     float64 `json:"cargo_weight"`     // 是	订单重量（单位：Kg）
-	ReceiverPhone   string  `json:"receiver_phone"`   // 是	收货人手机号（手机号和座机号必填一项）
-	ReceiverTel     string  `json:"receiver_tel"`     // 否	收货人座机号（手机号和座机号必填一项）
-	Tips            float64 `json:"tips"`             // 否	小费（单位：元，精确小数点后一位）
-	Info            string  `json:"info"`             // 否	订单备注
-	CargoType       int64   `json:"cargo_type"`       // 否	订单商品类型：食品小吃-1,饮料-2,鲜花绿植-3,文印票务-8,便利店-9,水果生鲜-13,同城电商-19, 医药-20,蛋糕-21,酒品-24,小商品市场-25,服装-26,汽修零配-27,数码家电-28,小龙虾-29,个人-50,火锅-51,个护美妆-53、母婴-55,家居家纺-57,手机-59,家装-61,其他-5
-	CargoNum        int64   `json:"cargo_num"`        // 否	订单商品数量
-	InvoiceTitle    string  `json:"invoice_title"`    // 否	发票抬头
-	OriginMark      string  `json:"origin_mark"`      // 否	订单来源标示（只支持字母，最大长度为10）
-	OriginMarkNo    string  `json:"origin_mark_no"`
+	ShopNo          string     `json:"shop_no" validate:"required"`          // 是	门店编号，门店创建后可在门店列表和单页查看
+	OriginID        string     `json:"origin_id" validate:"required"`        // 是	第三方订单ID
+	CityCode        string     `json:"city_code" validate:"required"`        // 是	订单所在城市的code（查看各城市对应的code值）
+	CargoPrice      money.Yuan `json:"cargo_price" validate:"required"`      // 是	订单金额（单位：元）
+	IsPrepay        int64      `json:"is_prepay"`                            // 是	是否需要垫付 1:是 0:否 (垫付订单金额，非运费)
+	ReceiverName    string     `json:"receiver_name" validate:"required"`    // 是	收货人姓名
+	ReceiverAddress string     `json:"receiver_address" validate:"required"` // 是	收货人地址
+	ReceiverLat     float64    `json:"receiver_lat" validate:"required"`     // 是	收货人地址纬度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器）
+	ReceiverLng     float64    `json:"receiver_lng" validate:"required"`     // 是	收货人地址经度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器)
+	Callback        string     `json:"callback" validate:"required"`         // 是	回调URL（查看回调说明）
+	CargoWeight     float64    `json:"cargo_weight" validate:"required"`     // 是	订单重量（单位：Kg）
+	ReceiverPhone   string     `json:"receiver_phone"`                       // 是	收货人手机号（手机号和座机号必填一项）
+	ReceiverTel     string     `json:"receiver_tel"`                         // 否	收货人座机号（手机号和座机号必填一项）
+	Tips            money.Yuan `json:"tips"`                                 // 否	小费（单位：元，精确小数点后一位）
+	Info            string     `json:"info"`                                 // 否	订单备注
+	CargoType       int64      `json:"cargo_type"`                           // 否	订单商品类型：食品小吃-1,饮料-2,鲜花绿植-3,文印票务-8,便利店-9,水果生鲜-13,同城电商-19, 医药-20,蛋糕-21,酒品-24,小商品市场-25,服装-26,汽修零配-27,数码家电-28,小龙虾-29,个人-50,火锅-51,个护美妆-53、母婴-55,家居家纺-57,手机-59,家装-61,其他-5
+	CargoNum        int64      `json:"cargo_num"`                            // 否	订单商品数量
+	InvoiceTitle    string     `json:"invoice_title"`                        // 否	发票抬头
+	OriginMark      string     `json:"origin_mark" validate:"max=10"`        // 否	订单来源标示（只支持字母，最大长度为10）
+	OriginMarkNo    string     `json:"origin_mark_no" validate:"max=30"`
 	/* 否	订单来源编号，最大长度为30，该字段可以显示在骑士APP订单详情页面，示例：
 	origin_mark_no:"#京东到家#1"
 	达达骑士APP看到的是：#京东到家#1
@@ -315,60 +440,50 @@ type ReAddOrderReq struct {
 	PickUpPos          string             `json:"pick_up_pos"`           // 否	货架信息,该字段可在骑士APP订单备注中展示
 }
 
-type reAddOrderRes struct {
-	Distance     float64 `map:"distance"`     // 是 配送距离(单位：米)
-	Fee          float64 `map:"fee"`          // 是 实际运费(单位：元)，运费减去优惠券费用
-	DeliverFee   float64 `map:"deliverFee"`   // 是 运费(单位：元)
-	CouponFee    float64 `map:"couponFee"`    // 否 优惠券费用(单位：元)
-	Tips         float64 `map:"tips"`         // 否 小费(单位：元)
-	InsuranceFee float64 `map:"insuranceFee"` // 否 保价费(单位：元)
-}
-
-func (dd *Dada) ReAddOrder(req *ReAddOrderReq) (*baseRes, *reAddOrderRes, error) {
-
-	ddReq, reqErr := dd.genBaseReq(req)
-	if reqErr != nil {
-		return nil, nil, reqErr
-	}
-
-	data, httpErr := net.HttpPost(dd.genUrl(reAddOrderUrl), *ddReq, dd.HttpHeader, false, "", "")
-	if httpErr != nil {
-		return nil, nil, httpErr
+// Validate 校验必填参数和长度，收货人手机号和座机号必填一项
+func (req *ReAddOrderReq) Validate() error {
+	if err := validate.Struct(req); err != nil {
+		return err
 	}
 
-	var ddRes baseRes
-	if jsonErr := json.Unmarshal(data, &ddRes); jsonErr != nil {
-		return nil, nil, jsonErr
-	}
+	return checkReceiverContact(req.ReceiverPhone, req.ReceiverTel)
+}
 
-	var res reAddOrderRes
-	encode.Map2struct(ddRes.Result, &res)
+type ReAddOrderRes struct {
+	Distance     float64    `json:"distance"`     // 是 配送距离(单位：米)
+	Fee          money.Yuan `json:"fee"`          // 是 实际运费(单位：元)，运费减去优惠券费用
+	DeliverFee   money.Yuan `json:"deliverFee"`   // 是 运费(单位：元)
+	CouponFee    money.Yuan `json:"couponFee"`    // 否 优惠券费用(单位：元)
+	Tips         money.Yuan `json:"tips"`         // 否 小费(单位：元)
+	InsuranceFee money.Yuan `json:"insuranceFee"` // 否 保价费(单位：元)
+}
 
-	return &ddRes, &res, nil
+func (dd *Dada) ReAddOrder(req *ReAddOrderReq) (*ReAddOrderRes, error) {
+	return post[ReAddOrderRes](dd, reAddOrderUrl, req)
 }
 
 // ==================== 预发布订单 ====================
 type QueryDeliverFeeReq struct {
-	ShopNo          string  `json:"shop_no"`          // 是	门店编号，门店创建后可在门店列表和单页查看
-	OriginID        string  `json:"origin_id"`        // 是	第三方订单ID
-	CityCode        string  `json:"city_code"`        // 是	订单所在城市的code（查看各城市对应的code值）
-	CargoPrice      float64 `json:"cargo_price"`      // 是	订单金额（单位：元）
-	IsPrepay        int64   `json:"is_prepay"`        // 是	是否需要垫付 1:是 0:否 (垫付订单金额，非运费)
-	ReceiverName    string  `json:"receiver_name"`    // 是	收货人姓名
-	ReceiverAddress string  `json:"receiver_address"` // 是	收货人地址
-	ReceiverLat     float64 `json:"receiver_lat"`     // 否	收货人地址纬度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器）
-	ReceiverLng     float64 `json:"receiver_lng"`     // 否	收货人地址经度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器)
-	Callback        string  `json:"callback"`         // 是	回调URL（查看回调说明）
-	CargoWeight     float64 `json:"cargo_weight"`     // 是	订单重量（单位：Kg）
-	ReceiverPhone   string  `json:"receiver_phone"`   // 是	收货人手机号（手机号和座机号必填一项）
-	ReceiverTel     string  `json:"receiver_tel"`     // 否	收货人座机号（手机号和座机号必填一项）
-	Tips            float64 `json:"tips"`             // 否	小费（单位：元，精确小数点后一位）
-	Info            string  `json:"info"`             // 否	订单备注
-	CargoType       int64   `json:"cargo_type"`       // 否	订单商品类型：食品小吃-1,饮料-2,鲜花绿植-3,文印票务-8,便利店-9,水果生鲜-13,同城电商-19, 医药-20,蛋糕-21,酒品-24,小商品市场-25,服装-26,汽修零配-27,数码家电-28,小龙虾-29,个人-50,火锅-51,个护美妆-53、母婴-55,家居家纺-57,手机-59,家装-61,其他-5
-	CargoNum        int64   `json:"cargo_num"`        // 否	订单商品数量
-	InvoiceTitle    string  `json:"invoice_title"`    // 否	发票抬头
-	OriginMark      string  `json:"origin_mark"`      // 否	订单来源标示（只支持字母，最大长度为10）
-	OriginMarkNo    string  `json:"origin_mark_no"`
+	ShopNo          string     `json:"shop_no" validate:"required"`          // 是	门店编号，门店创建后可在门店列表和单页查看
+	OriginID        string     `json:"origin_id" validate:"required"`        // 是	第三方订单ID
+	CityCode        string     `json:"city_code" validate:"required"`        // 是	订单所在城市的code（查看各城市对应的code值）
+	CargoPrice      money.Yuan `json:"cargo_price" validate:"required"`      // 是	订单金额（单位：元）
+	IsPrepay        int64      `json:"is_prepay"`                            // 是	是否需要垫付 1:是 0:否 (垫付订单金额，非运费)
+	ReceiverName    string     `json:"receiver_name" validate:"required"`    // 是	收货人姓名
+	ReceiverAddress string     `json:"receiver_address" validate:"required"` // 是	收货人地址
+	ReceiverLat     float64    `json:"receiver_lat"`                         // 否	收货人地址纬度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器）
+	ReceiverLng     float64    `json:"receiver_lng"`                         // 否	收货人地址经度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器)
+	Callback        string     `json:"callback" validate:"required"`         // 是	回调URL（查看回调说明）
+	CargoWeight     float64    `json:"cargo_weight" validate:"required"`     // 是	订单重量（单位：Kg）
+	ReceiverPhone   string     `json:"receiver_phone"`                       // 是	收货人手机号（手机号和座机号必填一项）
+	ReceiverTel     string     `json:"receiver_tel"`                         // 否	收货人座机号（手机号和座机号必填一项）
+	Tips            money.Yuan `json:"tips"`                                 // 否	小费（单位：元，精确小数点后一位）
+	Info            string     `json:"info"`                                 // 否	订单备注
+	CargoType       int64      `json:"cargo_type"`                           // 否	订单商品类型：食品小吃-1,饮料-2,鲜花绿植-3,文印票务-8,便利店-9,水果生鲜-13,同城电商-19, 医药-20,蛋糕-21,酒品-24,小商品市场-25,服装-26,汽修零配-27,数码家电-28,小龙虾-29,个人-50,火锅-51,个护美妆-53、母婴-55,家居家纺-57,手机-59,家装-61,其他-5
+	CargoNum        int64      `json:"cargo_num"`                            // 否	订单商品数量
+	InvoiceTitle    string     `json:"invoice_title"`                        // 否	发票抬头
+	OriginMark      string     `json:"origin_mark" validate:"max=10"`        // 否	订单来源标示（只支持字母，最大长度为10）
+	OriginMarkNo    string     `json:"origin_mark_no" validate:"max=30"`
 	/* 否	订单来源编号，最大长度为30，该字段可以显示在骑士APP订单详情页面，示例：
 	origin_mark_no:"#京东到家#1"
 	达达骑士APP看到的是：#京东到家#1
@@ -387,36 +502,27 @@ type QueryDeliverFeeReq struct {
 	PickUpPos          string             `json:"pick_up_pos"`
 }
 
-type queryDeliverFeeRes struct {
-	Distance     float64 `map:"distance"`     // 是 配送距离(单位：米)
-	Fee          float64 `map:"fee"`          // 是 实际运费(单位：元)，运费减去优惠券费用
-	DeliverFee   float64 `map:"deliverFee"`   // 是 运费(单位：元)
-	CouponFee    float64 `map:"couponFee"`    // 否 优惠券费用(单位：元)
-	Tips         float64 `map:"tips"`         // 否 小费(单位：元)
-	InsuranceFee float64 `map:"insuranceFee"` // 否 保价费(单位：元)
-	DeliveryNo   string  `map:"deliveryNo"`   // 是	平台订单号，有效期3分钟
-}
-
-func (dd *Dada) QueryDeliverFee(req *QueryDeliverFeeReq) (*baseRes, *queryDeliverFeeRes, error) {
-	ddReq, reqErr := dd.genBaseReq(req)
-	if reqErr != nil {
-		return nil, nil, reqErr
-	}
-
-	data, httpErr := net.HttpPost(dd.genUrl(queryDeliverFeeUrl), *ddReq, dd.HttpHeader, false, "", "")
-	if httpErr != nil {
-		return nil, nil, httpErr
+// Validate 校验必填参数和长度，收货人手机号和座机号必填一项
+func (req *QueryDeliverFeeReq) Validate() error {
+	if err := validate.Struct(req); err != nil {
+		return err
 	}
 
-	var ddRes baseRes
-	if jsonErr := json.Unmarshal(data, &ddRes); jsonErr != nil {
-		return nil, nil, jsonErr
-	}
+	return checkReceiverContact(req.ReceiverPhone, req.ReceiverTel)
+}
 
-	var res queryDeliverFeeRes
-	encode.Map2struct(ddRes.Result, &res)
+type QueryDeliverFeeRes struct {
+	Distance     float64    `json:"distance"`     // 是 配送距离(单位：米)
+	Fee          money.Yuan `json:"fee"`          // 是 实际运费(单位：元)，运费减去优惠券费用
+	DeliverFee   money.Yuan `json:"deliverFee"`   // 是 运费(单位：元)
+	CouponFee    money.Yuan `json:"couponFee"`    // 否 优惠券费用(单位：元)
+	Tips         money.Yuan `json:"tips"`         // 否 小费(单位：元)
+	InsuranceFee money.Yuan `json:"insuranceFee"` // 否 保价费(单位：元)
+	DeliveryNo   string     `json:"deliveryNo"`   // 是	平台订单号，有效期3分钟
+}
 
-	return &ddRes, &res, nil
+func (dd *Dada) QueryDeliverFee(req *QueryDeliverFeeReq) (*QueryDeliverFeeRes, error) {
+	return post[QueryDeliverFeeRes](dd, queryDeliverFeeUrl, req)
 }
 
 // ==================== 预发布订单后直接下单 ====================
@@ -424,23 +530,9 @@ type AddAfterQueryReq struct {
 	DeliveryNo string `json:"deliveryNo"` // 是	平台订单号
 }
 
-func (dd *Dada) AddAfterQuery(deliveryNo string) (*baseRes, error) {
-	ddReq, reqErr := dd.genBaseReq(&AddAfterQueryReq{DeliveryNo: deliveryNo})
-	if reqErr != nil {
-		return nil, reqErr
-	}
-
-	data, httpErr := net.HttpPost(dd.genUrl(addAfterQueryUrl), *ddReq, dd.HttpHeader, false, "", "")
-	if httpErr != nil {
-		return nil, httpErr
-	}
-
-	var ddRes baseRes
-	if jsonErr := json.Unmarshal(data, &ddRes); jsonErr != nil {
-		return nil, jsonErr
-	}
-
-	return &ddRes, nil
+func (dd *Dada) AddAfterQuery(deliveryNo string) error {
+	_, err := dd.call(addAfterQueryUrl, &AddAfterQueryReq{DeliveryNo: deliveryNo})
+	return err
 }
 
 // ==================== 订单详情查询（一分钟更新一次） ====================
@@ -463,55 +555,37 @@ type QueryOrderReq struct {
 	OrderID string `json:"order_id"` // 是	第三方订单ID
 }
 
-type queryOrderRes struct {
-	OrderId          string  `map:"orderId"`          //	第三方订单编号
-	StatusCode       float64 `map:"statusCode"`       // 	订单状态(待接单＝1,待取货＝2,配送中＝3,已完成＝4,已取消＝5, 指派单=8,妥投异常之物品返回中=9, 妥投异常之物品返回完成=10, 骑士到店=100,创建达达运单失败=1000 可参考文末的状态说明）
-	StatusMsg        string  `map:"statusMsg"`        // 	订单状态
-	TransporterName  string  `map:"transporterName"`  // 	骑手姓名
-	TransporterPhone string  `map:"transporterPhone"` //	骑手电话
-	TransporterLng   string  `map:"transporterLng"`   //	骑手经度
-	TransporterLat   string  `map:"transporterLat"`   //	骑手纬度
-	DeliveryFee      float64 `map:"deliveryFee"`      //	配送费,单位为元
-	Tips             float64 `map:"tips"`             //	小费,单位为元
-	CouponFee        float64 `map:"couponFee"`        //	优惠券费用,单位为元
-	InsuranceFee     float64 `map:"insuranceFee"`     //	保价费,单位为元
-	ActualFee        float64 `map:"actualFee"`        //	实际支付费用,单位为元
-	Distance         float64 `map:"distance"`         //	配送距离,单位为米
-	CreateTime       string  `map:"createTime"`       //	发单时间
-	AcceptTime       string  `map:"acceptTime"`       //	接单时间,若未接单,则为空
-	FetchTime        string  `map:"fetchTime"`        //	取货时间,若未取货,则为空
-	FinishTime       string  `map:"finishTime"`       //	送达时间,若未送达,则为空
-	CancelTime       string  `map:"cancelTime"`       //	取消时间,若未取消,则为空
-	OrderFinishCode  string  `map:"orderFinishCode"`  //	收货码
-	DeductFee        float64 `map:"deductFee"`        //	违约金
-	ReceiptUrl       string  `map:"receiptUrl"`       //
-	SupplierName     string  `map:"supplierName"`     //	店铺名
-	SupplierAddress  string  `map:"supplierAddress"`  //	店铺地址
-	SupplierPhone    string  `map:"supplierPhone"`    //	店铺联系手机
-	SupplierLat      string  `map:"supplierLat"`      //	店铺纬度
-	SupplierLng      string  `map:"supplierLng"`      //	店铺经度
-}
-
-func (dd *Dada) QueryOrder(orderID string) (*baseRes, *queryOrderRes, error) {
-	ddReq, reqErr := dd.genBaseReq(&QueryOrderReq{OrderID: orderID})
-	if reqErr != nil {
-		return nil, nil, reqErr
-	}
-
-	data, httpErr := net.HttpPost(dd.genUrl(queryOrderUrl), *ddReq, dd.HttpHeader, false, "", "")
-	if httpErr != nil {
-		return nil, nil, httpErr
-	}
-
-	var ddRes baseRes
-	if jsonErr := json.Unmarshal(data, &ddRes); jsonErr != nil {
-		return nil, nil, jsonErr
-	}
-
-	var res queryOrderRes
-	encode.Map2struct(ddRes.Result, &res)
+type QueryOrderRes struct {
+	OrderId          string          `json:"orderId"`          //	第三方订单编号
+	StatusCode       OrderStatusCode `json:"statusCode"`       // 	订单状态(待接单＝1,待取货＝2,配送中＝3,已完成＝4,已取消＝5, 指派单=8,妥投异常之物品返回中=9, 妥投异常之物品返回完成=10, 骑士到店=100,创建达达运单失败=1000 可参考文末的状态说明）
+	StatusMsg        string          `json:"statusMsg"`        // 	订单状态
+	TransporterName  string          `json:"transporterName"`  // 	骑手姓名
+	TransporterPhone string          `json:"transporterPhone"` //	骑手电话
+	TransporterLng   string          `json:"transporterLng"`   //	骑手经度
+	TransporterLat   string          `json:"transporterLat"`   //	骑手纬度
+	DeliveryFee      money.Yuan      `json:"deliveryFee"`      //	配送费,单位为元
+	Tips             money.Yuan      `json:"tips"`             //	小费,单位为元
+	CouponFee        money.Yuan      `json:"couponFee"`        //	优惠券费用,单位为元
+	InsuranceFee     money.Yuan      `json:"insuranceFee"`     //	保价费,单位为元
+	ActualFee        money.Yuan      `json:"actualFee"`        //	实际支付费用,单位为元
+	Distance         float64         `json:"distance"`         //	配送距离,单位为米
+	CreateTime       string          `json:"createTime"`       //	发单时间
+	AcceptTime       string          `json:"acceptTime"`       //	接单时间,若未接单,则为空
+	FetchTime        string          `json:"fetchTime"`        //	取货时间,若未取货,则为空
+	FinishTime       string          `json:"finishTime"`       //	送达时间,若未送达,则为空
+	CancelTime       string          `json:"cancelTime"`       //	取消时间,若未取消,则为空
+	OrderFinishCode  string          `json:"orderFinishCode"`  //	收货码
+	DeductFee        money.Yuan      `json:"deductFee"`        //	违约金
+	ReceiptUrl       string          `json:"receiptUrl"`       //
+	SupplierName     string          `json:"supplierName"`     //	店铺名
+	SupplierAddress  string          `json:"supplierAddress"`  //	店铺地址
+	SupplierPhone    string          `json:"supplierPhone"`    //	店铺联系手机
+	SupplierLat      string          `json:"supplierLat"`      //	店铺纬度
+	SupplierLng      string          `json:"supplierLng"`      //	店铺经度
+}
 
-	return &ddRes, &res, nil
+func (dd *Dada) QueryOrder(orderID string) (*QueryOrderRes, error) {
+	return post[QueryOrderRes](dd, queryOrderUrl, &QueryOrderReq{OrderID: orderID})
 }
 
 // ==================== 取消订单 ====================
@@ -531,194 +605,131 @@ const (
 )
 
 type CancelOrderReq struct {
-	OrderID        string         `json:"order_id"`         // 是	第三方订单ID
-	CancelReasonID CancelReasonID `json:"cancel_reason_id"` // 是	取消原因ID
-	CancelReason   string         `json:"cancel_reason"`    // 是	取消原因(当取消原因ID为其他时，此字段必填)
+	OrderID        string         `json:"order_id" validate:"required"`         // 是	第三方订单ID
+	CancelReasonID CancelReasonID `json:"cancel_reason_id" validate:"required"` // 是	取消原因ID
+	CancelReason   string         `json:"cancel_reason"`                        // 是	取消原因(当取消原因ID为其他时，此字段必填)
 }
 
-type cancelOrderRes struct {
-	DeductFee float64 `map:"deduct_fee"` //	违约金
-}
-
-func (dd *Dada) CancelOrder(req *CancelOrderReq) (*baseRes, *cancelOrderRes, error) {
-	ddReq, reqErr := dd.genBaseReq(req)
-	if reqErr != nil {
-		return nil, nil, reqErr
+// Validate 校验必填参数，取消原因ID为其他时取消原因必填
+func (req *CancelOrderReq) Validate() error {
+	if err := validate.Struct(req); err != nil {
+		return err
 	}
 
-	data, httpErr := net.HttpPost(dd.genUrl(cancelOrderUrl), *ddReq, dd.HttpHeader, false, "", "")
-	if httpErr != nil {
-		return nil, nil, httpErr
+	if req.CancelReasonID == CancelReasonOther && req.CancelReason == "" {
+		return &validate.FieldError{Field: "cancel_reason", Rule: "required_if=cancel_reason_id 10000"}
 	}
 
-	var ddRes baseRes
-	if jsonErr := json.Unmarshal(data, &ddRes); jsonErr != nil {
-		return nil, nil, jsonErr
-	}
+	return nil
+}
 
-	var res cancelOrderRes
-	encode.Map2struct(ddRes.Result, &res)
+type CancelOrderRes struct {
+	DeductFee money.Yuan `json:"deduct_fee"` //	违约金
+}
 
-	return &ddRes, &res, nil
+func (dd *Dada) CancelOrder(req *CancelOrderReq) (*CancelOrderRes, error) {
+	return post[CancelOrderRes](dd, cancelOrderUrl, req)
 }
 
 // ==================== 注册商户 ====================
 type AddMerchantReq struct {
-	Mobile            string `json:"mobile"`             // 是	注册商户手机号,用于登陆商户后台
-	CityName          string `json:"city_name"`          // 是	商户城市名称(如,上海)
-	EnterpriseName    string `json:"enterprise_name"`    // 是	企业全称
-	EnterpriseAddress string `json:"enterprise_address"` // 是	企业地址
-	ContactName       string `json:"contact_name"`       // 是	联系人姓名
-	ContactPhone      string `json:"contact_phone"`      // 是	联系人电话
-	Email             string `json:"email"`              // 是	邮箱地址
+	Mobile            string `json:"mobile" validate:"required"`             // 是	注册商户手机号,用于登陆商户后台
+	CityName          string `json:"city_name" validate:"required"`          // 是	商户城市名称(如,上海)
+	EnterpriseName    string `json:"enterprise_name" validate:"required"`    // 是	企业全称
+	EnterpriseAddress string `json:"enterprise_address" validate:"required"` // 是	企业地址
+	ContactName       string `json:"contact_name" validate:"required"`       // 是	联系人姓名
+	ContactPhone      string `json:"contact_phone" validate:"required"`      // 是	联系人电话
+	Email             string `json:"email" validate:"required"`              // 是	邮箱地址
 }
 
-func (dd *Dada) AddMerchant(req *AddMerchantReq) (*baseRes, string, error) {
-	ddReq, reqErr := dd.genBaseReq(req)
-	if reqErr != nil {
-		return nil, "", reqErr
-	}
-
-	data, httpErr := net.HttpPost(dd.genUrl(addMerchantUrl), *ddReq, dd.HttpHeader, false, "", "")
-	if httpErr != nil {
-		return nil, "", httpErr
-	}
-
-	var ddRes baseRes
-	if jsonErr := json.Unmarshal(data, &ddRes); jsonErr != nil {
-		return nil, "", jsonErr
+// AddMerchant 注册商户，返回商户编号（source_id）
+func (dd *Dada) AddMerchant(req *AddMerchantReq) (string, error) {
+	res, err := post[int64](dd, addMerchantUrl, req)
+	if err != nil {
+		return "", err
 	}
 
-	return &ddRes, strconv.FormatInt(ddRes.Result.(int64), 10), nil
+	return strconv.FormatInt(*res, 10), nil
 }
 
 // ==================== 创建门店 ====================
 type AddShopReq struct {
-	Shops []Shop
+	Shops []Shop `validate:"required"`
 }
 
 type Shop struct {
-	StationName    string  `json:"station_name"`    //	是	门店名称
-	Business       int64   `json:"business"`        //	是	业务类型(食品小吃-1,饮料-2,鲜花绿植-3,文印票务-8,便利店-9,水果生鲜-13,同城电商-19, 医药-20,蛋糕-21,酒品-24,小商品市场-25,服装-26,汽修零配-27,数码家电-28,小龙虾-29,个人-50,火锅-51,个护美妆-53、母婴-55,家居家纺-57,手机-59,家装-61,其他-5)
-	CityName       string  `json:"city_name"`       //	是	城市名称(如,上海)
-	AreaName       string  `json:"area_name"`       //	是	区域名称(如,浦东新区)
-	StationAddress string  `json:"station_address"` //	是	门店地址
-	Lng            float64 `json:"lng"`             //	是	门店经度
-	Lat            float64 `json:"lat"`             //	是	门店纬度
-	ContactName    string  `json:"contact_name"`    //	是	联系人姓名
-	Phone          string  `json:"phone"`           //	是	联系人电话
-	OriginShopID   string  `json:"origin_shop_id"`  //	否	门店编码,可自定义,但必须唯一;若不填写,则系统自动生成
-	IdCard         string  `json:"id_card"`         //	否	联系人身份证
-	Username       string  `json:"username"`        //	否	达达商家app账号(若不需要登陆app,则不用设置)
-	Password       string  `json:"password"`        //	否	达达商家app密码(若不需要登陆app,则不用设置)
-}
-
-type addShopRes struct {
-	Success     float64       `map:"success"`
-	SuccessList []successItem `map:"successList"`
-	FailedList  []failedItem  `map:"failedList"`
-}
-
-type successItem struct {
-	Phone          string  `map:"phone"`
-	Business       float64 `map:"business"`
-	Lng            float64 `map:"lng"`
-	Lat            float64 `map:"lat"`
-	StationName    string  `map:"stationName"`
-	OriginShopId   string  `map:"originShopId"`
-	ContactName    string  `map:"contactName"`
-	StationAddress string  `map:"stationAddress"`
-	CityName       string  `map:"cityName"`
-	AreaName       string  `map:"areaName"`
-}
-
-type failedItem struct {
-	ShopNo   string `map:"shopNo"`
-	Msg      string `map:"msg"`
-	ShopName string `map:"shopName"`
-}
-
-func (dd *Dada) AddShop(req *AddShopReq) (*baseRes, *addShopRes, error) {
-	ddReq, reqErr := dd.genBaseReq(req.Shops)
-	if reqErr != nil {
-		return nil, nil, reqErr
-	}
-
-	data, httpErr := net.HttpPost(dd.genUrl(addShopUrl), *ddReq, dd.HttpHeader, false, "", "")
-	if httpErr != nil {
-		return nil, nil, reqErr
-	}
-
-	var ddRes baseRes
-	if jsonErr := json.Unmarshal(data, &ddRes); jsonErr != nil {
-		return nil, nil, reqErr
-	}
+	StationName    string  `json:"station_name" validate:"required"`    //	是	门店名称
+	Business       int64   `json:"business" validate:"required"`        //	是	业务类型(食品小吃-1,饮料-2,鲜花绿植-3,文印票务-8,便利店-9,水果生鲜-13,同城电商-19, 医药-20,蛋糕-21,酒品-24,小商品市场-25,服装-26,汽修零配-27,数码家电-28,小龙虾-29,个人-50,火锅-51,个护美妆-53、母婴-55,家居家纺-57,手机-59,家装-61,其他-5)
+	CityName       string  `json:"city_name" validate:"required"`       //	是	城市名称(如,上海)
+	AreaName       string  `json:"area_name" validate:"required"`       //	是	区域名称(如,浦东新区)
+	StationAddress string  `json:"station_address" validate:"required"` //	是	门店地址
+	Lng            float64 `json:"lng" validate:"required"`             //	是	门店经度
+	Lat            float64 `json:"lat" validate:"required"`             //	是	门店纬度
+	ContactName    string  `json:"contact_name" validate:"required"`    //	是	联系人姓名
+	Phone          string  `json:"phone" validate:"required"`           //	是	联系人电话
+	OriginShopID   string  `json:"origin_shop_id"`                      //	否	门店编码,可自定义,但必须唯一;若不填写,则系统自动生成
+	IdCard         string  `json:"id_card"`                             //	否	联系人身份证
+	Username       string  `json:"username"`                            //	否	达达商家app账号(若不需要登陆app,则不用设置)
+	Password       string  `json:"password"`                            //	否	达达商家app密码(若不需要登陆app,则不用设置)
+}
 
-	var res addShopRes
+type AddShopRes struct {
+	Success     int64                `json:"success"`     // 成功导入门店的数量
+	SuccessList []AddShopSuccessItem `json:"successList"` // 成功导入的门店
+	FailedList  []AddShopFailedItem  `json:"failedList"`  // 导入失败的门店
+}
 
-	if ddRes.Result != nil {
-		res.Success = ddRes.Result.(map[string]interface{})["success"].(float64)
+type AddShopSuccessItem struct {
+	Phone          string  `json:"phone"`          // 联系人电话
+	Business       int64   `json:"business"`       // 业务类型
+	Lng            float64 `json:"lng"`            // 门店经度
+	Lat            float64 `json:"lat"`            // 门店纬度
+	StationName    string  `json:"stationName"`    // 门店名称
+	OriginShopId   string  `json:"originShopId"`   // 门店编码
+	ContactName    string  `json:"contactName"`    // 联系人姓名
+	StationAddress string  `json:"stationAddress"` // 门店地址
+	CityName       string  `json:"cityName"`       // 城市名称
+	AreaName       string  `json:"areaName"`       // 区域名称
+}
 
-		successList := ddRes.Result.(map[string]interface{})["successList"]
-		if successList != nil {
-			successRes := make([]successItem, len(successList.([]interface{})))
-			for i := 0; i < len(successList.([]interface{})); i++ {
-				encode.Map2struct(successList.([]interface{})[i], &successRes[i])
-			}
-			res.SuccessList = successRes
-		}
+type AddShopFailedItem struct {
+	ShopNo   string `json:"shopNo"`   // 门店编码
+	Msg      string `json:"msg"`      // 失败原因
+	ShopName string `json:"shopName"` // 门店名称
+}
 
-		failedList := ddRes.Result.(map[string]interface{})["failedList"]
-		if failedList != nil {
-			failRes := make([]failedItem, len(failedList.([]interface{})))
-			for i := 0; i < len(failedList.([]interface{})); i++ {
-				encode.Map2struct(failedList.([]interface{})[i], &failRes[i])
-			}
-			res.FailedList = failRes
-		}
+func (dd *Dada) AddShop(req *AddShopReq) (*AddShopRes, error) {
+	if err := validate.Struct(req); err != nil {
+		return nil, err
 	}
 
-	return &ddRes, &res, nil
+	return post[AddShopRes](dd, addShopUrl, req.Shops)
 }
 
 // ==================== 更新门店 ====================
 type UpdateShopReq struct {
-	OriginShopID   string  `json:"origin_shop_id"`  //	是	门店编码
-	NewShopID      string  `json:"new_shop_id"`     //	否	新的门店编码
-	StationName    string  `json:"station_name"`    //	否	门店名称
-	Business       int64   `json:"business"`        //	否	业务类型(食品小吃-1,饮料-2,鲜花绿植-3,文印票务-8,便利店-9,水果生鲜-13,同城电商-19, 医药-20,蛋糕-21,酒品-24,小商品市场-25,服装-26,汽修零配-27,数码家电-28,小龙虾-29,个人-50,火锅-51,个护美妆-53、母婴-55,家居家纺-57,手机-59,家装-61,其他-5)
-	CityName       string  `json:"city_name"`       //	否	城市名称(如,上海)
-	AreaName       string  `json:"area_name"`       //	否	区域名称(如,浦东新区)
-	StationAddress string  `json:"station_address"` //	否	门店地址
-	Lng            float64 `json:"lng"`             //	否	门店经度
-	Lat            float64 `json:"lat"`             //	否	门店纬度
-	ContactName    string  `json:"contact_name"`    //	否	联系人姓名
-	Phone          string  `json:"phone"`           //	否	联系人电话
-	Status         int64   `json:"status"`          //	否	门店状态（1-门店激活，0-门店下线）
-}
-
-func (dd *Dada) UpdateShop(req *UpdateShopReq) (*baseRes, error) {
-	ddReq, reqErr := dd.genBaseReq(req)
-	if reqErr != nil {
-		return nil, reqErr
-	}
-
-	data, httpErr := net.HttpPost(dd.genUrl(updateShopUrl), *ddReq, dd.HttpHeader, false, "", "")
-	if httpErr != nil {
-		return nil, httpErr
-	}
-
-	var ddRes baseRes
-	if jsonErr := json.Unmarshal(data, &ddRes); jsonErr != nil {
-		return nil, jsonErr
-	}
+	OriginShopID   string  `json:"origin_shop_id" validate:"required"` //	是	门店编码
+	NewShopID      string  `json:"new_shop_id"`                        //	否	新的门店编码
+	StationName    string  `json:"station_name"`                       //	否	门店名称
+	Business       int64   `json:"business"`                           //	否	业务类型(食品小吃-1,饮料-2,鲜花绿植-3,文印票务-8,便利店-9,水果生鲜-13,同城电商-19, 医药-20,蛋糕-21,酒品-24,小商品市场-25,服装-26,汽修零配-27,数码家电-28,小龙虾-29,个人-50,火锅-51,个护美妆-53、母婴-55,家居家纺-57,手机-59,家装-61,其他-5)
+	CityName       string  `json:"city_name"`                          //	否	城市名称(如,上海)
+	AreaName       string  `json:"area_name"`                          //	否	区域名称(如,浦东新区)
+	StationAddress string  `json:"station_address"`                    //	否	门店地址
+	Lng            float64 `json:"lng"`                                //	否	门店经度
+	Lat            float64 `json:"lat"`                                //	否	门店纬度
+	ContactName    string  `json:"contact_name"`                       //	否	联系人姓名
+	Phone          string  `json:"phone"`                              //	否	联系人电话
+	Status         int64   `json:"status"`                             //	否	门店状态（1-门店激活，0-门店下线）
+}
 
-	return &ddRes, nil
+func (dd *Dada) UpdateShop(req *UpdateShopReq) error {
+	_, err := dd.call(updateShopUrl, req)
+	return err
 }
 
 // ==================== 订单状态通知 ====================
 type OrderNotifyReq struct {
-	ClientID     string          `json:"client_id" validate:"required"`    //	是	返回达达运单号，默认为空
+	ClientID     string          `json:"client_id"`                        //	是	返回达达运单号，默认为空；创建达达运单失败（1000）时为空
 	OrderID      string          `json:"order_id" validate:"required"`     //	是	添加订单接口中的origin_id值
 	OrderStatus  OrderStatusCode `json:"order_status" validate:"required"` //	是	订单状态(待接单＝1,待取货＝2,配送中＝3,已完成＝4,已取消＝5, 指派单=8,妥投异常之物品返回中=9, 妥投异常之物品返回完成=10, 骑士到店=100,创建达达运单失败=1000 可参考文末的状态说明）
 	CancelReason string          `json:"cancel_reason"`                    //	是	订单取消原因,其他状态下默认值为空字符串
@@ -731,6 +742,15 @@ type OrderNotifyReq struct {
 	FinishCode   string          `json:"finish_code"`                      //	否	收货码
 }
 
+// Validate 校验回调的必填参数，创建达达运单失败（1000）的回调没有 client_id
+func (req *OrderNotifyReq) Validate() error {
+	if req.ClientID == "" && req.OrderStatus != OrderStatusCodeAddOrderFail {
+		return &validate.FieldError{Field: "client_id", Rule: "required"}
+	}
+
+	return validate.Struct(req)
+}
+
 func CheckNotifySign(clientID, orderID, updateTime, sign string) error {
 	args := []string{clientID, orderID, updateTime}
 	sort.Strings(args)
@@ -742,7 +762,7 @@ func CheckNotifySign(clientID, orderID, updateTime, sign string) error {
 	}
 
 	if md5Res != sign {
-		return fmt.Errorf("check signature fail")
+		return ErrNotifySignature
 	}
 
 	return nil
@@ -778,6 +798,11 @@ type TransporterNotifyReq struct {
 	CancelReason string `json:"cancelReason"`                // 是 骑士取消原因
 }
 
+// Validate 校验回调的必填参数
+func (req *TransporterNotifyReq) Validate() error {
+	return validate.Struct(req)
+}
+
 // ==================== 通知确认 ====================
 type IsConfirmTransporterCancel int64
 
@@ -787,15 +812,24 @@ const (
 )
 
 type NotifyConfirmReq struct {
-	OrderId     string                     `json:"orderId"`     // 是 商家第三方订单号
-	DadaOrderId int64                      `json:"dadaOrderId"` // 否 达达订单号
-	IsConfirm   IsConfirmTransporterCancel `json:"isConfirm"`   // 是 0:不同意，1:表示同意
+	OrderId     string                     `json:"orderId" validate:"required"` // 是 商家第三方订单号
+	DadaOrderId int64                      `json:"dadaOrderId"`                 // 否 达达订单号
+	IsConfirm   IsConfirmTransporterCancel `json:"isConfirm"`                   // 是 0:不同意，1:表示同意
+}
+
+func (dd *Dada) ConfirmMessage(req *NotifyConfirmReq) error {
+	return dd.ConfirmMessageContext(context.Background(), req)
 }
 
-func (dd *Dada) ConfirmMessage(req *NotifyConfirmReq) (*baseRes, error) {
+// ConfirmMessageContext 同 ConfirmMessage，请求受 ctx 的取消和截止时间约束
+func (dd *Dada) ConfirmMessageContext(ctx context.Context, req *NotifyConfirmReq) error {
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
 	reqByte, jsonMarshalErr := json.Marshal(req)
 	if jsonMarshalErr != nil {
-		return nil, jsonMarshalErr
+		return jsonMarshalErr
 	}
 
 	messageReq := &NotifyReq{
@@ -803,44 +837,195 @@ func (dd *Dada) ConfirmMessage(req *NotifyConfirmReq) (*baseRes, error) {
 		MessageType: NotifyMessageTypeTransporterCancel,
 	}
 
-	ddReq, reqErr := dd.genBaseReq(messageReq)
-	if reqErr != nil {
-		return nil, reqErr
-	}
+	_, err := dd.callContext(ctx, confirmMessageUrl, messageReq)
+	return err
+}
 
-	data, httpErr := net.HttpPost(dd.genUrl(confirmMessageUrl), *ddReq, dd.HttpHeader, false, "", "")
-	if httpErr != nil {
-		return nil, httpErr
-	}
+// ==================== 确认妥投异常之物品返回完成 ====================
+type ConfirmOrderGoodsReq struct {
+	OrderID string `json:"order_id"` // 是	第三方订单ID
+}
 
-	var ddRes baseRes
-	if jsonErr := json.Unmarshal(data, &ddRes); jsonErr != nil {
-		return nil, jsonErr
+func (dd *Dada) ConfirmOrderGoods(orderID string) error {
+	_, err := dd.call(confirmOrderGoodsUrl, &ConfirmOrderGoodsReq{OrderID: orderID})
+	return err
+}
+
+// ==================== 增加小费 ====================
+type AddTipReq struct {
+	OrderID  string     `json:"order_id" validate:"required"`  // 是	第三方订单编号
+	Tips     money.Yuan `json:"tips" validate:"required"`      // 是	小费金额(精确到小数点后一位，单位：元)，每次追加的小费需大于上一次的小费
+	CityCode string     `json:"city_code" validate:"required"` // 是	订单城市区号
+	Info     string     `json:"info" validate:"max=512"`       // 否	备注(字段最大长度：512)
+}
+
+// AddTip 订单待接单时增加小费，重复调用以最后一次的小费为准
+func (dd *Dada) AddTip(req *AddTipReq) error {
+	_, err := dd.call(addTipUrl, req)
+	return err
+}
+
+// ==================== 取消订单原因 ====================
+type CancelReason struct {
+	ID     CancelReasonID `json:"id"`     // 理由编号
+	Reason string         `json:"reason"` // 取消理由
+}
+
+// GetCancelReasons 获取取消订单原因列表，CancelOrder 的 cancel_reason_id 取自此列表
+func (dd *Dada) GetCancelReasons() ([]*CancelReason, error) {
+	res, err := post[[]*CancelReason](dd, cancelReasonsUrl, "")
+	if err != nil {
+		return nil, err
 	}
 
-	return &ddRes, nil
+	return *res, nil
 }
 
-// ==================== 确认妥投异常之物品返回完成 ====================
-type ConfirmOrderGoodsReq struct {
-	OrderID string `json:"order_id"` // 是	第三方订单ID
+// ==================== 商家投诉达达 ====================
+type ComplaintReasonID int64
+
+type ComplaintReason struct {
+	ID     ComplaintReasonID `json:"id"`     // 投诉原因编号
+	Reason string            `json:"reason"` // 投诉原因
 }
 
-func (dd *Dada) ConfirmOrderGoods(orderID string) (*baseRes, error) {
-	ddReq, reqErr := dd.genBaseReq(&ConfirmOrderGoodsReq{OrderID: orderID})
-	if reqErr != nil {
-		return nil, reqErr
+// GetComplaintReasons 获取商家投诉达达的原因列表
+func (dd *Dada) GetComplaintReasons() ([]*ComplaintReason, error) {
+	res, err := post[[]*ComplaintReason](dd, complaintReasonsUrl, "")
+	if err != nil {
+		return nil, err
 	}
 
-	data, httpErr := net.HttpPost(dd.genUrl(confirmOrderGoodsUrl), *ddReq, dd.HttpHeader, false, "", "")
-	if httpErr != nil {
-		return nil, httpErr
+	return *res, nil
+}
+
+type ComplaintDadaReq struct {
+	OrderID  string            `json:"order_id" validate:"required"`  // 是	第三方订单编号
+	ReasonID ComplaintReasonID `json:"reason_id" validate:"required"` // 是	投诉原因ID
+}
+
+// ComplaintDada 商家投诉配送员
+func (dd *Dada) ComplaintDada(req *ComplaintDadaReq) error {
+	_, err := dd.call(complaintDadaUrl, req)
+	return err
+}
+
+// ==================== 查询账户余额 ====================
+type BalanceCategory int64
+
+const (
+	BalanceCategoryDeliver   = BalanceCategory(1) // 运费账户
+	BalanceCategoryRedPacket = BalanceCategory(2) // 红包账户
+	BalanceCategoryAll       = BalanceCategory(3) // 所有
+)
+
+type QueryBalanceReq struct {
+	Category BalanceCategory `json:"category"` // 是	查询运费账户类型（1：运费账户；2：红包账户，3：所有）
+}
+
+type QueryBalanceRes struct {
+	DeliverBalance   money.Yuan `json:"deliverBalance"`   // 运费账户余额(单位：元)
+	RedPacketBalance money.Yuan `json:"redPacketBalance"` // 红包账户余额(单位：元)
+}
+
+func (dd *Dada) QueryBalance(category BalanceCategory) (*QueryBalanceRes, error) {
+	return post[QueryBalanceRes](dd, queryBalanceUrl, &QueryBalanceReq{Category: category})
+}
+
+// ==================== 获取充值链接 ====================
+type RechargeCategory string
+
+const (
+	RechargeCategoryPC = RechargeCategory("PC") // PC端充值
+	RechargeCategoryH5 = RechargeCategory("H5") // H5端充值
+)
+
+type RechargeReq struct {
+	Amount    money.Yuan       `json:"amount" validate:"required"`   // 是	充值金额（单位：元，可以精确到分）
+	Category  RechargeCategory `json:"category" validate:"required"` // 是	生成链接适应场景（PC、H5）
+	NotifyUrl string           `json:"notify_url"`                   // 否	支付成功后跳转的页面（支付宝在支付成功后可以跳转到某个指定的页面，微信支付不支持）
+}
+
+// Recharge 获取充值链接
+func (dd *Dada) Recharge(req *RechargeReq) (string, error) {
+	res, err := post[string](dd, rechargeUrl, req)
+	if err != nil {
+		return "", err
 	}
 
-	var ddRes baseRes
-	if jsonErr := json.Unmarshal(data, &ddRes); jsonErr != nil {
-		return nil, jsonErr
+	return *res, nil
+}
+
+// ==================== 门店详情 ====================
+type QueryShopReq struct {
+	OriginShopID string `json:"origin_shop_id"` // 是	门店编码
+}
+
+type ShopStatus int64
+
+const (
+	ShopStatusOffline = ShopStatus(0) // 门店下线
+	ShopStatusActive  = ShopStatus(1) // 门店激活
+)
+
+type QueryShopRes struct {
+	OriginShopID   string     `json:"origin_shop_id"`  // 门店编码
+	StationName    string     `json:"station_name"`    // 门店名称
+	Business       int64      `json:"business"`        // 业务类型
+	CityName       string     `json:"city_name"`       // 城市名称
+	AreaName       string     `json:"area_name"`       // 区域名称
+	StationAddress string     `json:"station_address"` // 门店地址
+	Lng            float64    `json:"lng"`             // 门店经度
+	Lat            float64    `json:"lat"`             // 门店纬度
+	ContactName    string     `json:"contact_name"`    // 联系人姓名
+	Phone          string     `json:"phone"`           // 联系人电话
+	IdCard         string     `json:"id_card"`         // 联系人身份证
+	Status         ShopStatus `json:"status"`          // 门店状态（1-门店激活，0-门店下线）
+}
+
+func (dd *Dada) QueryShop(originShopID string) (*QueryShopRes, error) {
+	return post[QueryShopRes](dd, queryShopUrl, &QueryShopReq{OriginShopID: originShopID})
+}
+
+// ==================== 追加订单 ====================
+type AppointOrderReq struct {
+	OrderID       string `json:"order_id" validate:"required"`       // 是	追加的第三方订单ID
+	TransporterID int64  `json:"transporter_id" validate:"required"` // 是	追加的配送员ID
+	ShopNo        string `json:"shop_no" validate:"required"`        // 是	追加订单的门店编码
+}
+
+// AppointOrder 将待接单的订单追加给门店可追加的配送员
+func (dd *Dada) AppointOrder(req *AppointOrderReq) error {
+	_, err := dd.call(appointOrderUrl, req)
+	return err
+}
+
+type CancelAppointReq struct {
+	OrderID string `json:"order_id"` // 是	追加的第三方订单ID
+}
+
+// CancelAppoint 取消追加，订单恢复为待接单
+func (dd *Dada) CancelAppoint(orderID string) error {
+	_, err := dd.call(cancelAppointUrl, &CancelAppointReq{OrderID: orderID})
+	return err
+}
+
+type AppointTransporterReq struct {
+	ShopNo string `json:"shop_no"` // 是	门店编码
+}
+
+type AppointTransporter struct {
+	ID     int64  `json:"id"`      // 配送员ID
+	Name   string `json:"name"`    // 配送员姓名
+	CityID int64  `json:"city_id"` // 配送员城市ID
+}
+
+// GetAppointTransporters 查询门店可追加订单的配送员
+func (dd *Dada) GetAppointTransporters(shopNo string) ([]*AppointTransporter, error) {
+	res, err := post[[]*AppointTransporter](dd, appointTransporterUrl, &AppointTransporterReq{ShopNo: shopNo})
+	if err != nil {
+		return nil, err
 	}
 
-	return &ddRes, nil
+	return *res, nil
 }
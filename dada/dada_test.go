@@ -1,7 +1,14 @@
 package dada
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/MangoMilk/go-sdk/coordinate"
+	"github.com/MangoMilk/go-sdk/money"
+	"github.com/MangoMilk/go-sdk/validate"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -29,13 +36,10 @@ func TestMain(m *testing.M) {
 }
 
 func TestGetCity(t *testing.T) {
-	ddRes, res, err := dada.GetCity()
+	res, err := dada.GetCity()
 	if err != nil {
 		fmt.Println(err)
 	} else {
-		if ddRes.Code != 0 || ddRes.Status != "success" {
-			fmt.Println(ddRes)
-		}
 		fmt.Println(res)
 		fmt.Println(res[0].CityCode, res[0].CityName)
 	}
@@ -43,29 +47,25 @@ func TestGetCity(t *testing.T) {
 
 func TestAddOrder(t *testing.T) {
 	req := AddOrderReq{
-		ShopNo:             shopNo,                             // 是	门店编号，门店创建后可在门店列表和单页查看
-		OriginID:           "1626071471",                       // 是	第三方订单ID
-		CityCode:           "020",                              // 是	订单所在城市的code（查看各城市对应的code值）
-		CargoPrice:         10.00,                              // 是	订单金额（单位：元）
-		IsPrepay:           0,                                  // 是	是否需要垫付 1:是 0:否 (垫付订单金额，非运费)
-		ReceiverName:       "vv",                               // 是	收货人姓名
-		ReceiverAddress:    "广州市天河区花城广场",                       // 是	收货人地址
-		ReceiverLat:        23.123641,                          // 是	收货人地址纬度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器）
-		ReceiverLng:        113.345769,                         // 是	收货人地址经度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器)
-		Callback:           "http://127.0.0.1/xx/xx/xx/notify", // 是	回调URL（查看回调说明）
-		CargoWeight:        0.5,                                // 是	订单重量（单位：Kg）
-		ReceiverPhone:      "11111111111",                      // 是	收货人手机号（手机号和座机号必填一项）
+		ShopNo:             shopNo,                              // 是	门店编号，门店创建后可在门店列表和单页查看
+		OriginID:           "1626071471",                        // 是	第三方订单ID
+		CityCode:           "020",                               // 是	订单所在城市的code（查看各城市对应的code值）
+		CargoPrice:         money.MustParseYuan("10.00").Yuan(), // 是	订单金额（单位：元）
+		IsPrepay:           0,                                   // 是	是否需要垫付 1:是 0:否 (垫付订单金额，非运费)
+		ReceiverName:       "vv",                                // 是	收货人姓名
+		ReceiverAddress:    "广州市天河区花城广场",                        // 是	收货人地址
+		ReceiverLat:        23.123641,                           // 是	收货人地址纬度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器）
+		ReceiverLng:        113.345769,                          // 是	收货人地址经度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器)
+		Callback:           "http://127.0.0.1/xx/xx/xx/notify",  // 是	回调URL（查看回调说明）
+		CargoWeight:        0.5,                                 // 是	订单重量（单位：Kg）
+		ReceiverPhone:      "11111111111",                       // 是	收货人手机号（手机号和座机号必填一项）
 		IsFinishCodeNeeded: IsFinishCodeNeededYes,
 	}
 
-	ddRes, res, err := dada.AddOrder(&req)
+	res, err := dada.AddOrder(&req)
 	if err != nil {
 		fmt.Println(err)
 	} else {
-		if ddRes.Code != 0 || ddRes.Status != "success" {
-			fmt.Println(ddRes)
-		}
-
 		fmt.Println(res)
 	}
 
@@ -73,13 +73,13 @@ func TestAddOrder(t *testing.T) {
 
 func TestQueryDeliverFee(t *testing.T) {
 	req := QueryDeliverFeeReq{
-		ShopNo:          shopNo,          // 是	门店编号，门店创建后可在门店列表和单页查看
-		OriginID:        "1621578228000", // 是	第三方订单ID
-		CityCode:        "020",           // 是	订单所在城市的code（查看各城市对应的code值）
-		CargoPrice:      10.00,           // 是	订单金额（单位：元）
-		IsPrepay:        0,               // 是	是否需要垫付 1:是 0:否 (垫付订单金额，非运费)
-		ReceiverName:    "vv",            // 是	收货人姓名
-		ReceiverAddress: "广州市天河区花城广场",    // 是	收货人地址
+		ShopNo:          shopNo,                              // 是	门店编号，门店创建后可在门店列表和单页查看
+		OriginID:        "1621578228000",                     // 是	第三方订单ID
+		CityCode:        "020",                               // 是	订单所在城市的code（查看各城市对应的code值）
+		CargoPrice:      money.MustParseYuan("10.00").Yuan(), // 是	订单金额（单位：元）
+		IsPrepay:        0,                                   // 是	是否需要垫付 1:是 0:否 (垫付订单金额，非运费)
+		ReceiverName:    "vv",                                // 是	收货人姓名
+		ReceiverAddress: "广州市天河区花城广场",                        // 是	收货人地址
 		//ReceiverLat:     23.123641,                         // 是	收货人地址纬度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器）
 		//ReceiverLng:     113.345769,                        // 是	收货人地址经度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器)
 		Callback:      "http://127.0.0.1/xx/xx/notify", // 是	回调URL（查看回调说明）
@@ -87,26 +87,19 @@ func TestQueryDeliverFee(t *testing.T) {
 		ReceiverPhone: "11111111111",                   // 是	收货人手机号（手机号和座机号必填一项）
 	}
 
-	ddRes, res, err := dada.QueryDeliverFee(&req)
+	res, err := dada.QueryDeliverFee(&req)
 	if err != nil {
 		fmt.Println(err)
 	} else {
-		if ddRes.Code != 0 || ddRes.Status != "success" {
-			fmt.Println(ddRes)
-		}
-
 		fmt.Println(res)
 	}
 }
 
 func TestQueryOrder(t *testing.T) {
-	ddRes, res, err := dada.QueryOrder("1621506287000")
+	res, err := dada.QueryOrder("1621506287000")
 	if err != nil {
 		fmt.Println(err)
 	} else {
-		if ddRes.Code != 0 || ddRes.Status != "success" {
-			fmt.Println(ddRes)
-		}
 		fmt.Println(res)
 	}
 }
@@ -117,14 +110,10 @@ func TestCancelOrder(t *testing.T) {
 		CancelReasonID: CancelReasonNotNeed,
 	}
 
-	ddRes, res, err := dada.CancelOrder(&req)
+	res, err := dada.CancelOrder(&req)
 	if err != nil {
 		fmt.Println(err)
 	} else {
-		if ddRes.Code != 0 || ddRes.Status != "success" {
-			fmt.Println(ddRes)
-		}
-
 		fmt.Println(res)
 	}
 }
@@ -163,14 +152,10 @@ func TestAddShop(t *testing.T) {
 		},
 	}
 
-	ddRes, res, err := dada.AddShop(&req)
+	res, err := dada.AddShop(&req)
 	if err != nil {
 		fmt.Println(err)
 	} else {
-		if ddRes.Code != 0 || ddRes.Status != "success" {
-			fmt.Println(ddRes)
-		}
-
 		fmt.Println(res)
 		//fmt.Println(res.FailedList)
 		//fmt.Println(res.SuccessList)
@@ -191,57 +176,255 @@ func TestUpdateShop(t *testing.T) {
 		OriginShopID:   "10000001",
 	}
 
-	ddRes, err := dada.UpdateShop(&req)
-	if err != nil {
+	if err := dada.UpdateShop(&req); err != nil {
 		fmt.Println(err)
-	} else {
-		if ddRes.Code != 0 || ddRes.Status != "success" {
-			fmt.Println(ddRes)
-		}
-
-		fmt.Println(ddRes)
-		//fmt.Println(res.FailedList)
-		//fmt.Println(res.SuccessList)
 	}
 }
 
 func TestReAddOrder(t *testing.T) {
 	req := ReAddOrderReq{
-		ShopNo:             shopNo,                             // 是	门店编号，门店创建后可在门店列表和单页查看
-		OriginID:           "1626071471",                       // 是	第三方订单ID
-		CityCode:           "020",                              // 是	订单所在城市的code（查看各城市对应的code值）
-		CargoPrice:         10.00,                              // 是	订单金额（单位：元）
-		IsPrepay:           0,                                  // 是	是否需要垫付 1:是 0:否 (垫付订单金额，非运费)
-		ReceiverName:       "vv",                               // 是	收货人姓名
-		ReceiverAddress:    "广州市天河区花城广场",                       // 是	收货人地址
-		ReceiverLat:        23.123641,                          // 是	收货人地址纬度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器）
-		ReceiverLng:        113.345769,                         // 是	收货人地址经度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器)
-		Callback:           "http://127.0.0.1/v1/xx/xx/notify", // 是	回调URL（查看回调说明）
-		CargoWeight:        0.5,                                // 是	订单重量（单位：Kg）
-		ReceiverPhone:      "11111111111",                      // 是	收货人手机号（手机号和座机号必填一项）
+		ShopNo:             shopNo,                              // 是	门店编号，门店创建后可在门店列表和单页查看
+		OriginID:           "1626071471",                        // 是	第三方订单ID
+		CityCode:           "020",                               // 是	订单所在城市的code（查看各城市对应的code值）
+		CargoPrice:         money.MustParseYuan("10.00").Yuan(), // 是	订单金额（单位：元）
+		IsPrepay:           0,                                   // 是	是否需要垫付 1:是 0:否 (垫付订单金额，非运费)
+		ReceiverName:       "vv",                                // 是	收货人姓名
+		ReceiverAddress:    "广州市天河区花城广场",                        // 是	收货人地址
+		ReceiverLat:        23.123641,                           // 是	收货人地址纬度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器）
+		ReceiverLng:        113.345769,                          // 是	收货人地址经度（高德坐标系，若是其他地图经纬度需要转化成高德地图经纬度，高德地图坐标拾取器)
+		Callback:           "http://127.0.0.1/v1/xx/xx/notify",  // 是	回调URL（查看回调说明）
+		CargoWeight:        0.5,                                 // 是	订单重量（单位：Kg）
+		ReceiverPhone:      "11111111111",                       // 是	收货人手机号（手机号和座机号必填一项）
 		IsFinishCodeNeeded: IsFinishCodeNeededYes,
 	}
 
-	ddRes, res, err := dada.ReAddOrder(&req)
+	res, err := dada.ReAddOrder(&req)
 	if err != nil {
 		fmt.Println(err)
 	} else {
-		if ddRes.Code != 0 || ddRes.Status != "success" {
-			fmt.Println(ddRes)
-		}
-
 		fmt.Println(res)
 	}
 
 }
 
 func TestConfirmOrderGoods(t *testing.T) {
-	ddRes, err := dada.ConfirmOrderGoods("")
-	if err != nil {
+	if err := dada.ConfirmOrderGoods(""); err != nil {
 		fmt.Println(err)
-	} else {
-		if ddRes.Code != 0 || ddRes.Status != "success" {
-			fmt.Println(ddRes)
+	}
+}
+
+func TestDecodeResponse(t *testing.T) {
+	result, err := decodeResponse([]byte(`{"status":"success","code":0,"msg":"成功","result":{"distance":1200.5,"fee":9.8,"deliverFee":10.8,"couponFee":1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res QueryDeliverFeeRes
+	if jsonErr := json.Unmarshal(result, &res); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if res.Distance != 1200.5 || res.Fee.String() != "9.80" || res.DeliverFee.String() != "10.80" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	_, err = decodeResponse([]byte(`{"status":"fail","code":2003,"msg":"签名错误","result":""}`))
+	ddErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("got %v, want *Error", err)
+	}
+	if ddErr.Code != 2003 || ddErr.Msg != "签名错误" {
+		t.Fatalf("unexpected error %+v", ddErr)
+	}
+}
+
+// fakeApi 按接口路径返回固定应答的本地服务，记录每个接口收到的业务参数
+func fakeApi(t *testing.T, results map[string]string) (*Dada, map[string]string) {
+	bodies := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, ok := results[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		var req baseReq
+		if jsonErr := json.NewDecoder(r.Body).Decode(&req); jsonErr != nil || req.SourceID != sourceID {
+			t.Errorf("%s got request %+v, %v", r.URL.Path, req, jsonErr)
 		}
+		bodies[r.URL.Path] = req.Body
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprintf(w, `{"status":"success","code":0,"msg":"成功","result":%s}`, result)
+	}))
+	t.Cleanup(server.Close)
+
+	dd := NewDada("appKey", "appSecret", sourceID, TestEnv)
+	dd.BaseUrl = server.URL
+
+	return dd, bodies
+}
+
+func TestGetCancelReasons(t *testing.T) {
+	dd, _ := fakeApi(t, map[string]string{
+		cancelReasonsUrl: `[{"id":1,"reason":"没有配送员接单"},{"id":10000,"reason":"其他"}]`,
+	})
+
+	res, err := dd.GetCancelReasons()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || res[0].ID != 1 || res[0].Reason != "没有配送员接单" || res[1].ID != 10000 {
+		t.Fatalf("unexpected cancel reasons %+v", res)
+	}
+}
+
+func TestQueryBalance(t *testing.T) {
+	dd, bodies := fakeApi(t, map[string]string{
+		queryBalanceUrl: `{"deliverBalance":100.5,"redPacketBalance":20}`,
+	})
+
+	res, err := dd.QueryBalance(BalanceCategoryAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.DeliverBalance.Fen() != 10050 || res.RedPacketBalance.Fen() != 2000 {
+		t.Fatalf("unexpected balance %+v", res)
+	}
+	if bodies[queryBalanceUrl] != `{"category":3}` {
+		t.Fatalf("unexpected body %s", bodies[queryBalanceUrl])
+	}
+}
+
+func TestQueryShop(t *testing.T) {
+	dd, bodies := fakeApi(t, map[string]string{
+		queryShopUrl: `{"origin_shop_id":"11047059","station_name":"永旺梦乐城","business":19,"city_name":"广州","lng":113.3,"lat":23.0,"status":1}`,
+	})
+
+	res, err := dd.QueryShop(shopNo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.OriginShopID != shopNo || res.StationName != "永旺梦乐城" || res.Business != 19 || res.Lng != 113.3 || res.Status != ShopStatusActive {
+		t.Fatalf("unexpected shop %+v", res)
+	}
+	if bodies[queryShopUrl] != `{"origin_shop_id":"11047059"}` {
+		t.Fatalf("unexpected body %s", bodies[queryShopUrl])
+	}
+}
+
+func TestSimulateOnline(t *testing.T) {
+	online := NewDada("", "", sourceID, OnlineEnv)
+	if err := online.SimulateAccept("1626071471"); err != ErrSimulateOnline {
+		t.Fatalf("got %v, want ErrSimulateOnline", err)
+	}
+
+	if err := (&Dada{}).SimulateAccept("1626071471"); err != ErrSimulateOnline {
+		t.Fatalf("zero value Dada: got %v, want ErrSimulateOnline", err)
+	}
+
+	proxied := NewDada("", "", sourceID, TestEnv)
+	proxied.BaseUrl = "https://" + onlineDomain
+	if err := proxied.SimulateFinish("1626071471"); err != ErrSimulateOnline {
+		t.Fatalf("online base url: got %v, want ErrSimulateOnline", err)
+	}
+}
+
+func TestGenUrl(t *testing.T) {
+	dd := NewDada("", "", sourceID, TestEnv)
+	if url := dd.genUrl(addOrderUrl); url != "https://newopen.qa.imdada.cn/api/order/addOrder" {
+		t.Fatalf("got %s", url)
+	}
+
+	dd.Env = OnlineEnv
+	dd.Scheme = "http"
+	if url := dd.genUrl(addOrderUrl); url != "http://newopen.imdada.cn/api/order/addOrder" {
+		t.Fatalf("got %s", url)
+	}
+
+	dd.BaseUrl = "http://127.0.0.1:8080/dada/"
+	if url := dd.genUrl(addOrderUrl); url != "http://127.0.0.1:8080/dada/api/order/addOrder" {
+		t.Fatalf("got %s", url)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dd := NewDada("", "", sourceID, TestEnv)
+	dd.BaseUrl = "http://127.0.0.1:0"
+
+	req := AddOrderReq{
+		ShopNo:          shopNo,
+		OriginID:        "1626071471",
+		CityCode:        "020",
+		CargoPrice:      money.MustParseYuan("10.00").Yuan(),
+		ReceiverName:    "vv",
+		ReceiverAddress: "广州市天河区花城广场",
+		ReceiverLat:     23.123641,
+		ReceiverLng:     113.345769,
+		Callback:        "http://127.0.0.1/xx/xx/xx/notify",
+		CargoWeight:     0.5,
+		ReceiverTel:     "020-12345678",
+		OriginMark:      "JDDJ",
+	}
+	if err := req.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var fieldErr *validate.FieldError
+	invalid := req
+	invalid.ReceiverTel = ""
+	if _, err := dd.AddOrder(&invalid); !errors.As(err, &fieldErr) || fieldErr.Field != "receiver_phone" {
+		t.Fatalf("want receiver_phone error before request, got %v", err)
+	}
+
+	invalid = req
+	invalid.OriginMark = "JDDJJDDJJDDJ"
+	if err := invalid.Validate(); !errors.As(err, &fieldErr) || fieldErr.Field != "origin_mark" {
+		t.Fatalf("want origin_mark error, got %v", err)
+	}
+
+	invalid = req
+	invalid.ProductList = []Product{{SkuName: "苹果", Count: 1}}
+	if err := invalid.Validate(); !errors.As(err, &fieldErr) || fieldErr.Field != "product_list[0].src_product_no" {
+		t.Fatalf("want src_product_no error, got %v", err)
+	}
+
+	cancel := CancelOrderReq{OrderID: "1626071471", CancelReasonID: CancelReasonOther}
+	if _, err := dd.CancelOrder(&cancel); !errors.As(err, &fieldErr) || fieldErr.Field != "cancel_reason" {
+		t.Fatalf("want cancel_reason error, got %v", err)
+	}
+
+	if err := dd.AddTip(&AddTipReq{OrderID: "1626071471", CityCode: "020"}); !errors.As(err, &fieldErr) || fieldErr.Field != "tips" {
+		t.Fatalf("want tips error, got %v", err)
+	}
+}
+
+func TestReceiverLocation(t *testing.T) {
+	gps := coordinate.New(113.340262, 23.126033, coordinate.WGS84)
+
+	var req AddOrderReq
+	if err := req.SetReceiverLocation(gps); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := gps.GCJ02()
+	if loc := req.ReceiverLocation(); loc != want {
+		t.Fatalf("got %v, want %v", loc, want)
+	}
+	if req.ReceiverLng == gps.Lng || req.ReceiverLat == gps.Lat {
+		t.Fatal("wgs84 location should be converted to gcj02")
+	}
+
+	shop := Shop{}
+	if err := shop.SetLocation(coordinate.New(113.345769, 23.123641, coordinate.GCJ02)); err != nil {
+		t.Fatal(err)
+	}
+	if shop.Lng != 113.345769 || shop.Lat != 23.123641 {
+		t.Fatalf("gcj02 location should be kept, got %v", shop.Location())
+	}
+
+	if err := shop.SetLocation(coordinate.New(1, 2, coordinate.System("baidu"))); err != coordinate.ErrUnknownSystem {
+		t.Fatalf("got %v, want ErrUnknownSystem", err)
+	}
+	if shop.Lng != 113.345769 || shop.Lat != 23.123641 {
+		t.Fatal("unknown system should not change location")
 	}
 }
@@ -0,0 +1,313 @@
+package dadatest
+
+import (
+	"github.com/MangoMilk/go-sdk/dada"
+	"github.com/MangoMilk/go-sdk/money"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	appKey    = "dada8888888888888"
+	appSecret = "0123456789abcdef0123456789abcdef"
+	sourceID  = "73753"
+	shopNo    = "11047059"
+)
+
+// notifyRecorder 使用 dada.OrderNotifyHandler 接收回调并记录状态
+type notifyRecorder struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []dada.OrderStatusCode
+}
+
+func newNotifyRecorder() *notifyRecorder {
+	n := &notifyRecorder{}
+	handler := dada.NewOrderNotifyHandler(nil)
+	record := func(req *dada.OrderNotifyReq) error {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		n.statuses = append(n.statuses, req.OrderStatus)
+		return nil
+	}
+	handler.OnWaitToReceive = record
+	handler.OnWaitToTake = record
+	handler.OnDelivering = record
+	handler.OnDone = record
+	handler.OnCanceled = record
+	n.Server = httptest.NewServer(handler)
+
+	return n
+}
+
+func (n *notifyRecorder) assert(t *testing.T, want ...dada.OrderStatusCode) {
+	t.Helper()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.statuses) != len(want) {
+		t.Fatalf("callbacks %v, want %v", n.statuses, want)
+	}
+	for i := range want {
+		if n.statuses[i] != want[i] {
+			t.Fatalf("callbacks %v, want %v", n.statuses, want)
+		}
+	}
+}
+
+func orderReq(orderID, callback string) *dada.AddOrderReq {
+	return &dada.AddOrderReq{
+		ShopNo:          shopNo,
+		OriginID:        orderID,
+		CityCode:        "020",
+		CargoPrice:      money.MustParseYuan("10.00").Yuan(),
+		ReceiverName:    "vv",
+		ReceiverAddress: "广州市天河区花城广场",
+		ReceiverLat:     23.123641,
+		ReceiverLng:     113.345769,
+		Callback:        callback,
+		CargoWeight:     0.5,
+		ReceiverPhone:   "11111111111",
+	}
+}
+
+func TestOrderDelivery(t *testing.T) {
+	srv := NewServer(appKey, appSecret)
+	defer srv.Close()
+	notify := newNotifyRecorder()
+	defer notify.Close()
+
+	dd := srv.Client(sourceID)
+
+	wrong := srv.Client(sourceID)
+	wrong.AppSecret = "wrong"
+	if _, err := wrong.AddOrder(orderReq("O1", notify.URL)); err == nil {
+		t.Fatal("wrong signature should fail")
+	} else if ddErr, ok := err.(*dada.Error); !ok || ddErr.Code != CodeSignature {
+		t.Fatalf("got %v, want signature error", err)
+	}
+
+	res, err := dd.AddOrder(orderReq("O1", notify.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Fee.String() != "10.00" {
+		t.Fatalf("unexpected fee %v", res.Fee)
+	}
+	if _, err := dd.AddOrder(orderReq("O1", notify.URL)); err == nil {
+		t.Fatal("duplicate order should fail")
+	}
+
+	if err := dd.SimulateFinish("O1"); err == nil {
+		t.Fatal("finish before accept should fail")
+	}
+	for _, step := range []func(string) error{dd.SimulateAccept, dd.SimulateFetch, dd.SimulateFinish} {
+		if err := step("O1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	order, err := dd.QueryOrder("O1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.StatusCode != dada.OrderStatusCodeDone || order.TransporterName == "" || order.FinishTime == "" {
+		t.Fatalf("unexpected order %+v", order)
+	}
+
+	notify.assert(t,
+		dada.OrderStatusCodeWaitToReceive,
+		dada.OrderStatusCodeWaitToTake,
+		dada.OrderStatusCodeDelivering,
+		dada.OrderStatusCodeDone,
+	)
+}
+
+func TestQuoteCancelAndReAdd(t *testing.T) {
+	srv := NewServer(appKey, appSecret)
+	defer srv.Close()
+	notify := newNotifyRecorder()
+	defer notify.Close()
+
+	dd := srv.Client(sourceID)
+
+	quoteReq := dada.QueryDeliverFeeReq(*orderReq("O2", notify.URL))
+	quote, err := dd.QueryDeliverFee(&quoteReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.DeliveryNo == "" {
+		t.Fatal("missing deliveryNo")
+	}
+
+	srv.ExpireQuote(quote.DeliveryNo)
+	if err := dd.AddAfterQuery(quote.DeliveryNo); err == nil {
+		t.Fatal("expired deliveryNo should fail")
+	}
+
+	quote, err = dd.QueryDeliverFee(&quoteReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dd.AddAfterQuery(quote.DeliveryNo); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dd.SimulateAccept("O2"); err != nil {
+		t.Fatal(err)
+	}
+	cancelRes, err := dd.CancelOrder(&dada.CancelOrderReq{OrderID: "O2", CancelReasonID: dada.CancelReasonNotNeed, CancelReason: "不需要了"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelRes.DeductFee.String() != "2.00" {
+		t.Fatalf("unexpected deduct fee %v", cancelRes.DeductFee)
+	}
+
+	reAddReq := dada.ReAddOrderReq(*orderReq("O2", notify.URL))
+	if _, err := dd.ReAddOrder(&reAddReq); err != nil {
+		t.Fatal(err)
+	}
+	if order, _ := srv.Order("O2"); order.Status != dada.OrderStatusCodeWaitToReceive {
+		t.Fatalf("unexpected status %v", order.Status)
+	}
+
+	notify.assert(t,
+		dada.OrderStatusCodeWaitToReceive,
+		dada.OrderStatusCodeWaitToTake,
+		dada.OrderStatusCodeCanceled,
+		dada.OrderStatusCodeWaitToReceive,
+	)
+}
+
+func TestShopAndMerchant(t *testing.T) {
+	srv := NewServer(appKey, appSecret)
+	defer srv.Close()
+
+	dd := srv.Client(sourceID)
+
+	shop := dada.Shop{StationName: "永旺梦乐城", Business: 19, CityName: "广州", AreaName: "番禺区", StationAddress: "亚运大道1号", Lng: 113.3, Lat: 23.0, ContactName: "D先生", Phone: "13412345678", OriginShopID: "S1"}
+	res, err := dd.AddShop(&dada.AddShopReq{Shops: []dada.Shop{shop, shop}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success != 1 || len(res.SuccessList) != 1 || len(res.FailedList) != 1 {
+		t.Fatalf("unexpected add shop result %+v", res)
+	}
+
+	if err := dd.UpdateShop(&dada.UpdateShopReq{OriginShopID: "S1", NewShopID: "S2", Status: int64(dada.ShopStatusOffline)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dd.QueryShop("S1"); err == nil {
+		t.Fatal("renamed shop should not exist")
+	}
+	detail, err := dd.QueryShop("S2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.StationName != shop.StationName || detail.Status != dada.ShopStatusOffline {
+		t.Fatalf("unexpected shop %+v", detail)
+	}
+
+	merchant := dada.AddMerchantReq{
+		Mobile:            "13412345678",
+		CityName:          "广州",
+		EnterpriseName:    "测试",
+		EnterpriseAddress: "亚运大道1号",
+		ContactName:       "D先生",
+		ContactPhone:      "13412345678",
+		Email:             "d@example.com",
+	}
+	if _, err := dd.AddMerchant(&dada.AddMerchantReq{Mobile: "13412345678", EnterpriseName: "测试"}); err == nil {
+		t.Fatal("incomplete merchant should fail validation")
+	}
+	merchantID, err := dd.AddMerchant(&merchant)
+	if err != nil || merchantID == "" {
+		t.Fatalf("add merchant %q %v", merchantID, err)
+	}
+}
+
+func TestQuoteManager(t *testing.T) {
+	srv := NewServer(appKey, appSecret)
+	defer srv.Close()
+	notify := newNotifyRecorder()
+	defer notify.Close()
+
+	dd := srv.Client(sourceID)
+	quotes := dd.NewQuoteManager()
+
+	req := dada.QueryDeliverFeeReq(*orderReq("O3", notify.URL))
+	other := req
+	other.ShopNo = "11047060"
+	other.Tips = money.MustParseYuan("1.00").Yuan()
+
+	cheapest, err := quotes.Cheapest(&req, &other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cheapest.Req.ShopNo != shopNo {
+		t.Fatalf("cheapest quote from %s", cheapest.Req.ShopNo)
+	}
+	again, err := quotes.Quote(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.DeliveryNo != cheapest.DeliveryNo {
+		t.Fatal("valid quote should be cached")
+	}
+
+	// deliveryNo 在服务端失效后改为直接发单
+	srv.ExpireQuote(cheapest.DeliveryNo)
+	if _, err := quotes.Book(&req); err != nil {
+		t.Fatal(err)
+	}
+	if order, ok := srv.Order("O3"); !ok || order.Status != dada.OrderStatusCodeWaitToReceive {
+		t.Fatalf("order not published: %+v", order)
+	}
+
+	if _, err := dd.CancelOrder(&dada.CancelOrderReq{OrderID: "O3", CancelReasonID: dada.CancelReasonNotNeed}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 本地过期的报价不再使用，已取消的订单重新发布
+	quotes.TTL = time.Nanosecond
+	if _, err := quotes.Quote(&req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := quotes.Book(&req); err != nil {
+		t.Fatal(err)
+	}
+
+	// 有效报价通过 AddAfterQuery 下单
+	quotes.TTL = dada.DefaultQuoteTTL
+	if _, err := dd.CancelOrder(&dada.CancelOrderReq{OrderID: "O3", CancelReasonID: dada.CancelReasonNotNeed}); err != nil {
+		t.Fatal(err)
+	}
+	valid, err := quotes.Quote(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 请求失败且结果未知时返回错误并保留报价，不改为直接发单
+	dd.BaseUrl = "http://127.0.0.1:1"
+	if _, err := quotes.Book(&req); err == nil {
+		t.Fatal("want network error")
+	}
+	dd.BaseUrl = srv.URL
+	if kept, err := quotes.Quote(&req); err != nil || kept.DeliveryNo != valid.DeliveryNo {
+		t.Fatalf("quote should be kept after unknown result, got %+v %v", kept, err)
+	}
+	if _, err := quotes.Book(&req); err != nil {
+		t.Fatal(err)
+	}
+
+	notify.assert(t,
+		dada.OrderStatusCodeWaitToReceive,
+		dada.OrderStatusCodeCanceled,
+		dada.OrderStatusCodeWaitToReceive,
+		dada.OrderStatusCodeCanceled,
+		dada.OrderStatusCodeWaitToReceive,
+	)
+}
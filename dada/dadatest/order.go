@@ -0,0 +1,338 @@
+package dadatest
+
+import (
+	"fmt"
+	"github.com/MangoMilk/go-sdk/dada"
+	"github.com/MangoMilk/go-sdk/money"
+	"time"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(timeLayout)
+}
+
+// 已取消或创建运单失败的订单可重新发单
+func republishable(order *Order) bool {
+	return order.Status == dada.OrderStatusCodeCanceled || order.Status == dada.OrderStatusCodeAddOrderFail
+}
+
+// 新增订单、重新发布订单、查询运费使用相同的请求参数
+func checkOrderReq(req *dada.AddOrderReq) *apiError {
+	if req.ShopNo == "" || req.OriginID == "" || req.CityCode == "" || req.Callback == "" {
+		return fail(CodeParam, "shop_no、origin_id、city_code、callback 不能为空")
+	}
+	if req.ReceiverPhone == "" && req.ReceiverTel == "" {
+		return fail(CodeParam, "收货人手机号和座机号必填一项")
+	}
+
+	return nil
+}
+
+func (s *Server) quoteRes(req *dada.AddOrderReq) dada.AddOrderRes {
+	return dada.AddOrderRes{
+		Distance:   s.Distance,
+		Fee:        money.YuanFromFen(int64(s.DeliverFee.Fen() + req.Tips.Fen())),
+		DeliverFee: s.DeliverFee,
+		Tips:       req.Tips,
+	}
+}
+
+// publish 创建或重新发布订单，调用方需持有锁
+func (s *Server) publish(req *dada.AddOrderReq, reAdd bool) (*Order, *apiError) {
+	order, exist := s.orders[req.OriginID]
+	switch {
+	case reAdd && !exist:
+		return nil, fail(CodeOrderNotExist, "订单不存在")
+	case reAdd && !republishable(order):
+		return nil, fail(CodeOrderStatus, "只有已取消或创建失败的订单可以重新发布")
+	case !reAdd && exist && !republishable(order):
+		return nil, fail(CodeOrderExist, "订单已存在")
+	}
+
+	if !exist {
+		order = &Order{OrderID: req.OriginID}
+		s.orders[req.OriginID] = order
+	}
+
+	order.ClientID = fmt.Sprintf("%d", 200000000+s.nextID())
+	order.ShopNo = req.ShopNo
+	order.CityCode = req.CityCode
+	order.Callback = req.Callback
+	order.Distance = s.Distance
+	order.DeliverFee = s.DeliverFee
+	order.Tips = req.Tips
+	order.DeductFee = money.Yuan{}
+	order.CancelReason = ""
+	order.CancelFrom = 0
+	order.FinishCode = ""
+	if req.IsFinishCodeNeeded == dada.IsFinishCodeNeededYes {
+		order.FinishCode = fmt.Sprintf("%06d", s.seq%1000000)
+	}
+	s.transition(order, dada.OrderStatusCodeWaitToReceive)
+
+	return order, nil
+}
+
+func (s *Server) addOrder(body []byte) (interface{}, *apiError) {
+	return s.publishOrder(body, false)
+}
+
+func (s *Server) reAddOrder(body []byte) (interface{}, *apiError) {
+	return s.publishOrder(body, true)
+}
+
+func (s *Server) publishOrder(body []byte, reAdd bool) (interface{}, *apiError) {
+	var req dada.AddOrderReq
+	if apiErr := decodeBody(body, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := checkOrderReq(&req); apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	order, apiErr := s.publish(&req, reAdd)
+	if apiErr != nil {
+		s.mu.Unlock()
+		return nil, apiErr
+	}
+	snapshot := *order
+	s.mu.Unlock()
+
+	s.notify(&snapshot)
+
+	return s.quoteRes(&req), nil
+}
+
+func (s *Server) queryDeliverFee(body []byte) (interface{}, *apiError) {
+	var req dada.AddOrderReq
+	if apiErr := decodeBody(body, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := checkOrderReq(&req); apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if order, ok := s.orders[req.OriginID]; ok && !republishable(order) {
+		return nil, fail(CodeOrderExist, "订单已存在")
+	}
+
+	deliveryNo := fmt.Sprintf("Dada%d", 300000000+s.nextID())
+	s.quotes[deliveryNo] = &quote{req: req, expire: time.Now().Add(dada.QuoteTTL)}
+
+	quoteRes := s.quoteRes(&req)
+	return dada.QueryDeliverFeeRes{
+		Distance:   quoteRes.Distance,
+		Fee:        quoteRes.Fee,
+		DeliverFee: quoteRes.DeliverFee,
+		Tips:       quoteRes.Tips,
+		DeliveryNo: deliveryNo,
+	}, nil
+}
+
+// ExpireQuote 使预发布订单号立即过期，用于测试过期后的下单逻辑
+func (s *Server) ExpireQuote(deliveryNo string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if q, ok := s.quotes[deliveryNo]; ok {
+		q.expire = time.Now()
+	}
+}
+
+func (s *Server) addAfterQuery(body []byte) (interface{}, *apiError) {
+	var req dada.AddAfterQueryReq
+	if apiErr := decodeBody(body, &req); apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	q, ok := s.quotes[req.DeliveryNo]
+	if !ok || !time.Now().Before(q.expire) {
+		delete(s.quotes, req.DeliveryNo)
+		s.mu.Unlock()
+		return nil, fail(CodeQuoteExpired, "deliveryNo不存在或已过期")
+	}
+	delete(s.quotes, req.DeliveryNo)
+
+	_, exist := s.orders[q.req.OriginID]
+	order, apiErr := s.publish(&q.req, exist)
+	if apiErr != nil {
+		s.mu.Unlock()
+		return nil, apiErr
+	}
+	snapshot := *order
+	s.mu.Unlock()
+
+	s.notify(&snapshot)
+
+	return nil, nil
+}
+
+func (s *Server) queryOrder(body []byte) (interface{}, *apiError) {
+	var req dada.QueryOrderReq
+	if apiErr := decodeBody(body, &req); apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[req.OrderID]
+	if !ok {
+		return nil, fail(CodeOrderNotExist, "订单不存在")
+	}
+
+	return dada.QueryOrderRes{
+		OrderId:          order.OrderID,
+		StatusCode:       order.Status,
+		StatusMsg:        statusMsg[order.Status],
+		TransporterName:  order.TransporterName,
+		TransporterPhone: order.TransporterPhone,
+		DeliveryFee:      order.DeliverFee,
+		Tips:             order.Tips,
+		ActualFee:        money.YuanFromFen(int64(order.DeliverFee.Fen() + order.Tips.Fen())),
+		Distance:         order.Distance,
+		CreateTime:       formatTime(order.CreateTime),
+		AcceptTime:       formatTime(order.AcceptTime),
+		FetchTime:        formatTime(order.FetchTime),
+		FinishTime:       formatTime(order.FinishTime),
+		CancelTime:       formatTime(order.CancelTime),
+		OrderFinishCode:  order.FinishCode,
+		DeductFee:        order.DeductFee,
+	}, nil
+}
+
+var statusMsg = map[dada.OrderStatusCode]string{
+	dada.OrderStatusCodeWaitToReceive:     "待接单",
+	dada.OrderStatusCodeWaitToTake:        "待取货",
+	dada.OrderStatusCodeDelivering:        "配送中",
+	dada.OrderStatusCodeDone:              "已完成",
+	dada.OrderStatusCodeCanceled:          "已取消",
+	dada.OrderStatusCodeAssign:            "指派单",
+	dada.OrderStatusCodeUnusualBacking:    "妥投异常之物品返回中",
+	dada.OrderStatusCodeUnusualBackDone:   "妥投异常之物品返回完成",
+	dada.OrderStatusCodeTransporterArrive: "骑士到店",
+	dada.OrderStatusCodeAddOrderFail:      "创建达达运单失败",
+}
+
+// 取货前可取消，骑士接单后取消需支付违约金
+var cancelDeductFee = map[dada.OrderStatusCode]money.Yuan{
+	dada.OrderStatusCodeWaitToReceive:     {},
+	dada.OrderStatusCodeAssign:            {},
+	dada.OrderStatusCodeWaitToTake:        money.YuanFromFen(200),
+	dada.OrderStatusCodeTransporterArrive: money.YuanFromFen(200),
+}
+
+func (s *Server) cancelOrder(body []byte) (interface{}, *apiError) {
+	var req dada.CancelOrderReq
+	if apiErr := decodeBody(body, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	if req.CancelReasonID == dada.CancelReasonOther && req.CancelReason == "" {
+		return nil, fail(CodeParam, "取消原因为其他时 cancel_reason 必填")
+	}
+
+	s.mu.Lock()
+	order, ok := s.orders[req.OrderID]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fail(CodeOrderNotExist, "订单不存在")
+	}
+	deductFee, ok := cancelDeductFee[order.Status]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fail(CodeOrderStatus, "订单当前状态不允许取消")
+	}
+
+	order.DeductFee = deductFee
+	order.CancelReason = req.CancelReason
+	order.CancelFrom = dada.CancelFromSupplier
+	s.transition(order, dada.OrderStatusCodeCanceled)
+	snapshot := *order
+	s.mu.Unlock()
+
+	s.notify(&snapshot)
+
+	return dada.CancelOrderRes{DeductFee: deductFee}, nil
+}
+
+func (s *Server) addTip(body []byte) (interface{}, *apiError) {
+	var req dada.AddTipReq
+	if apiErr := decodeBody(body, &req); apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[req.OrderID]
+	if !ok {
+		return nil, fail(CodeOrderNotExist, "订单不存在")
+	}
+	if order.Status != dada.OrderStatusCodeWaitToReceive {
+		return nil, fail(CodeOrderStatus, "只有待接单的订单可以加小费")
+	}
+	if req.Tips.Fen() <= order.Tips.Fen() {
+		return nil, fail(CodeParam, "小费需大于上一次的小费")
+	}
+
+	order.Tips = req.Tips
+	return nil, nil
+}
+
+func (s *Server) confirmGoods(body []byte) (interface{}, *apiError) {
+	var req dada.ConfirmOrderGoodsReq
+	if apiErr := decodeBody(body, &req); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return nil, s.advance(req.OrderID, dada.OrderStatusCodeUnusualBackDone, "")
+}
+
+// simulate 测试环境的模拟接口
+func (s *Server) simulate(status dada.OrderStatusCode) handlerFunc {
+	return func(body []byte) (interface{}, *apiError) {
+		var req dada.SimulateReq
+		if apiErr := decodeBody(body, &req); apiErr != nil {
+			return nil, apiErr
+		}
+
+		return nil, s.advance(req.OrderID, status, req.Reason)
+	}
+}
+
+// advance 接口触发的状态变更，已取消的订单记为系统取消
+func (s *Server) advance(orderID string, status dada.OrderStatusCode, cancelReason string) *apiError {
+	s.mu.Lock()
+	order, ok := s.orders[orderID]
+	if !ok {
+		s.mu.Unlock()
+		return fail(CodeOrderNotExist, "订单不存在")
+	}
+	if !dada.CanTransition(order.Status, status) {
+		s.mu.Unlock()
+		return fail(CodeOrderStatus, "订单状态%v不能变更为%v", order.Status, status)
+	}
+
+	if status == dada.OrderStatusCodeCanceled {
+		order.CancelReason = cancelReason
+		order.CancelFrom = dada.CancelFromSystem
+	}
+	s.transition(order, status)
+	snapshot := *order
+	s.mu.Unlock()
+
+	s.notify(&snapshot)
+
+	return nil
+}
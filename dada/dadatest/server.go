@@ -0,0 +1,350 @@
+// Package dadatest 提供达达开放平台接口的本地模拟服务，用于离线集成测试：
+// 校验请求签名、保存订单与门店状态，并在订单状态变化时向 callback 发送签名的订单状态回调
+package dadatest
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/MangoMilk/go-sdk/dada"
+	"github.com/MangoMilk/go-sdk/money"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrOrderNotExist = errors.New("order not exist")
+	ErrOrderStatus   = errors.New("order status does not allow this operation")
+)
+
+// 模拟服务返回的错误码
+const (
+	CodeSignature     = 2003                   // 签名错误
+	CodeParam         = 2005                   // 参数错误
+	CodeOrderExist    = 2105                   // 订单已存在
+	CodeOrderNotExist = dada.CodeOrderNotExist // 订单不存在
+	CodeOrderStatus   = 2107                   // 订单状态不允许此操作
+	CodeQuoteExpired  = dada.CodeQuoteExpired  // 预发布订单号不存在或已过期
+	CodeShopExist     = 2201                   // 门店已存在
+	CodeShopNotExist  = 2202                   // 门店不存在
+)
+
+// Order 模拟服务中的订单快照
+type Order struct {
+	OrderID          string               // 第三方订单ID（origin_id）
+	ClientID         string               // 达达运单号
+	ShopNo           string               // 门店编号
+	CityCode         string               // 城市编码
+	Callback         string               // 订单状态回调地址
+	Status           dada.OrderStatusCode // 订单状态
+	Distance         float64              // 配送距离
+	DeliverFee       money.Yuan           // 运费
+	Tips             money.Yuan           // 小费
+	DeductFee        money.Yuan           // 违约金
+	TransporterID    int64                // 配送员ID，接单后生成
+	TransporterName  string               // 配送员姓名
+	TransporterPhone string               // 配送员手机号
+	FinishCode       string               // 收货码，下单时需要收货码才生成
+	CancelReason     string               // 取消原因
+	CancelFrom       dada.CancelFrom      // 取消来源
+	CreateTime       time.Time
+	AcceptTime       time.Time
+	FetchTime        time.Time
+	FinishTime       time.Time
+	CancelTime       time.Time
+	UpdateTime       time.Time // 最近一次状态变更时间，精确到秒，同一订单严格递增
+}
+
+type quote struct {
+	req    dada.AddOrderReq
+	expire time.Time
+}
+
+type Server struct {
+	*httptest.Server
+
+	AppKey       string
+	AppSecret    string
+	DeliverFee   money.Yuan   // 每单运费，默认 10 元
+	Distance     float64      // 每单配送距离，默认 1000 米
+	NotifyClient *http.Client // 发送回调使用的客户端
+
+	mu        sync.Mutex
+	seq       int64
+	orders    map[string]*Order    // origin_id
+	quotes    map[string]*quote    // deliveryNo
+	shops     map[string]dada.Shop // origin_shop_id
+	shopState map[string]dada.ShopStatus
+}
+
+// NewServer 创建并启动模拟服务，使用 Close 关闭
+func NewServer(appKey, appSecret string) *Server {
+	s := &Server{
+		AppKey:       appKey,
+		AppSecret:    appSecret,
+		DeliverFee:   money.YuanFromFen(1000),
+		Distance:     1000,
+		NotifyClient: &http.Client{Timeout: 10 * time.Second},
+		orders:       make(map[string]*Order),
+		quotes:       make(map[string]*quote),
+		shops:        make(map[string]dada.Shop),
+		shopState:    make(map[string]dada.ShopStatus),
+	}
+	s.Server = httptest.NewServer(s)
+
+	return s
+}
+
+// Client 返回指向模拟服务的达达客户端
+func (s *Server) Client(sourceID string) *dada.Dada {
+	dd := dada.NewDada(s.AppKey, s.AppSecret, sourceID, dada.TestEnv)
+	dd.BaseUrl = s.URL
+
+	return dd
+}
+
+type baseReq struct {
+	AppKey    string `json:"app_key"`
+	Signature string `json:"signature"`
+	Timestamp string `json:"timestamp"`
+	Format    string `json:"format"`
+	V         string `json:"v"`
+	SourceID  string `json:"source_id"`
+	Body      string `json:"body"`
+}
+
+// sign 与 dada.Dada 的签名算法一致：去掉 signature 和空值后按 key 升序拼接 key+value，首尾加 app_secret 后取 md5 大写
+func (r *baseReq) sign(appSecret string) string {
+	params := map[string]string{
+		"app_key":   r.AppKey,
+		"timestamp": r.Timestamp,
+		"format":    r.Format,
+		"v":         r.V,
+		"source_id": r.SourceID,
+		"body":      r.Body,
+	}
+
+	keys := make([]string, 0, len(params))
+	for k, v := range params {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var buf strings.Builder
+	buf.WriteString(appSecret)
+	for _, k := range keys {
+		buf.WriteString(k)
+		buf.WriteString(params[k])
+	}
+	buf.WriteString(appSecret)
+
+	sum := md5.Sum([]byte(buf.String()))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+type apiError struct {
+	code int64
+	msg  string
+}
+
+func (e *apiError) Error() string {
+	return e.msg
+}
+
+func fail(code int64, format string, args ...interface{}) *apiError {
+	return &apiError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+type handlerFunc func(body []byte) (interface{}, *apiError)
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	routes := map[string]handlerFunc{
+		"/api/order/addOrder":               s.addOrder,
+		"/api/order/reAddOrder":             s.reAddOrder,
+		"/api/order/queryDeliverFee":        s.queryDeliverFee,
+		"/api/order/addAfterQuery":          s.addAfterQuery,
+		"/api/order/status/query":           s.queryOrder,
+		"/api/order/formalCancel":           s.cancelOrder,
+		"/api/order/addTip":                 s.addTip,
+		"/api/order/confirm/goods":          s.confirmGoods,
+		"/api/order/accept":                 s.simulate(dada.OrderStatusCodeWaitToTake),
+		"/api/order/fetch":                  s.simulate(dada.OrderStatusCodeDelivering),
+		"/api/order/finish":                 s.simulate(dada.OrderStatusCodeDone),
+		"/api/order/cancel":                 s.simulate(dada.OrderStatusCodeCanceled),
+		"/api/order/expire":                 s.simulate(dada.OrderStatusCodeCanceled),
+		"/api/order/delivery/abnormal/back": s.simulate(dada.OrderStatusCodeUnusualBacking),
+		"/api/shop/add":                     s.addShop,
+		"/api/shop/update":                  s.updateShop,
+		"/api/shop/detail":                  s.queryShop,
+		"/merchantApi/merchant/add":         s.addMerchant,
+	}
+
+	handler, ok := routes[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	data, readErr := ioutil.ReadAll(r.Body)
+	if readErr != nil {
+		http.Error(w, readErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req baseReq
+	if jsonErr := json.Unmarshal(data, &req); jsonErr != nil {
+		writeRes(w, nil, fail(CodeParam, "invalid request: %v", jsonErr))
+		return
+	}
+	if req.AppKey != s.AppKey || req.Signature != req.sign(s.AppSecret) {
+		writeRes(w, nil, fail(CodeSignature, "签名错误"))
+		return
+	}
+
+	result, apiErr := handler([]byte(req.Body))
+	writeRes(w, result, apiErr)
+}
+
+func writeRes(w http.ResponseWriter, result interface{}, apiErr *apiError) {
+	res := dada.Response[interface{}]{Status: "success", Msg: "成功", Result: result}
+	if apiErr != nil {
+		res = dada.Response[interface{}]{Status: "fail", Code: apiErr.code, Msg: apiErr.msg, ErrorCode: apiErr.code}
+	}
+
+	data, _ := json.Marshal(res)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(data)
+}
+
+func decodeBody(body []byte, v interface{}) *apiError {
+	if jsonErr := json.Unmarshal(body, v); jsonErr != nil {
+		return fail(CodeParam, "invalid body: %v", jsonErr)
+	}
+
+	return nil
+}
+
+func (s *Server) nextID() int64 {
+	s.seq++
+	return s.seq
+}
+
+// Order 返回订单快照
+func (s *Server) Order(orderID string) (Order, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[orderID]
+	if !ok {
+		return Order{}, false
+	}
+
+	return *order, true
+}
+
+// SetStatus 按达达订单状态流转规则变更订单状态，并同步发送订单状态回调，返回回调的错误
+func (s *Server) SetStatus(orderID string, status dada.OrderStatusCode) error {
+	s.mu.Lock()
+	order, ok := s.orders[orderID]
+	if !ok {
+		s.mu.Unlock()
+		return ErrOrderNotExist
+	}
+	if !dada.CanTransition(order.Status, status) {
+		s.mu.Unlock()
+		return ErrOrderStatus
+	}
+
+	s.transition(order, status)
+	snapshot := *order
+	s.mu.Unlock()
+
+	return s.notify(&snapshot)
+}
+
+// transition 变更订单状态并记录对应的时间和配送员，调用方需持有锁
+func (s *Server) transition(order *Order, status dada.OrderStatusCode) {
+	now := time.Now()
+	switch status {
+	case dada.OrderStatusCodeWaitToReceive:
+		order.CreateTime = now
+		order.TransporterID = 0
+		order.TransporterName = ""
+		order.TransporterPhone = ""
+	case dada.OrderStatusCodeWaitToTake, dada.OrderStatusCodeAssign:
+		if order.TransporterID == 0 {
+			order.TransporterID = 10000 + s.nextID()
+			order.TransporterName = fmt.Sprintf("骑士%d", order.TransporterID)
+			order.TransporterPhone = fmt.Sprintf("138%08d", order.TransporterID)
+		}
+		order.AcceptTime = now
+	case dada.OrderStatusCodeDelivering:
+		order.FetchTime = now
+	case dada.OrderStatusCodeDone:
+		order.FinishTime = now
+	case dada.OrderStatusCodeCanceled:
+		order.CancelTime = now
+	}
+	order.Status = status
+
+	// 回调签名不包含 order_status，同一秒内的多次状态变更顺延 update_time，避免被视为重放
+	updateTime := now.Truncate(time.Second)
+	if !updateTime.After(order.UpdateTime) {
+		updateTime = order.UpdateTime.Add(time.Second)
+	}
+	order.UpdateTime = updateTime
+}
+
+// notify 向订单的 callback 发送订单状态回调
+func (s *Server) notify(order *Order) error {
+	if order.Callback == "" {
+		return nil
+	}
+
+	updateTime := fmt.Sprintf("%d", order.UpdateTime.Unix())
+	if order.Status == dada.OrderStatusCodeAddOrderFail {
+		updateTime = fmt.Sprintf("%d", order.UpdateTime.UnixNano()/int64(time.Millisecond))
+	}
+
+	args := []string{order.ClientID, order.OrderID, updateTime}
+	sort.Strings(args)
+	sum := md5.Sum([]byte(strings.Join(args, "")))
+
+	req := dada.OrderNotifyReq{
+		ClientID:     order.ClientID,
+		OrderID:      order.OrderID,
+		OrderStatus:  order.Status,
+		CancelReason: order.CancelReason,
+		CancelFrom:   order.CancelFrom,
+		UpdateTime:   updateTime,
+		Signature:    hex.EncodeToString(sum[:]),
+		DmID:         order.TransporterID,
+		DmName:       order.TransporterName,
+		DmMobile:     order.TransporterPhone,
+		FinishCode:   order.FinishCode,
+	}
+	body, _ := json.Marshal(req)
+
+	resp, httpErr := s.NotifyClient.Post(order.Callback, "application/json", strings.NewReader(string(body)))
+	if httpErr != nil {
+		return httpErr
+	}
+	defer resp.Body.Close()
+
+	var res dada.NotifyRes
+	data, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(data, &res) != nil || res.Status != dada.NotifySuccessReturnMsg {
+		return fmt.Errorf("notify %s fail: http status %d, body %s", order.Callback, resp.StatusCode, data)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package dadatest
+
+import (
+	"fmt"
+	"github.com/MangoMilk/go-sdk/dada"
+)
+
+// Shop 返回门店快照
+func (s *Server) Shop(originShopID string) (dada.Shop, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shop, ok := s.shops[originShopID]
+	return shop, ok
+}
+
+func (s *Server) addShop(body []byte) (interface{}, *apiError) {
+	var shops []dada.Shop
+	if apiErr := decodeBody(body, &shops); apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := dada.AddShopRes{}
+	for _, shop := range shops {
+		if shop.OriginShopID == "" {
+			shop.OriginShopID = fmt.Sprintf("%d", 11000000+s.nextID())
+		}
+		if _, ok := s.shops[shop.OriginShopID]; ok {
+			res.FailedList = append(res.FailedList, dada.AddShopFailedItem{ShopNo: shop.OriginShopID, Msg: "门店编码已存在", ShopName: shop.StationName})
+			continue
+		}
+
+		s.shops[shop.OriginShopID] = shop
+		s.shopState[shop.OriginShopID] = dada.ShopStatusActive
+		res.Success++
+		res.SuccessList = append(res.SuccessList, dada.AddShopSuccessItem{
+			Phone:          shop.Phone,
+			Business:       shop.Business,
+			Lng:            shop.Lng,
+			Lat:            shop.Lat,
+			StationName:    shop.StationName,
+			OriginShopId:   shop.OriginShopID,
+			ContactName:    shop.ContactName,
+			StationAddress: shop.StationAddress,
+			CityName:       shop.CityName,
+			AreaName:       shop.AreaName,
+		})
+	}
+
+	return res, nil
+}
+
+func (s *Server) updateShop(body []byte) (interface{}, *apiError) {
+	var req dada.UpdateShopReq
+	if apiErr := decodeBody(body, &req); apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shop, ok := s.shops[req.OriginShopID]
+	if !ok {
+		return nil, fail(CodeShopNotExist, "门店不存在")
+	}
+
+	if req.StationName != "" {
+		shop.StationName = req.StationName
+	}
+	if req.Business != 0 {
+		shop.Business = req.Business
+	}
+	if req.CityName != "" {
+		shop.CityName = req.CityName
+	}
+	if req.AreaName != "" {
+		shop.AreaName = req.AreaName
+	}
+	if req.StationAddress != "" {
+		shop.StationAddress = req.StationAddress
+	}
+	if req.Lng != 0 {
+		shop.Lng = req.Lng
+	}
+	if req.Lat != 0 {
+		shop.Lat = req.Lat
+	}
+	if req.ContactName != "" {
+		shop.ContactName = req.ContactName
+	}
+	if req.Phone != "" {
+		shop.Phone = req.Phone
+	}
+
+	status := s.shopState[req.OriginShopID]
+	if req.Status == int64(dada.ShopStatusActive) || req.Status == int64(dada.ShopStatusOffline) {
+		status = dada.ShopStatus(req.Status)
+	}
+
+	if req.NewShopID != "" && req.NewShopID != req.OriginShopID {
+		if _, exist := s.shops[req.NewShopID]; exist {
+			return nil, fail(CodeShopExist, "门店编码已存在")
+		}
+		delete(s.shops, req.OriginShopID)
+		delete(s.shopState, req.OriginShopID)
+		shop.OriginShopID = req.NewShopID
+	}
+
+	s.shops[shop.OriginShopID] = shop
+	s.shopState[shop.OriginShopID] = status
+
+	return nil, nil
+}
+
+func (s *Server) queryShop(body []byte) (interface{}, *apiError) {
+	var req dada.QueryShopReq
+	if apiErr := decodeBody(body, &req); apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shop, ok := s.shops[req.OriginShopID]
+	if !ok {
+		return nil, fail(CodeShopNotExist, "门店不存在")
+	}
+
+	return dada.QueryShopRes{
+		OriginShopID:   shop.OriginShopID,
+		StationName:    shop.StationName,
+		Business:       shop.Business,
+		CityName:       shop.CityName,
+		AreaName:       shop.AreaName,
+		StationAddress: shop.StationAddress,
+		Lng:            shop.Lng,
+		Lat:            shop.Lat,
+		ContactName:    shop.ContactName,
+		Phone:          shop.Phone,
+		IdCard:         shop.IdCard,
+		Status:         s.shopState[shop.OriginShopID],
+	}, nil
+}
+
+func (s *Server) addMerchant(body []byte) (interface{}, *apiError) {
+	var req dada.AddMerchantReq
+	if apiErr := decodeBody(body, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	if req.Mobile == "" || req.EnterpriseName == "" {
+		return nil, fail(CodeParam, "mobile、enterprise_name 不能为空")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return 70000 + s.nextID(), nil
+}
@@ -0,0 +1,88 @@
+package dada
+
+import (
+	"github.com/MangoMilk/go-sdk/coordinate"
+)
+
+// 达达接口的经纬度均使用高德坐标系（GCJ02），设置坐标时自动从 WGS84、BD09 转换，未设置坐标系的坐标视为 GCJ02，
+// 不支持的坐标系返回 coordinate.ErrUnknownSystem 且不修改原坐标
+
+// ReceiverLocation 收货人坐标
+func (req *AddOrderReq) ReceiverLocation() coordinate.Coordinate {
+	return coordinate.New(req.ReceiverLng, req.ReceiverLat, coordinate.GCJ02)
+}
+
+// SetReceiverLocation 设置收货人坐标
+func (req *AddOrderReq) SetReceiverLocation(location coordinate.Coordinate) error {
+	gcj, err := location.GCJ02()
+	if err != nil {
+		return err
+	}
+
+	req.ReceiverLng, req.ReceiverLat = gcj.Lng, gcj.Lat
+	return nil
+}
+
+// ReceiverLocation 收货人坐标
+func (req *ReAddOrderReq) ReceiverLocation() coordinate.Coordinate {
+	return coordinate.New(req.ReceiverLng, req.ReceiverLat, coordinate.GCJ02)
+}
+
+// SetReceiverLocation 设置收货人坐标
+func (req *ReAddOrderReq) SetReceiverLocation(location coordinate.Coordinate) error {
+	gcj, err := location.GCJ02()
+	if err != nil {
+		return err
+	}
+
+	req.ReceiverLng, req.ReceiverLat = gcj.Lng, gcj.Lat
+	return nil
+}
+
+// ReceiverLocation 收货人坐标
+func (req *QueryDeliverFeeReq) ReceiverLocation() coordinate.Coordinate {
+	return coordinate.New(req.ReceiverLng, req.ReceiverLat, coordinate.GCJ02)
+}
+
+// SetReceiverLocation 设置收货人坐标
+func (req *QueryDeliverFeeReq) SetReceiverLocation(location coordinate.Coordinate) error {
+	gcj, err := location.GCJ02()
+	if err != nil {
+		return err
+	}
+
+	req.ReceiverLng, req.ReceiverLat = gcj.Lng, gcj.Lat
+	return nil
+}
+
+// Location 门店坐标
+func (shop *Shop) Location() coordinate.Coordinate {
+	return coordinate.New(shop.Lng, shop.Lat, coordinate.GCJ02)
+}
+
+// SetLocation 设置门店坐标
+func (shop *Shop) SetLocation(location coordinate.Coordinate) error {
+	gcj, err := location.GCJ02()
+	if err != nil {
+		return err
+	}
+
+	shop.Lng, shop.Lat = gcj.Lng, gcj.Lat
+	return nil
+}
+
+// SetLocation 设置门店坐标
+func (req *UpdateShopReq) SetLocation(location coordinate.Coordinate) error {
+	gcj, err := location.GCJ02()
+	if err != nil {
+		return err
+	}
+
+	req.Lng, req.Lat = gcj.Lng, gcj.Lat
+	return nil
+}
+
+// Location 门店坐标
+func (res *QueryShopRes) Location() coordinate.Coordinate {
+	return coordinate.New(res.Lng, res.Lat, coordinate.GCJ02)
+}
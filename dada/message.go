@@ -0,0 +1,165 @@
+package dada
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrMessageType      = errors.New("unexpected dada message type")
+	ErrNoMessageConfirm = errors.New("dada message notify handler has no Confirm")
+)
+
+const (
+	// DefaultMessageTimeout 处理一次消息回调（决定并确认）的默认时限，需小于达达回调的响应时限
+	DefaultMessageTimeout = 5 * time.Second
+	// DefaultMessageDecideTimeout 等待商家决定是否同意骑士取消的默认时长，超时按 MessageNotifyHandler.Default 确认
+	DefaultMessageDecideTimeout = 3 * time.Second
+)
+
+// DecodeTransporterCancel 解析并校验骑士取消消息的 messageBody
+func (req *NotifyReq) DecodeTransporterCancel() (*TransporterNotifyReq, error) {
+	if req.MessageType != NotifyMessageTypeTransporterCancel {
+		return nil, ErrMessageType
+	}
+
+	var data TransporterNotifyReq
+	if jsonErr := json.Unmarshal([]byte(req.MessageBody), &data); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	if err := data.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// ==================== 消息回调处理 ====================
+// TransporterCancelFunc 决定是否同意骑士取消订单
+type TransporterCancelFunc func(req *TransporterNotifyReq) (IsConfirmTransporterCancel, error)
+
+// LateDecisionFunc 接收超时后才返回的决定，此时已按 Default 确认，可用于记录或人工补偿
+type LateDecisionFunc func(req *TransporterNotifyReq, isConfirm IsConfirmTransporterCancel, err error)
+
+// MessageNotifyHandler 达达消息回调处理：解析 messageBody，由 OnTransporterCancel 决定是否同意骑士取消后调用 Confirm 确认。
+// 决定与确认共同受 Timeout 约束：OnTransporterCancel 在 DecideTimeout（及剩余时限）内未返回时按 Default 确认，
+// 迟到的决定交给 OnLateDecision；确认请求只使用剩余时限。返回 error 或确认失败时应答 fail，达达会重新推送。
+// 未知类型的消息直接应答 ok
+type MessageNotifyHandler struct {
+	OnTransporterCancel TransporterCancelFunc
+	OnLateDecision      LateDecisionFunc                                       // 可选
+	Default             IsConfirmTransporterCancel                             // 超时未决定时的确认结果，默认不同意
+	Timeout             time.Duration                                          // 为 0 时使用 DefaultMessageTimeout
+	DecideTimeout       time.Duration                                          // 为 0 时使用 DefaultMessageDecideTimeout
+	Confirm             func(ctx context.Context, req *NotifyConfirmReq) error // 确认骑士取消，通常为 Dada.ConfirmMessageContext
+}
+
+func (dd *Dada) NewMessageNotifyHandler(fn TransporterCancelFunc) *MessageNotifyHandler {
+	return &MessageNotifyHandler{
+		OnTransporterCancel: fn,
+		Default:             IsConfirmTransporterCancelNo,
+		Timeout:             DefaultMessageTimeout,
+		DecideTimeout:       DefaultMessageDecideTimeout,
+		Confirm:             dd.ConfirmMessageContext,
+	}
+}
+
+type decideResult struct {
+	isConfirm IsConfirmTransporterCancel
+	err       error
+}
+
+func (h *MessageNotifyHandler) decide(ctx context.Context, req *TransporterNotifyReq) (IsConfirmTransporterCancel, error) {
+	if h.OnTransporterCancel == nil {
+		return h.Default, nil
+	}
+
+	decideTimeout := h.DecideTimeout
+	if decideTimeout <= 0 {
+		decideTimeout = DefaultMessageDecideTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, decideTimeout)
+	defer cancel()
+
+	// done 不带缓冲：超时后无人接收，迟到的结果转交 OnLateDecision
+	done := make(chan decideResult)
+	timeout := make(chan struct{})
+	go func() {
+		isConfirm, err := h.OnTransporterCancel(req)
+		select {
+		case done <- decideResult{isConfirm: isConfirm, err: err}:
+		case <-timeout:
+			if h.OnLateDecision != nil {
+				h.OnLateDecision(req, isConfirm, err)
+			}
+		}
+	}()
+
+	select {
+	case res := <-done:
+		return res.isConfirm, res.err
+	case <-ctx.Done():
+		close(timeout)
+		return h.Default, nil
+	}
+}
+
+// Handle 处理已解析的消息回调
+func (h *MessageNotifyHandler) Handle(req *NotifyReq) error {
+	if req.MessageType != NotifyMessageTypeTransporterCancel {
+		return nil
+	}
+
+	if h.Confirm == nil {
+		return ErrNoMessageConfirm
+	}
+
+	cancelReq, decodeErr := req.DecodeTransporterCancel()
+	if decodeErr != nil {
+		return decodeErr
+	}
+
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultMessageTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	isConfirm, decideErr := h.decide(ctx, cancelReq)
+	if decideErr != nil {
+		return decideErr
+	}
+
+	return h.Confirm(ctx, &NotifyConfirmReq{
+		OrderId:     cancelReq.OrderId,
+		DadaOrderId: cancelReq.DadaOrderId,
+		IsConfirm:   isConfirm,
+	})
+}
+
+func (h *MessageNotifyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, readErr := ioutil.ReadAll(r.Body)
+	if readErr != nil {
+		writeNotifyRes(w, http.StatusBadRequest)
+		return
+	}
+
+	var req NotifyReq
+	if jsonErr := json.Unmarshal(body, &req); jsonErr != nil {
+		writeNotifyRes(w, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Handle(&req); err != nil {
+		writeNotifyRes(w, http.StatusInternalServerError)
+		return
+	}
+
+	writeNotifyRes(w, http.StatusOK)
+}
@@ -0,0 +1,114 @@
+package dada
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMessageNotifyHandler(t *testing.T) {
+	var confirmed []*NotifyConfirmReq
+
+	handler := NewDada("", "", sourceID, TestEnv).NewMessageNotifyHandler(func(req *TransporterNotifyReq) (IsConfirmTransporterCancel, error) {
+		if req.OrderId == "slow" {
+			time.Sleep(time.Second)
+		}
+		return IsConfirmTransporterCancelYes, nil
+	})
+	handler.DecideTimeout = 100 * time.Millisecond
+	late := make(chan string, 1)
+	handler.OnLateDecision = func(req *TransporterNotifyReq, isConfirm IsConfirmTransporterCancel, err error) {
+		late <- req.OrderId
+	}
+	handler.Confirm = func(ctx context.Context, req *NotifyConfirmReq) error {
+		if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > DefaultMessageTimeout {
+			t.Errorf("confirm should be bounded by handler timeout, got %v %v", deadline, ok)
+		}
+		confirmed = append(confirmed, req)
+		return nil
+	}
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	post := func(orderID string) NotifyRes {
+		msgBody, _ := json.Marshal(TransporterNotifyReq{OrderId: orderID, DadaOrderId: 1001, CancelReason: "车坏了"})
+		body, _ := json.Marshal(NotifyReq{MessageBody: string(msgBody), MessageType: NotifyMessageTypeTransporterCancel})
+
+		resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var res NotifyRes
+		if jsonErr := json.NewDecoder(resp.Body).Decode(&res); jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+		return res
+	}
+
+	if res := post("o1"); res.Status != NotifySuccessReturnMsg {
+		t.Fatalf("got %q", res.Status)
+	}
+	if res := post("slow"); res.Status != NotifySuccessReturnMsg {
+		t.Fatalf("got %q", res.Status)
+	}
+
+	if len(confirmed) != 2 {
+		t.Fatalf("confirmed %d messages", len(confirmed))
+	}
+	if confirmed[0].OrderId != "o1" || confirmed[0].DadaOrderId != 1001 || confirmed[0].IsConfirm != IsConfirmTransporterCancelYes {
+		t.Fatalf("unexpected confirm %+v", confirmed[0])
+	}
+	if confirmed[1].IsConfirm != IsConfirmTransporterCancelNo {
+		t.Fatalf("timeout should fall back to default, got %+v", confirmed[1])
+	}
+	select {
+	case orderID := <-late:
+		if orderID != "slow" {
+			t.Fatalf("late decision for %s", orderID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("late decision not reported")
+	}
+
+	// 零值 handler 不 panic，DecideTimeout 为 0 时使用默认时长
+	msgBody, _ := json.Marshal(TransporterNotifyReq{OrderId: "o2", DadaOrderId: 1002, CancelReason: "车坏了"})
+	req := &NotifyReq{MessageBody: string(msgBody), MessageType: NotifyMessageTypeTransporterCancel}
+	zero := &MessageNotifyHandler{OnTransporterCancel: func(req *TransporterNotifyReq) (IsConfirmTransporterCancel, error) {
+		return IsConfirmTransporterCancelYes, nil
+	}}
+	if err := zero.Handle(req); err != ErrNoMessageConfirm {
+		t.Fatalf("got %v, want ErrNoMessageConfirm", err)
+	}
+	zero.Confirm = func(ctx context.Context, req *NotifyConfirmReq) error {
+		if req.IsConfirm != IsConfirmTransporterCancelYes {
+			t.Fatalf("zero DecideTimeout should not fire immediately, got %+v", req)
+		}
+		return nil
+	}
+	if err := zero.Handle(req); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecodeTransporterCancel(t *testing.T) {
+	req := NotifyReq{MessageBody: `{"orderId":"o1","dadaOrderId":1001,"cancelReason":"r"}`, MessageType: 2}
+	if _, err := req.DecodeTransporterCancel(); err != ErrMessageType {
+		t.Fatalf("got %v, want ErrMessageType", err)
+	}
+
+	req.MessageType = NotifyMessageTypeTransporterCancel
+	data, err := req.DecodeTransporterCancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.OrderId != "o1" || data.DadaOrderId != 1001 || data.CancelReason != "r" {
+		t.Fatalf("unexpected %+v", data)
+	}
+}
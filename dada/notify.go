@@ -0,0 +1,294 @@
+package dada
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/MangoMilk/go-sdk/validate"
+	"hash/fnv"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotifySignature  = errors.New("check signature fail")
+	ErrNotifyExpired    = errors.New("notify update_time expired")
+	ErrNotifyUpdateTime = errors.New("invalid notify update_time")
+	ErrNotifyReplay     = errors.New("notify replayed with another order_status")
+	ErrOrderTransition  = errors.New("illegal order status transition")
+)
+
+// DefaultNotifyMaxAge NewOrderNotifyHandler 默认的 update_time 最大时长，超过则视为过期或重放。
+// 达达在应答失败后会持续重推，因自身故障超过该时长才恢复的回调将被拒绝且不再调用回调，需要处理时可将 MaxAge 设为 0
+const DefaultNotifyMaxAge = 10 * time.Minute
+
+// UpdateAt 解析 update_time，创建达达运单失败=1000 时精确到毫秒，其他状态精确到秒
+func (req *OrderNotifyReq) UpdateAt() (time.Time, error) {
+	ts, parseErr := strconv.ParseInt(req.UpdateTime, 10, 64)
+	if parseErr != nil || ts <= 0 {
+		return time.Time{}, ErrNotifyUpdateTime
+	}
+
+	if req.OrderStatus == OrderStatusCodeAddOrderFail {
+		return time.Unix(0, ts*int64(time.Millisecond)), nil
+	}
+
+	return time.Unix(ts, 0), nil
+}
+
+// Check 校验签名，maxAge 大于 0 时同时校验 update_time 不早于 maxAge 之前
+func (req *OrderNotifyReq) Check(maxAge time.Duration) error {
+	if signErr := CheckNotifySign(req.ClientID, req.OrderID, req.UpdateTime, req.Signature); signErr != nil {
+		return signErr
+	}
+
+	updateAt, timeErr := req.UpdateAt()
+	if timeErr != nil {
+		return timeErr
+	}
+
+	if maxAge > 0 && time.Since(updateAt) > maxAge {
+		return ErrNotifyExpired
+	}
+
+	return nil
+}
+
+// ==================== 订单状态机 ====================
+// orderTransitions 订单状态允许的流转：
+// 待接单(1) -> 指派单(8) -> 待取货(2) -> 骑士到店(100) -> 配送中(3) -> 已完成(4)；
+// 骑士取消后回到待接单(1)，妥投异常时 配送中(3) -> 返回中(9) -> 返回完成(10)；
+// 已取消(5)、创建运单失败(1000) 的订单重新发单后回到待接单(1)
+var orderTransitions = map[OrderStatusCode][]OrderStatusCode{
+	OrderStatusCodeWaitToReceive:     {OrderStatusCodeAssign, OrderStatusCodeWaitToTake, OrderStatusCodeCanceled, OrderStatusCodeAddOrderFail},
+	OrderStatusCodeAssign:            {OrderStatusCodeWaitToReceive, OrderStatusCodeWaitToTake, OrderStatusCodeCanceled},
+	OrderStatusCodeWaitToTake:        {OrderStatusCodeWaitToReceive, OrderStatusCodeTransporterArrive, OrderStatusCodeDelivering, OrderStatusCodeCanceled},
+	OrderStatusCodeTransporterArrive: {OrderStatusCodeWaitToReceive, OrderStatusCodeDelivering, OrderStatusCodeCanceled},
+	OrderStatusCodeDelivering:        {OrderStatusCodeDone, OrderStatusCodeUnusualBacking, OrderStatusCodeCanceled},
+	OrderStatusCodeUnusualBacking:    {OrderStatusCodeUnusualBackDone},
+	OrderStatusCodeCanceled:          {OrderStatusCodeWaitToReceive},
+	OrderStatusCodeAddOrderFail:      {OrderStatusCodeWaitToReceive},
+	OrderStatusCodeDone:              {},
+	OrderStatusCodeUnusualBackDone:   {},
+}
+
+// CanTransition 订单状态能否从 from 流转到 to
+func CanTransition(from, to OrderStatusCode) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OrderState 订单最近一次处理成功的回调状态
+type OrderState struct {
+	Status   OrderStatusCode
+	UpdateAt time.Time
+}
+
+// OrderStateStore 订单状态存储，Get 在订单不存在时返回 nil, nil；多实例部署时应使用共享存储
+type OrderStateStore interface {
+	Get(orderID string) (*OrderState, error)
+	Set(orderID string, state *OrderState) error
+}
+
+// MemoryOrderStateStore 进程内的订单状态存储
+type MemoryOrderStateStore struct {
+	mu     sync.RWMutex
+	states map[string]OrderState
+}
+
+func NewMemoryOrderStateStore() *MemoryOrderStateStore {
+	return &MemoryOrderStateStore{states: make(map[string]OrderState)}
+}
+
+func (s *MemoryOrderStateStore) Get(orderID string) (*OrderState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, ok := s.states[orderID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &state, nil
+}
+
+func (s *MemoryOrderStateStore) Set(orderID string, state *OrderState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[orderID] = *state
+	return nil
+}
+
+// ==================== 订单状态回调处理 ====================
+// OrderNotifyFunc 订单状态回调，返回 error 时应答 fail，达达会重新推送。
+// 回调至少调用一次：回调成功后 Store.Set 失败或进程退出时，达达重推会再次调用，实现需保证幂等
+type OrderNotifyFunc func(req *OrderNotifyReq) error
+
+// OrderNotifyHandler 订单状态回调处理：校验签名与 update_time 时效，按状态机校验流转后调用对应状态的回调。
+// 与当前状态相同或 update_time 早于当前状态的回调视为重复或乱序，直接应答 ok 且不调用回调；
+// 签名不包含 order_status，update_time 与当前状态相同但状态不同的回调视为篡改状态的重放，应答 fail；
+// 不允许的流转应答 fail。订单首个回调不校验流转，便于状态丢失后继续处理。
+// 状态在回调成功后才写入 Store，同一状态的回调可能被重复调用（至少一次语义）
+type OrderNotifyHandler struct {
+	Store  OrderStateStore
+	MaxAge time.Duration // update_time 最大时长，为 0 时不校验时效
+
+	OnWaitToReceive     OrderNotifyFunc // 待接单
+	OnAssign            OrderNotifyFunc // 指派单
+	OnWaitToTake        OrderNotifyFunc // 待取货
+	OnTransporterArrive OrderNotifyFunc // 骑士到店
+	OnDelivering        OrderNotifyFunc // 配送中
+	OnDone              OrderNotifyFunc // 已完成
+	OnCanceled          OrderNotifyFunc // 已取消
+	OnUnusualBacking    OrderNotifyFunc // 妥投异常之物品返回中
+	OnUnusualBackDone   OrderNotifyFunc // 妥投异常之物品返回完成
+	OnAddOrderFail      OrderNotifyFunc // 创建达达运单失败
+
+	locks [32]sync.Mutex
+}
+
+// NewOrderNotifyHandler store 为 nil 时使用进程内存储，MaxAge 默认为 DefaultNotifyMaxAge
+func NewOrderNotifyHandler(store OrderStateStore) *OrderNotifyHandler {
+	if store == nil {
+		store = NewMemoryOrderStateStore()
+	}
+
+	return &OrderNotifyHandler{Store: store, MaxAge: DefaultNotifyMaxAge}
+}
+
+func (h *OrderNotifyHandler) hook(status OrderStatusCode) OrderNotifyFunc {
+	switch status {
+	case OrderStatusCodeWaitToReceive:
+		return h.OnWaitToReceive
+	case OrderStatusCodeAssign:
+		return h.OnAssign
+	case OrderStatusCodeWaitToTake:
+		return h.OnWaitToTake
+	case OrderStatusCodeTransporterArrive:
+		return h.OnTransporterArrive
+	case OrderStatusCodeDelivering:
+		return h.OnDelivering
+	case OrderStatusCodeDone:
+		return h.OnDone
+	case OrderStatusCodeCanceled:
+		return h.OnCanceled
+	case OrderStatusCodeUnusualBacking:
+		return h.OnUnusualBacking
+	case OrderStatusCodeUnusualBackDone:
+		return h.OnUnusualBackDone
+	case OrderStatusCodeAddOrderFail:
+		return h.OnAddOrderFail
+	}
+
+	return nil
+}
+
+// 同一订单的回调串行处理
+func (h *OrderNotifyHandler) lock(orderID string) *sync.Mutex {
+	hash := fnv.New32a()
+	hash.Write([]byte(orderID))
+
+	return &h.locks[hash.Sum32()%uint32(len(h.locks))]
+}
+
+// Handle 处理已解析的订单状态回调，依次校验必填参数、签名和状态流转
+func (h *OrderNotifyHandler) Handle(req *OrderNotifyReq) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	if checkErr := req.Check(h.MaxAge); checkErr != nil {
+		return checkErr
+	}
+
+	if _, ok := orderTransitions[req.OrderStatus]; !ok {
+		return ErrOrderTransition
+	}
+
+	updateAt, _ := req.UpdateAt()
+
+	mu := h.lock(req.OrderID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	state, storeErr := h.Store.Get(req.OrderID)
+	if storeErr != nil {
+		return storeErr
+	}
+
+	if state != nil {
+		if updateAt.Equal(state.UpdateAt) && state.Status != req.OrderStatus {
+			return ErrNotifyReplay
+		}
+
+		if state.Status == req.OrderStatus || updateAt.Before(state.UpdateAt) {
+			return nil
+		}
+
+		if !CanTransition(state.Status, req.OrderStatus) {
+			return ErrOrderTransition
+		}
+	}
+
+	// 回调成功后再记录状态：回调失败时达达重推仍会调用回调；Store.Set 失败时重推会再次调用回调
+	if fn := h.hook(req.OrderStatus); fn != nil {
+		if err := fn(req); err != nil {
+			return err
+		}
+	}
+
+	return h.Store.Set(req.OrderID, &OrderState{Status: req.OrderStatus, UpdateAt: updateAt})
+}
+
+func (h *OrderNotifyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, readErr := ioutil.ReadAll(r.Body)
+	if readErr != nil {
+		writeNotifyRes(w, http.StatusBadRequest)
+		return
+	}
+
+	var req OrderNotifyReq
+	if jsonErr := json.Unmarshal(body, &req); jsonErr != nil {
+		writeNotifyRes(w, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Handle(&req); err != nil {
+		if _, ok := err.(*validate.FieldError); ok {
+			writeNotifyRes(w, http.StatusBadRequest)
+			return
+		}
+
+		switch err {
+		case ErrNotifySignature, ErrNotifyExpired, ErrNotifyUpdateTime, ErrNotifyReplay:
+			writeNotifyRes(w, http.StatusForbidden)
+		case ErrOrderTransition:
+			writeNotifyRes(w, http.StatusConflict)
+		default:
+			writeNotifyRes(w, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeNotifyRes(w, http.StatusOK)
+}
+
+func writeNotifyRes(w http.ResponseWriter, status int) {
+	res := NotifyRes{Status: NotifySuccessReturnMsg}
+	if status != http.StatusOK {
+		res.Status = NotifyFailReturnMsg
+	}
+
+	data, _ := json.Marshal(res)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
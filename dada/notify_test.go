@@ -0,0 +1,159 @@
+package dada
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/MangoMilk/go-kit/encrypt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signedOrderNotify(t *testing.T, orderID string, status OrderStatusCode, updateAt time.Time) *OrderNotifyReq {
+	updateTime := strconv.FormatInt(updateAt.Unix(), 10)
+	if status == OrderStatusCodeAddOrderFail {
+		updateTime = strconv.FormatInt(updateAt.UnixNano()/int64(time.Millisecond), 10)
+	}
+
+	args := []string{"dd" + orderID, orderID, updateTime}
+	sort.Strings(args)
+	sign, err := encrypt.MD5(strings.Join(args, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &OrderNotifyReq{
+		ClientID:    "dd" + orderID,
+		OrderID:     orderID,
+		OrderStatus: status,
+		UpdateTime:  updateTime,
+		Signature:   sign,
+	}
+}
+
+func TestOrderNotifyCheck(t *testing.T) {
+	now := time.Now()
+
+	req := signedOrderNotify(t, "o1", OrderStatusCodeWaitToReceive, now)
+	if err := req.Check(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	req.Signature = "bad"
+	if err := req.Check(time.Minute); err != ErrNotifySignature {
+		t.Fatalf("got %v, want ErrNotifySignature", err)
+	}
+
+	req = signedOrderNotify(t, "o1", OrderStatusCodeWaitToReceive, now.Add(-time.Hour))
+	if err := req.Check(time.Minute); err != ErrNotifyExpired {
+		t.Fatalf("got %v, want ErrNotifyExpired", err)
+	}
+
+	req = signedOrderNotify(t, "o1", OrderStatusCodeAddOrderFail, now)
+	if err := req.Check(time.Minute); err != nil {
+		t.Fatalf("millisecond update_time: %v", err)
+	}
+}
+
+func TestOrderNotifyHandler(t *testing.T) {
+	var called []OrderStatusCode
+	record := func(req *OrderNotifyReq) error {
+		called = append(called, req.OrderStatus)
+		return nil
+	}
+
+	handler := NewOrderNotifyHandler(nil)
+	handler.OnWaitToReceive = record
+	handler.OnWaitToTake = record
+	handler.OnTransporterArrive = record
+	handler.OnDelivering = record
+	handler.OnDone = record
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	post := func(req *OrderNotifyReq) int {
+		body, _ := json.Marshal(req)
+		resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var res NotifyRes
+		json.NewDecoder(resp.Body).Decode(&res)
+		if (resp.StatusCode == http.StatusOK) != (res.Status == NotifySuccessReturnMsg) {
+			t.Fatalf("status %d with body %q", resp.StatusCode, res.Status)
+		}
+		return resp.StatusCode
+	}
+
+	missing := signedOrderNotify(t, "o1", OrderStatusCodeWaitToReceive, time.Now())
+	missing.ClientID = ""
+	if code := post(missing); code != http.StatusBadRequest {
+		t.Fatalf("missing client_id: got %d, want %d", code, http.StatusBadRequest)
+	}
+	addFail := signedOrderNotify(t, "o0", OrderStatusCodeAddOrderFail, time.Now())
+	addFail.ClientID = ""
+	if err := addFail.Validate(); err != nil {
+		t.Fatalf("add order fail notify has no client_id: %v", err)
+	}
+
+	base := time.Now().Add(-time.Minute)
+	steps := []struct {
+		status OrderStatusCode
+		offset time.Duration
+		code   int
+	}{
+		{OrderStatusCodeWaitToReceive, 0, http.StatusOK},
+		{OrderStatusCodeWaitToTake, time.Second, http.StatusOK},
+		{OrderStatusCodeWaitToTake, time.Second, http.StatusOK},     // 重复回调
+		{OrderStatusCodeDone, 2 * time.Second, http.StatusConflict}, // 跳过配送中
+		{OrderStatusCodeTransporterArrive, 3 * time.Second, http.StatusOK},
+		{OrderStatusCodeCanceled, 3 * time.Second, http.StatusForbidden}, // 相同 update_time 篡改状态的重放
+		{OrderStatusCodeWaitToReceive, 0, http.StatusOK},                 // 乱序的旧回调
+		{OrderStatusCodeDelivering, 4 * time.Second, http.StatusOK},
+		{OrderStatusCodeDone, 5 * time.Second, http.StatusOK},
+		{OrderStatusCodeCanceled, 6 * time.Second, http.StatusConflict}, // 完成后不可取消
+	}
+	for i, step := range steps {
+		if code := post(signedOrderNotify(t, "o1", step.status, base.Add(step.offset))); code != step.code {
+			t.Fatalf("step %d status %v: got %d, want %d", i, step.status, code, step.code)
+		}
+	}
+
+	want := []OrderStatusCode{
+		OrderStatusCodeWaitToReceive,
+		OrderStatusCodeWaitToTake,
+		OrderStatusCodeTransporterArrive,
+		OrderStatusCodeDelivering,
+		OrderStatusCodeDone,
+	}
+	if len(called) != len(want) {
+		t.Fatalf("hooks called %v, want %v", called, want)
+	}
+	for i := range want {
+		if called[i] != want[i] {
+			t.Fatalf("hooks called %v, want %v", called, want)
+		}
+	}
+
+	bad := signedOrderNotify(t, "o2", OrderStatusCodeWaitToReceive, time.Now())
+	bad.Signature = "bad"
+	if code := post(bad); code != http.StatusForbidden {
+		t.Fatalf("bad signature: got %d", code)
+	}
+
+	// 默认校验时效，MaxAge 为 0 时自身故障恢复后收到的重推仍会处理
+	if code := post(signedOrderNotify(t, "o3", OrderStatusCodeWaitToReceive, time.Now().Add(-time.Hour))); code != http.StatusForbidden {
+		t.Fatalf("expired: got %d, want %d", code, http.StatusForbidden)
+	}
+	handler.MaxAge = 0
+	if code := post(signedOrderNotify(t, "o4", OrderStatusCodeWaitToReceive, time.Now().Add(-time.Hour))); code != http.StatusOK {
+		t.Fatalf("delayed retry without MaxAge: got %d, want %d", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,172 @@
+package dada
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrNoQuote = errors.New("no delivery fee quote")
+
+// QuoteTTL 预发布订单号（deliveryNo）的有效期
+const QuoteTTL = 3 * time.Minute
+
+// DefaultQuoteTTL 报价在本地的默认有效期，比 deliveryNo 提前 10 秒过期，避免临界时下单失败
+const DefaultQuoteTTL = QuoteTTL - 10*time.Second
+
+// ==================== 运费报价 ====================
+// Quote 运费报价，DeliveryNo 在 ExpireAt 前可用于 AddAfterQuery 下单
+type Quote struct {
+	QueryDeliverFeeRes
+	Req      QueryDeliverFeeReq // 报价使用的订单参数，过期后按此参数直接下单
+	ExpireAt time.Time
+}
+
+func (q *Quote) Valid() bool {
+	return time.Now().Before(q.ExpireAt)
+}
+
+// QuoteManager 按订单缓存运费报价：报价有效时通过 AddAfterQuery 下单，
+// 本地过期或达达返回 deliveryNo 失效时自动改用 AddOrder，已取消或创建失败的订单改用 ReAddOrder
+type QuoteManager struct {
+	TTL time.Duration
+
+	dd     *Dada
+	mu     sync.Mutex
+	quotes map[string]*Quote // origin_id + shop_no
+}
+
+func (dd *Dada) NewQuoteManager() *QuoteManager {
+	return &QuoteManager{TTL: DefaultQuoteTTL, dd: dd, quotes: make(map[string]*Quote)}
+}
+
+func quoteKey(req *QueryDeliverFeeReq) string {
+	return req.OriginID + "/" + req.ShopNo
+}
+
+func sameReq(a, b *QueryDeliverFeeReq) bool {
+	aByte, _ := json.Marshal(a)
+	bByte, _ := json.Marshal(b)
+
+	return string(aByte) == string(bByte)
+}
+
+// cached 返回与 req 参数一致且未过期的报价，并清理过期的报价
+func (m *QuoteManager) cached(req *QueryDeliverFeeReq) *Quote {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, q := range m.quotes {
+		if !q.Valid() {
+			delete(m.quotes, key)
+		}
+	}
+
+	q, ok := m.quotes[quoteKey(req)]
+	if !ok || !sameReq(&q.Req, req) {
+		return nil
+	}
+
+	return q
+}
+
+// Quote 查询运费，参数未变化且报价未过期时直接返回缓存的报价
+func (m *QuoteManager) Quote(req *QueryDeliverFeeReq) (*Quote, error) {
+	if q := m.cached(req); q != nil {
+		return q, nil
+	}
+
+	res, err := m.dd.QueryDeliverFee(req)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Quote{QueryDeliverFeeRes: *res, Req: *req, ExpireAt: time.Now().Add(m.TTL)}
+
+	m.mu.Lock()
+	m.quotes[quoteKey(req)] = q
+	m.mu.Unlock()
+
+	return q, nil
+}
+
+// Cheapest 对同一订单的多个候选门店（或参数）报价，返回实际运费最低的报价
+func (m *QuoteManager) Cheapest(reqs ...*QueryDeliverFeeReq) (*Quote, error) {
+	var cheapest *Quote
+	var lastErr error
+	for _, req := range reqs {
+		q, err := m.Quote(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if cheapest == nil || q.Fee.Fen() < cheapest.Fee.Fen() {
+			cheapest = q
+		}
+	}
+
+	if cheapest == nil {
+		if lastErr == nil {
+			lastErr = ErrNoQuote
+		}
+		return nil, lastErr
+	}
+
+	return cheapest, nil
+}
+
+// Invalidate 删除订单的报价
+func (m *QuoteManager) Invalidate(req *QueryDeliverFeeReq) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.quotes, quoteKey(req))
+}
+
+// Book 按 req 下单：存在有效报价时使用 AddAfterQuery，仅当达达返回 deliveryNo 已失效时改为直接发单；
+// 其他错误（包括超时等结果未知的情况）原样返回并保留报价，重试时仍使用同一个 deliveryNo，避免重复发单
+func (m *QuoteManager) Book(req *QueryDeliverFeeReq) (*AddOrderRes, error) {
+	if q := m.cached(req); q != nil {
+		err := m.dd.AddAfterQuery(q.DeliveryNo)
+		if err == nil {
+			m.Invalidate(req)
+			return &AddOrderRes{
+				Distance:     q.Distance,
+				Fee:          q.Fee,
+				DeliverFee:   q.DeliverFee,
+				CouponFee:    q.CouponFee,
+				Tips:         q.Tips,
+				InsuranceFee: q.InsuranceFee,
+			}, nil
+		}
+		if !isCode(err, CodeQuoteExpired) {
+			return nil, err
+		}
+		m.Invalidate(req)
+	}
+
+	return m.publish(req)
+}
+
+// publish 订单不存在时直接发单，已取消或创建失败的订单重新发布；查询订单失败时返回错误
+func (m *QuoteManager) publish(req *QueryDeliverFeeReq) (*AddOrderRes, error) {
+	order, queryErr := m.dd.QueryOrder(req.OriginID)
+	if queryErr != nil && !isCode(queryErr, CodeOrderNotExist) {
+		return nil, queryErr
+	}
+
+	if queryErr == nil && (order.StatusCode == OrderStatusCodeCanceled || order.StatusCode == OrderStatusCodeAddOrderFail) {
+		reAddReq := ReAddOrderReq(*req)
+		res, err := m.dd.ReAddOrder(&reAddReq)
+		if err != nil {
+			return nil, err
+		}
+
+		addRes := AddOrderRes(*res)
+		return &addRes, nil
+	}
+
+	addReq := AddOrderReq(*req)
+	return m.dd.AddOrder(&addReq)
+}
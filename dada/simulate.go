@@ -0,0 +1,66 @@
+package dada
+
+import (
+	"errors"
+	"net/url"
+)
+
+// 测试环境的订单不会自动流转，需调用以下模拟接口驱动订单状态；
+// 仅 Env 为 TestEnv 且实际访问的不是线上域名时可调用，未设置 Env 的客户端同样拒绝
+var ErrSimulateOnline = errors.New("dada simulate api is only available in test env")
+
+const (
+	simulateAcceptUrl       = "/api/order/accept"
+	simulateFetchUrl        = "/api/order/fetch"
+	simulateFinishUrl       = "/api/order/finish"
+	simulateCancelUrl       = "/api/order/cancel"
+	simulateExpireUrl       = "/api/order/expire"
+	simulateAbnormalBackUrl = "/api/order/delivery/abnormal/back"
+)
+
+type SimulateReq struct {
+	OrderID string `json:"order_id"`         // 是	第三方订单编号
+	Reason  string `json:"reason,omitempty"` // 否	取消原因，仅模拟取消时传
+}
+
+func (dd *Dada) simulate(api string, req *SimulateReq) error {
+	if dd.Env != TestEnv {
+		return ErrSimulateOnline
+	}
+	if u, urlErr := url.Parse(dd.genUrl(api)); urlErr != nil || u.Hostname() == onlineDomain {
+		return ErrSimulateOnline
+	}
+
+	_, err := dd.call(api, req)
+	return err
+}
+
+// SimulateAccept 模拟接受订单，订单状态变为待取货(2)
+func (dd *Dada) SimulateAccept(orderID string) error {
+	return dd.simulate(simulateAcceptUrl, &SimulateReq{OrderID: orderID})
+}
+
+// SimulateFetch 模拟完成取货，订单状态变为配送中(3)
+func (dd *Dada) SimulateFetch(orderID string) error {
+	return dd.simulate(simulateFetchUrl, &SimulateReq{OrderID: orderID})
+}
+
+// SimulateFinish 模拟完成订单，订单状态变为已完成(4)
+func (dd *Dada) SimulateFinish(orderID string) error {
+	return dd.simulate(simulateFinishUrl, &SimulateReq{OrderID: orderID})
+}
+
+// SimulateCancel 模拟取消订单，订单状态变为已取消(5)
+func (dd *Dada) SimulateCancel(orderID, reason string) error {
+	return dd.simulate(simulateCancelUrl, &SimulateReq{OrderID: orderID, Reason: reason})
+}
+
+// SimulateExpire 模拟订单过期，订单状态变为已取消(5)
+func (dd *Dada) SimulateExpire(orderID string) error {
+	return dd.simulate(simulateExpireUrl, &SimulateReq{OrderID: orderID})
+}
+
+// SimulateAbnormalBack 模拟配送中的订单妥投异常，订单状态变为妥投异常之物品返回中(9)
+func (dd *Dada) SimulateAbnormalBack(orderID string) error {
+	return dd.simulate(simulateAbnormalBackUrl, &SimulateReq{OrderID: orderID})
+}
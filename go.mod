@@ -1,9 +1,10 @@
 module github.com/MangoMilk/go-sdk
 
-go 1.15
+go 1.18
 
 require (
 	github.com/MangoMilk/go-kit v0.0.8
 	github.com/aliyun/aliyun-oss-go-sdk v2.1.8+incompatible
-	golang.org/x/time v0.0.0-20210611083556-38a9dc6acbc6 // indirect
+	golang.org/x/crypto v0.0.0-20220214200702-86341886e292
+	golang.org/x/time v0.0.0-20210611083556-38a9dc6acbc6
 )
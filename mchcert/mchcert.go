@@ -0,0 +1,142 @@
+package mchcert
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"golang.org/x/crypto/pkcs12"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrNoCertificate = errors.New("merchant certificate not configured")
+
+// ==================== 商户API证书 ====================
+// Certificate 商户 API 证书，用于退款、红包、企业付款等需要双向 TLS 的接口；
+// 同一证书的 http.Client 只创建一次并复用连接
+type Certificate struct {
+	tls.Certificate
+
+	once   sync.Once
+	client *http.Client
+}
+
+// New 使用已加载的 tls.Certificate
+func New(certificate tls.Certificate) *Certificate {
+	return &Certificate{Certificate: certificate}
+}
+
+// FromPEM 使用 PEM 格式的证书（apiclient_cert.pem）和私钥（apiclient_key.pem）内容
+func FromPEM(certPEM, keyPEM []byte) (*Certificate, error) {
+	certificate, pairErr := tls.X509KeyPair(certPEM, keyPEM)
+	if pairErr != nil {
+		return nil, pairErr
+	}
+
+	return New(certificate), nil
+}
+
+// FromPKCS12 使用 PKCS#12 格式的证书（apiclient_cert.p12）内容，微信支付证书密码默认为商户号
+func FromPKCS12(data []byte, password string) (*Certificate, error) {
+	blocks, decodeErr := pkcs12.ToPEM(data, password)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	var certPEM, keyPEM []byte
+	for _, block := range blocks {
+		if block.Type == "PRIVATE KEY" {
+			keyPEM = append(keyPEM, pem.EncodeToMemory(block)...)
+		} else {
+			certPEM = append(certPEM, pem.EncodeToMemory(block)...)
+		}
+	}
+
+	return FromPEM(certPEM, keyPEM)
+}
+
+// FromFile 使用 PEM 格式的证书和私钥文件路径
+func FromFile(certFile, keyFile string) (*Certificate, error) {
+	certificate, loadErr := tls.LoadX509KeyPair(certFile, keyFile)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+
+	return New(certificate), nil
+}
+
+// Client 返回携带该证书的 http.Client，首次调用时创建
+func (c *Certificate) Client() *http.Client {
+	c.once.Do(func() {
+		c.client = &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{Certificates: []tls.Certificate{c.Certificate}},
+			},
+		}
+	})
+
+	return c.client
+}
+
+// Files 按文件路径加载证书，证书或私钥文件的修改时间、大小变化（如证书续期）时重新加载，
+// 未变化时复用已加载的证书及其 http.Client；零值可用，由持有它的 QQ/Wechat 实例各自缓存
+type Files struct {
+	mu    sync.Mutex
+	certs map[string]*fileCert
+}
+
+type fileCert struct {
+	certStat fileStat
+	keyStat  fileStat
+	cert     *Certificate
+}
+
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+func statFile(name string) (fileStat, error) {
+	info, statErr := os.Stat(name)
+	if statErr != nil {
+		return fileStat{}, statErr
+	}
+
+	return fileStat{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// Load 同 FromFile，文件未变化时返回缓存的证书
+func (f *Files) Load(certFile, keyFile string) (*Certificate, error) {
+	certStat, certStatErr := statFile(certFile)
+	if certStatErr != nil {
+		return nil, certStatErr
+	}
+	keyStat, keyStatErr := statFile(keyFile)
+	if keyStatErr != nil {
+		return nil, keyStatErr
+	}
+
+	key := certFile + "\x00" + keyFile
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if cached, ok := f.certs[key]; ok && cached.certStat == certStat && cached.keyStat == keyStat {
+		return cached.cert, nil
+	}
+
+	c, loadErr := FromFile(certFile, keyFile)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	if f.certs == nil {
+		f.certs = make(map[string]*fileCert)
+	}
+	f.certs[key] = &fileCert{certStat: certStat, keyStat: keyStat, cert: c}
+
+	return c, nil
+}
@@ -0,0 +1,104 @@
+package mchcert
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readFile(t *testing.T, name string) []byte {
+	data, readErr := ioutil.ReadFile("testdata/" + name)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+
+	return data
+}
+
+func TestFromPKCS12(t *testing.T) {
+	c, err := FromPKCS12(readFile(t, "apiclient_cert.p12"), "1900000109")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pemCert, pemErr := FromPEM(readFile(t, "apiclient_cert.pem"), readFile(t, "apiclient_key.pem"))
+	if pemErr != nil {
+		t.Fatal(pemErr)
+	}
+	if string(c.Certificate.Certificate[0]) != string(pemCert.Certificate.Certificate[0]) {
+		t.Fatal("p12 and pem certificates differ")
+	}
+
+	if _, err := FromPKCS12(readFile(t, "apiclient_cert.p12"), "wrong"); err == nil {
+		t.Fatal("wrong password should fail")
+	}
+}
+
+func TestFilesLoad(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "apiclient_cert.pem"), filepath.Join(dir, "apiclient_key.pem")
+	if err := ioutil.WriteFile(certFile, readFile(t, "apiclient_cert.pem"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, readFile(t, "apiclient_key.pem"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var files Files
+	a, err := files.Load(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := files.Load(certFile, keyFile)
+	if a != b || a.Client() != b.Client() {
+		t.Fatal("same files should reuse certificate and client")
+	}
+
+	// 证书续期后重新加载
+	renewed := time.Now().Add(time.Hour)
+	if err := os.Chtimes(certFile, renewed, renewed); err != nil {
+		t.Fatal(err)
+	}
+	c, err := files.Load(certFile, keyFile)
+	if err != nil || c == a {
+		t.Fatalf("renewed certificate should be reloaded, got %v", err)
+	}
+
+	if _, err := files.Load(filepath.Join(dir, "missing.pem"), keyFile); err == nil {
+		t.Fatal("missing cert file should fail")
+	}
+}
+
+func TestClientPresentsCertificate(t *testing.T) {
+	c, err := FromPEM(readFile(t, "apiclient_cert.pem"), readFile(t, "apiclient_key.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) != 1 || r.TLS.PeerCertificates[0].Subject.CommonName != "1900000109" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	// 测试服务端使用自签名证书，信任其根证书
+	client := c.Client()
+	client.Transport.(*http.Transport).TLSClientConfig.RootCAs = srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	resp, httpErr := client.Get(srv.URL)
+	if httpErr != nil {
+		t.Fatal(httpErr)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+}
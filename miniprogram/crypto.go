@@ -0,0 +1,134 @@
+package miniprogram
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrSignature        = errors.New("check signature fail")
+	ErrDecrypt          = errors.New("decrypt encrypted data fail")
+	ErrWatermarkAppID   = errors.New("watermark appid mismatch")
+	ErrWatermarkExpired = errors.New("watermark timestamp expired")
+	ErrWatermarkFuture  = errors.New("watermark timestamp in the future")
+)
+
+// DefaultWatermarkMaxAge 解密数据水印时间戳的默认有效期
+const DefaultWatermarkMaxAge = 10 * time.Minute
+
+// watermarkClockSkew 水印时间戳允许超前本机时间的时长
+const watermarkClockSkew = time.Minute
+
+// ==================== 数据签名校验 ====================
+// CheckSignature 校验 getUserInfo 返回的 rawData，signature = sha1(rawData + session_key)
+func CheckSignature(rawData, sessionKey, signature string) error {
+	sum := sha1.Sum([]byte(rawData + sessionKey))
+	if subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(signature)) != 1 {
+		return ErrSignature
+	}
+
+	return nil
+}
+
+// ==================== 加密数据解密 ====================
+// Watermark 数据水印
+type Watermark struct {
+	AppID     string `json:"appid"`     // 敏感数据归属 appid
+	Timestamp int64  `json:"timestamp"` // 敏感数据获取的时间戳
+}
+
+// Verify 校验水印 appid 与数据时效，maxAge 不大于 0 时使用 DefaultWatermarkMaxAge；
+// 时间戳超前本机时间 1 分钟以上时返回 ErrWatermarkFuture
+func (w Watermark) Verify(appID string, maxAge time.Duration) error {
+	if w.AppID != appID {
+		return ErrWatermarkAppID
+	}
+
+	if maxAge <= 0 {
+		maxAge = DefaultWatermarkMaxAge
+	}
+	ts := time.Unix(w.Timestamp, 0)
+	if time.Until(ts) > watermarkClockSkew {
+		return ErrWatermarkFuture
+	}
+	if time.Since(ts) > maxAge {
+		return ErrWatermarkExpired
+	}
+
+	return nil
+}
+
+// PhoneNumber 手机号
+type PhoneNumber struct {
+	PhoneNumber     string    `json:"phoneNumber"`     // 用户绑定的手机号（国外手机号会有区号）
+	PurePhoneNumber string    `json:"purePhoneNumber"` // 没有区号的手机号
+	CountryCode     string    `json:"countryCode"`     // 区号
+	Watermark       Watermark `json:"watermark"`
+}
+
+// UserInfo 用户信息
+type UserInfo struct {
+	OpenID    string    `json:"openId"`
+	UnionID   string    `json:"unionId"`
+	NickName  string    `json:"nickName"`
+	Gender    int       `json:"gender"` // 0 未知，1 男性，2 女性
+	City      string    `json:"city"`
+	Province  string    `json:"province"`
+	Country   string    `json:"country"`
+	AvatarUrl string    `json:"avatarUrl"`
+	Language  string    `json:"language"`
+	Watermark Watermark `json:"watermark"`
+}
+
+// ShareInfo 群分享信息
+type ShareInfo struct {
+	OpenGID   string    `json:"openGId"`   // 群对当前小程序的唯一 ID
+	ChatType  int       `json:"chatType"`  // 分享对话类型：1 群聊，2 单聊
+	Watermark Watermark `json:"watermark"` // 数据水印
+}
+
+// Decrypt 使用 session_key 解密 encryptedData（AES-128-CBC，PKCS#7 填充，参数均为 base64），并解码到 v
+func Decrypt(sessionKey, encryptedData, iv string, v interface{}) error {
+	key, keyErr := base64.StdEncoding.DecodeString(sessionKey)
+	if keyErr != nil {
+		return keyErr
+	}
+	ivBytes, ivErr := base64.StdEncoding.DecodeString(iv)
+	if ivErr != nil {
+		return ivErr
+	}
+	ciphertext, dataErr := base64.StdEncoding.DecodeString(encryptedData)
+	if dataErr != nil {
+		return dataErr
+	}
+
+	block, aesErr := aes.NewCipher(key)
+	if aesErr != nil {
+		return aesErr
+	}
+	if len(ivBytes) != block.BlockSize() || len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return ErrDecrypt
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, ivBytes).CryptBlocks(plaintext, ciphertext)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > block.BlockSize() {
+		return ErrDecrypt
+	}
+	for _, b := range plaintext[len(plaintext)-padding:] {
+		if int(b) != padding {
+			return ErrDecrypt
+		}
+	}
+
+	return json.Unmarshal(plaintext[:len(plaintext)-padding], v)
+}
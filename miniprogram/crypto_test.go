@@ -0,0 +1,73 @@
+package miniprogram
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func encryptData(key, iv []byte, plaintext string) string {
+	block, _ := aes.NewCipher(key)
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	data := append([]byte(plaintext), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func TestDecrypt(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	iv := []byte("fedcba9876543210")
+	sessionKey := base64.StdEncoding.EncodeToString(key)
+	ivStr := base64.StdEncoding.EncodeToString(iv)
+
+	plaintext := fmt.Sprintf(`{"phoneNumber":"+86 13800138000","purePhoneNumber":"13800138000","countryCode":"86","watermark":{"appid":"wx1","timestamp":%d}}`, time.Now().Unix())
+
+	var phone PhoneNumber
+	if err := Decrypt(sessionKey, encryptData(key, iv, plaintext), ivStr, &phone); err != nil {
+		t.Fatal(err)
+	}
+	if phone.PurePhoneNumber != "13800138000" {
+		t.Fatalf("decrypted %+v", phone)
+	}
+	if err := phone.Watermark.Verify("wx1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := phone.Watermark.Verify("wx2", 0); !errors.Is(err, ErrWatermarkAppID) {
+		t.Fatalf("want appid mismatch, got %v", err)
+	}
+
+	phone.Watermark.Timestamp = time.Now().Add(-time.Hour).Unix()
+	if err := phone.Watermark.Verify("wx1", 0); !errors.Is(err, ErrWatermarkExpired) {
+		t.Fatalf("want expired, got %v", err)
+	}
+	phone.Watermark.Timestamp = time.Now().Add(time.Hour).Unix()
+	if err := phone.Watermark.Verify("wx1", 0); !errors.Is(err, ErrWatermarkFuture) {
+		t.Fatalf("want future, got %v", err)
+	}
+
+	wrongKey := base64.StdEncoding.EncodeToString([]byte("abcdef0123456789"))
+	if err := Decrypt(wrongKey, encryptData(key, iv, plaintext), ivStr, &phone); err == nil {
+		t.Fatal("decrypt with wrong session_key should fail")
+	}
+}
+
+func TestCheckSignature(t *testing.T) {
+	rawData := `{"nickName":"Band","gender":1}`
+	sessionKey := "HyVFkGl5F5OQWJZZaNzBBg=="
+	sum := sha1.Sum([]byte(rawData + sessionKey))
+	signature := hex.EncodeToString(sum[:])
+
+	if err := CheckSignature(rawData, sessionKey, signature); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckSignature(rawData+" ", sessionKey, signature); !errors.Is(err, ErrSignature) {
+		t.Fatalf("want signature error, got %v", err)
+	}
+}
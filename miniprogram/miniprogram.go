@@ -0,0 +1,130 @@
+package miniprogram
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Client 微信、QQ小程序服务端接口的公共部分，登录等业务可以只依赖该接口切换平台
+type Client interface {
+	JsCode2Session(code string) (*Session, error)
+	GetAccessToken() (*AccessToken, error)
+	AccessToken() (string, error)
+	SubscribeSend(accessToken string, req *SubscribeSendReq) (*Result, error)
+}
+
+// ==================== 通用返回 ====================
+type Result struct {
+	ErrCode float64 `json:"errcode"`
+	ErrMsg  string  `json:"errmsg"`
+}
+
+// Error errcode 不为 0 时返回
+type Error struct {
+	ErrCode float64
+	ErrMsg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("miniprogram errcode=%v errmsg=%s", e.ErrCode, e.ErrMsg)
+}
+
+// ==================== 授权 ====================
+type Session struct {
+	ErrCode    float64 `json:"errcode"`
+	ErrMsg     string  `json:"errmsg"`
+	SessionKey string  `json:"session_key"`
+	OpenID     string  `json:"openid"`
+	UnionID    string  `json:"unionid"`
+}
+
+// ==================== 获取access_token ====================
+type AccessToken struct {
+	ErrCode     float64 `json:"errcode"`
+	ErrMsg      string  `json:"errmsg"`
+	ExpiresIn   float64 `json:"expires_in"`   // 凭证有效时间，单位：秒。目前是7200秒之内的值。
+	AccessToken string  `json:"access_token"` // 获取到的凭证
+}
+
+// TokenSource 获取新的 access_token
+type TokenSource interface {
+	GetAccessToken() (*AccessToken, error)
+}
+
+// 提前刷新 access_token 的时间，避免临界时刻使用过期凭证
+const tokenRefreshAhead = 5 * time.Minute
+
+// TokenCache 缓存 access_token，过期前自动刷新，可并发使用
+type TokenCache struct {
+	mu       sync.Mutex
+	source   TokenSource
+	token    string
+	expireAt time.Time
+	now      func() time.Time
+}
+
+func NewTokenCache(source TokenSource) *TokenCache {
+	return &TokenCache{source: source, now: time.Now}
+}
+
+// Token 返回缓存的 access_token，过期或即将过期时重新获取
+func (c *TokenCache) Token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && c.now().Before(c.expireAt) {
+		return c.token, nil
+	}
+
+	res, err := c.source.GetAccessToken()
+	if err != nil {
+		return "", err
+	}
+	if res.ErrCode != 0 || res.AccessToken == "" {
+		return "", &Error{ErrCode: res.ErrCode, ErrMsg: res.ErrMsg}
+	}
+
+	ttl := time.Duration(res.ExpiresIn) * time.Second
+	if ttl > 2*tokenRefreshAhead {
+		ttl -= tokenRefreshAhead
+	} else {
+		ttl /= 2
+	}
+
+	c.token = res.AccessToken
+	c.expireAt = c.now().Add(ttl)
+
+	return c.token, nil
+}
+
+// Invalidate 丢弃缓存的 access_token，用于接口返回凭证失效时强制刷新
+func (c *TokenCache) Invalidate() {
+	c.mu.Lock()
+	c.token = ""
+	c.mu.Unlock()
+}
+
+// InvalidateIf 缓存的 access_token 仍为 token 时才丢弃，并发请求同时返回凭证失效时只刷新一次
+func (c *TokenCache) InvalidateIf(token string) {
+	c.mu.Lock()
+	if c.token == token {
+		c.token = ""
+	}
+	c.mu.Unlock()
+}
+
+// ==================== 发送订阅通知 ====================
+type SubscribeSendReq struct {
+	ToUser           string                           `json:"touser"`                     // 是	接收者（用户）的 openid
+	TemplateID       string                           `json:"template_id"`                // 是	所需下发的订阅模板id
+	Page             string                           `json:"page"`                       // 否	点击模板卡片后的跳转页面，仅限本小程序内的页面。支持带参数,（示例index?foo=bar）。该字段不填则模板无跳转。
+	Data             map[string]SubscribeSendDataItem `json:"data"`                       // 是	模板内容，格式形如 { "key1": { "value": any }, "key2": { "value": any } }
+	MiniprogramState string                           `json:"miniprogram_state"`          // 否	微信：跳转小程序类型：developer为开发版；trial为体验版；formal为正式版；默认为正式版
+	Lang             string                           `json:"lang"`                       // 否	微信：进入小程序查看”的语言类型，支持zh_CN(简体中文)、en_US(英文)、zh_HK(繁体中文)、zh_TW(繁体中文)，默认为zh_CN
+	EmphasisKeyword  string                           `json:"emphasis_keyword,omitempty"` // 否	QQ：模板需要放大的关键词，不填则默认无放大
+}
+
+type SubscribeSendDataItem struct {
+	Value string `json:"value"`
+}
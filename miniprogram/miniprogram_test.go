@@ -0,0 +1,63 @@
+package miniprogram
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeSource struct {
+	calls int
+	res   AccessToken
+}
+
+func (s *fakeSource) GetAccessToken() (*AccessToken, error) {
+	s.calls++
+	res := s.res
+	return &res, nil
+}
+
+func TestTokenCache(t *testing.T) {
+	now := time.Now()
+	src := &fakeSource{res: AccessToken{AccessToken: "t1", ExpiresIn: 7200}}
+	cache := NewTokenCache(src)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if token, err := cache.Token(); err != nil || token != "t1" {
+			t.Fatalf("token %q err %v", token, err)
+		}
+	}
+	if src.calls != 1 {
+		t.Fatalf("source called %d times, want 1", src.calls)
+	}
+
+	src.res.AccessToken = "t2"
+	now = now.Add(7200*time.Second - tokenRefreshAhead)
+	if token, _ := cache.Token(); token != "t2" {
+		t.Fatalf("token should be refreshed before expiry, got %q", token)
+	}
+
+	src.res.AccessToken = "t3"
+	cache.Invalidate()
+	if token, _ := cache.Token(); token != "t3" || src.calls != 3 {
+		t.Fatalf("token %q calls %d after invalidate", token, src.calls)
+	}
+
+	src.res.AccessToken = "t4"
+	cache.InvalidateIf("t2")
+	if token, _ := cache.Token(); token != "t3" || src.calls != 3 {
+		t.Fatalf("stale token should not invalidate, got %q calls %d", token, src.calls)
+	}
+	cache.InvalidateIf("t3")
+	if token, _ := cache.Token(); token != "t4" || src.calls != 4 {
+		t.Fatalf("token %q calls %d after invalidate if", token, src.calls)
+	}
+
+	src.res = AccessToken{ErrCode: 40013, ErrMsg: "invalid appid"}
+	cache.Invalidate()
+	var mpErr *Error
+	if _, err := cache.Token(); !errors.As(err, &mpErr) || mpErr.ErrCode != 40013 {
+		t.Fatalf("want Error, got %v", err)
+	}
+}
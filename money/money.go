@@ -0,0 +1,334 @@
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+/*
+ 金额
+
+ 所有金额内部均以"分"为单位的整数保存，避免浮点误差；
+ Fen、Yuan 分别对应各服务商接口中"单位为分的整数"和"单位为元的小数"两种线上格式。
+*/
+
+type Currency string
+
+const (
+	CNY = Currency("CNY") // 人民币，境内商户号仅支持人民币
+)
+
+type Money struct {
+	fen      int64
+	currency Currency
+}
+
+func New(fen int64, currency Currency) Money {
+	if currency == "" {
+		currency = CNY
+	}
+
+	return Money{fen: fen, currency: currency}
+}
+
+// FromFen 以分为单位构造人民币金额
+func FromFen(fen int64) Money {
+	return New(fen, CNY)
+}
+
+// FromYuan 以元为单位构造人民币金额，四舍五入到分（仅用于兼容浮点来源的数据）
+func FromYuan(yuan float64) Money {
+	return New(int64(math.Round(yuan*100)), CNY)
+}
+
+// ParseYuan 精确解析以元为单位的十进制字符串，如 "10", "10.5", "-0.01"，最多两位小数
+func ParseYuan(s string) (Money, error) {
+	fen, err := parseYuan(s)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return New(fen, CNY), nil
+}
+
+func MustParseYuan(s string) Money {
+	m, err := ParseYuan(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
+
+func (m Money) Currency() Currency {
+	if m.currency == "" {
+		return CNY
+	}
+
+	return m.currency
+}
+
+func (m Money) Fen() Fen {
+	return Fen(m.fen)
+}
+
+func (m Money) Yuan() Yuan {
+	return Yuan{fen: m.fen}
+}
+
+func (m Money) IsZero() bool {
+	return m.fen == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.fen < 0
+}
+
+func (m Money) Neg() Money {
+	return New(-m.fen, m.Currency())
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return Money{}, err
+	}
+
+	return New(m.fen+o.fen, m.Currency()), nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return Money{}, err
+	}
+
+	return New(m.fen-o.fen, m.Currency()), nil
+}
+
+// Mul 金额乘以整数，如单价 * 数量
+func (m Money) Mul(n int64) Money {
+	return New(m.fen*n, m.Currency())
+}
+
+// Cmp 比较两个金额，m < o 返回 -1，相等返回 0，m > o 返回 1
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m.fen < o.fen:
+		return -1, nil
+	case m.fen > o.fen:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+func (m Money) Equal(o Money) bool {
+	return m.fen == o.fen && m.Currency() == o.Currency()
+}
+
+// String 形如 "10.50 CNY"
+func (m Money) String() string {
+	return formatYuan(m.fen) + " " + string(m.Currency())
+}
+
+func (m Money) checkCurrency(o Money) error {
+	if m.Currency() != o.Currency() {
+		return fmt.Errorf("money: currency mismatch %s != %s", m.Currency(), o.Currency())
+	}
+
+	return nil
+}
+
+// ==================== 线上格式：分 ====================
+// Fen 单位为分的整数金额，微信支付（v2/v3）、QQ钱包等接口使用
+type Fen int64
+
+func (f Fen) Money() Money {
+	return FromFen(int64(f))
+}
+
+// ==================== 线上格式：元 ====================
+// Yuan 单位为元的金额，序列化为保留两位小数的 JSON 数字，达达等接口使用
+type Yuan struct {
+	fen int64
+}
+
+func YuanFromFen(fen int64) Yuan {
+	return Yuan{fen: fen}
+}
+
+func (y Yuan) Money() Money {
+	return FromFen(y.fen)
+}
+
+func (y Yuan) Fen() Fen {
+	return Fen(y.fen)
+}
+
+func (y Yuan) IsZero() bool {
+	return y.fen == 0
+}
+
+// String 形如 "10.50"
+func (y Yuan) String() string {
+	return formatYuan(y.fen)
+}
+
+func (y Yuan) MarshalJSON() ([]byte, error) {
+	return []byte(formatYuan(y.fen)), nil
+}
+
+func (y *Yuan) UnmarshalJSON(data []byte) error {
+	s := strings.TrimSpace(string(data))
+	if s == "null" {
+		return nil
+	}
+
+	// 兼容以字符串形式返回的金额，如 "12.5"
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		s = strings.TrimSpace(str)
+		if s == "" {
+			y.fen = 0
+			return nil
+		}
+	}
+
+	fen, err := parseYuan(s)
+	if err != nil {
+		return err
+	}
+	y.fen = fen
+
+	return nil
+}
+
+func formatYuan(fen int64) string {
+	sign := ""
+	u := uint64(fen)
+	if fen < 0 {
+		sign = "-"
+		u = uint64(-fen)
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, u/100, u%100)
+}
+
+func parseYuan(s string) (int64, error) {
+	raw := s
+	if s == "" {
+		return 0, fmt.Errorf("money: invalid yuan amount %q", raw)
+	}
+
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	// JSON 数字可能使用科学计数法，如 1e2，按指数移动小数点后按普通小数精确解析
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		shifted, ok := shiftDecimal(s[:i], s[i+1:])
+		if !ok {
+			return 0, fmt.Errorf("money: invalid yuan amount %q", raw)
+		}
+		s = shifted
+	}
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return 0, fmt.Errorf("money: invalid yuan amount %q", raw)
+	}
+
+	// 超过两位的小数只允许是 0，如 "10.500"
+	if len(fracPart) > 2 {
+		if strings.Trim(fracPart[2:], "0") != "" {
+			return 0, fmt.Errorf("money: yuan amount %q has more than 2 decimal places", raw)
+		}
+		fracPart = fracPart[:2]
+	}
+	for len(fracPart) < 2 {
+		fracPart += "0"
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, fmt.Errorf("money: invalid yuan amount %q", raw)
+	}
+
+	yuan, intErr := strconv.ParseInt(intPart, 10, 64)
+	if intErr != nil {
+		return 0, fmt.Errorf("money: invalid yuan amount %q", raw)
+	}
+	fen, fracErr := strconv.ParseInt(fracPart, 10, 64)
+	if fracErr != nil {
+		return 0, fmt.Errorf("money: invalid yuan amount %q", raw)
+	}
+	if yuan > (math.MaxInt64-fen)/100 {
+		return 0, fmt.Errorf("money: yuan amount %q overflows", raw)
+	}
+
+	total := yuan*100 + fen
+	if neg {
+		total = -total
+	}
+
+	return total, nil
+}
+
+// maxYuanExponent 科学计数法允许的最大指数绝对值，超过时必然溢出或超过两位小数
+const maxYuanExponent = 20
+
+// shiftDecimal 将 mantissa（不带符号）的小数点按 exp 移动，如 ("1.5", "-1") 返回 ".15"
+func shiftDecimal(mantissa, exp string) (string, bool) {
+	e, expErr := strconv.Atoi(exp)
+	if expErr != nil || e > maxYuanExponent || e < -maxYuanExponent {
+		return "", false
+	}
+
+	intPart, fracPart := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		intPart, fracPart = mantissa[:i], mantissa[i+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return "", false
+	}
+
+	digits := intPart + fracPart
+	point := len(intPart) + e
+	if point < 0 {
+		digits = strings.Repeat("0", -point) + digits
+		point = 0
+	}
+	if point > len(digits) {
+		digits += strings.Repeat("0", point-len(digits))
+	}
+
+	return digits[:point] + "." + digits[point:], true
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,113 @@
+package money
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+)
+
+func TestParseYuan(t *testing.T) {
+	cases := map[string]int64{
+		"10":     1000,
+		"10.5":   1050,
+		"10.50":  1050,
+		"0.01":   1,
+		".5":     50,
+		"-0.01":  -1,
+		"10.500": 1050,
+		"1e2":    10000,
+		"1.5E-1": 15,
+		"-25e-2": -25,
+		"1.00e0": 100,
+	}
+
+	for s, fen := range cases {
+		m, err := ParseYuan(s)
+		if err != nil {
+			t.Errorf("ParseYuan(%q): %v", s, err)
+			continue
+		}
+		if int64(m.Fen()) != fen {
+			t.Errorf("ParseYuan(%q) = %d fen, want %d", s, m.Fen(), fen)
+		}
+	}
+
+	for _, s := range []string{"", ".", "abc", "10.001", "1.-5", "--1", "1.001e0", "1e-3", "e2", "1e", "1e99", "1e+-2"} {
+		if _, err := ParseYuan(s); err == nil {
+			t.Errorf("ParseYuan(%q) should fail", s)
+		}
+	}
+}
+
+func TestFromYuanRounding(t *testing.T) {
+	// 0.1 + 0.2 = 0.30000000000000004
+	if fen := FromYuan(0.1 + 0.2).Fen(); fen != 30 {
+		t.Errorf("FromYuan(0.1+0.2) = %d fen, want 30", fen)
+	}
+	if fen := FromYuan(19.99).Fen(); fen != 1999 {
+		t.Errorf("FromYuan(19.99) = %d fen, want 1999", fen)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	price := MustParseYuan("19.99")
+	total := price.Mul(3)
+	if total.Fen() != 5997 {
+		t.Fatalf("19.99 * 3 = %s", total)
+	}
+
+	delivery := MustParseYuan("5.01")
+	sum, err := total.Add(delivery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.String() != "64.98 CNY" {
+		t.Errorf("sum = %s", sum)
+	}
+
+	if _, err := sum.Add(New(1, Currency("USD"))); err == nil {
+		t.Error("adding different currencies should fail")
+	}
+
+	if c, _ := delivery.Cmp(total); c != -1 {
+		t.Errorf("Cmp = %d, want -1", c)
+	}
+}
+
+func TestYuanJSON(t *testing.T) {
+	type fee struct {
+		Fee  Yuan `json:"fee"`
+		Tips Yuan `json:"tips"`
+	}
+
+	var f fee
+	if err := json.Unmarshal([]byte(`{"fee":12.3,"tips":"1.5"}`), &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Fee.Fen() != 1230 || f.Tips.Fen() != 150 {
+		t.Fatalf("decoded %+v", f)
+	}
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"fee":12.30,"tips":1.50}` {
+		t.Errorf("encoded %s", b)
+	}
+}
+
+func TestFenXML(t *testing.T) {
+	type order struct {
+		XMLName  xml.Name `xml:"xml"`
+		TotalFee Fen      `xml:"total_fee"`
+	}
+
+	b, err := xml.Marshal(order{TotalFee: MustParseYuan("1.01").Fen()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "<xml><total_fee>101</total_fee></xml>" {
+		t.Errorf("encoded %s", b)
+	}
+}
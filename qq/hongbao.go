@@ -0,0 +1,228 @@
+package qq
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/MangoMilk/go-sdk/money"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ==================== 现金红包 ====================
+const HBRetCodeSuccess = "0"
+
+// HBError 红包接口 retcode 不为 0 时返回
+type HBError struct {
+	RetCode string `json:"retcode"`
+	RetMsg  string `json:"retmsg"`
+}
+
+func (e *HBError) Error() string {
+	return fmt.Sprintf("qpay hongbao retcode=%s retmsg=%s", e.RetCode, e.RetMsg)
+}
+
+type QPayHBRes struct {
+	RetCode string `json:"retcode"`
+	RetMsg  string `json:"retmsg"`
+	ListID  string `json:"listid"` // QQ红包订单号
+}
+
+// QPayHB 发放现金红包，mchBillno 为空时自动生成
+func (qq *QQ) QPayHB(openId string, iconID string, bannerID string, mchBillno string, sender string, nonceStr string, totalFree money.Fen, actName string, wishing string) (*QPayHBRes, error) {
+	if mchBillno == "" {
+		mchBillno = qq.GenMchBillNo()
+	}
+	if nonceStr == "" {
+		nonceStr = genNonceStr()
+	}
+
+	var totalFreeStr = strconv.FormatInt(int64(totalFree), 10)
+	var data = make(map[string]string)
+	data["charset"] = "1"
+	data["nonce_str"] = nonceStr
+	data["mch_billno"] = mchBillno
+	data["mch_id"] = qq.MchID
+	data["mch_name"] = sender //红包发送者名称
+	data["qqappid"] = qq.AppID
+	data["re_openid"] = openId
+	data["total_amount"] = totalFreeStr //发放总金额(单位：分)
+	data["total_num"] = "1"             //红包发送总人数(目前限制为1)
+	data["wishing"] = wishing           //红包祝福语
+	data["act_name"] = actName          //活动名称
+	data["icon_id"] = iconID            //qq coin_id
+	data["banner_id"] = bannerID        //qq banner_id
+	data["min_value"] = "1"             //单个红包的最小金额(单位：分)
+	data["max_value"] = totalFreeStr    //单个红包的最大金额(单位：分)
+
+	data["sign"] = genSign(data, qq.ApiKey)
+
+	var response QPayHBRes
+	if err := qq.postHB(PayHBApi, data, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// ==================== 红包详情查询 ====================
+// HBState 红包状态
+type HBState int
+
+const (
+	HBStateSending  HBState = 1 // 发放中/待领取
+	HBStateReceived HBState = 2 // 已领完
+	HBStateRefunded HBState = 3 // 已过期退款
+)
+
+type QPayHBQueryReq struct {
+	SendListID string `xml:"send_listid"` // 是，QQ红包订单号，即发放接口返回的listid
+	SubMchID   string `xml:"sub_mch_id"`  // 否，子商户号，服务商模式下填写
+	MchID      string `xml:"mch_id"`      // 是，QQ钱包分配的商户号，不传默认为QQ.MchID
+	NonceStr   string `xml:"nonce_str"`   // 是，随机字符串，不传自动生成
+}
+
+// HBRecvDetail 红包领取记录
+type HBRecvDetail struct {
+	Uin        string    `json:"uin"`         // 领取用户
+	Amount     money.Fen `json:"amount"`      // 领取金额，单位为分
+	CreateTime string    `json:"create_time"` // 领取时间
+}
+
+type qPayHBQueryRes struct {
+	RetCode      string         `json:"retcode"`
+	RetMsg       string         `json:"retmsg"`
+	ListID       string         `json:"listid"`        // QQ红包订单号
+	State        HBState        `json:"state"`         // 红包状态
+	TotalNum     int            `json:"total_num"`     // 红包总个数
+	RecvNum      int            `json:"recv_num"`      // 已领取个数
+	TotalAmount  money.Fen      `json:"total_amount"`  // 红包总金额，单位为分
+	RecvAmount   money.Fen      `json:"recv_amount"`   // 已领取金额，单位为分
+	RefundAmount money.Fen      `json:"refund_amount"` // 退款金额，单位为分
+	SendTime     string         `json:"send_time"`     // 发放时间
+	RefundTime   string         `json:"refund_time"`   // 退款时间
+	RecvDetails  []HBRecvDetail `json:"recv_details"`  // 领取记录
+}
+
+// QPayHBQuery 查询红包领取和退款状态
+func (qq *QQ) QPayHBQuery(req *QPayHBQueryReq) (*qPayHBQueryRes, error) {
+	if req.MchID == "" {
+		req.MchID = qq.MchID
+	}
+	if req.NonceStr == "" {
+		req.NonceStr = genNonceStr()
+	}
+
+	data := structToParams(req)
+	data["sign"] = genSign(data, qq.ApiKey)
+
+	var response qPayHBQueryRes
+	if err := qq.postHB(PayHBQueryApi, data, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// postHB 以表单方式请求红包接口，响应为 json，retcode 不为 0 时返回 HBError
+func (qq *QQ) postHB(api string, data map[string]string, res interface{}) error {
+	client, certErr := qq.certClient()
+	if certErr != nil {
+		return certErr
+	}
+
+	form := url.Values{}
+	for k, v := range data {
+		form.Set(k, v)
+	}
+
+	body, httpErr := httpPost(client, api, "application/x-www-form-urlencoded", form.Encode())
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return decodeHBResponse(body, res)
+}
+
+func decodeHBResponse(body []byte, res interface{}) error {
+	var ret HBError
+	if jsonErr := json.Unmarshal(body, &ret); jsonErr != nil {
+		return jsonErr
+	}
+	if ret.RetCode != HBRetCodeSuccess {
+		return &ret
+	}
+
+	return json.Unmarshal(body, res)
+}
+
+// ==================== 商户订单号 ====================
+const billNoSeqSize = 100000
+
+// MchBillNoGenerator 生成 mch_billno：mch_id + yyyymmdd + 10位当天不重复数字，
+// 10位数字由当天已过秒数（5位）和秒内序号（5位）组成，序号起点随机以降低多实例间碰撞概率，
+// 单秒内序号用尽时等待下一秒（等待期间不持有锁），可并发使用
+type MchBillNoGenerator struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	sec   int64
+	start int
+	count int
+}
+
+func NewMchBillNoGenerator() *MchBillNoGenerator {
+	return &MchBillNoGenerator{now: time.Now}
+}
+
+func (g *MchBillNoGenerator) Next(mchID string) string {
+	for {
+		t, seq, wait := g.next()
+		if wait > 0 {
+			time.Sleep(wait)
+			continue
+		}
+
+		secOfDay := t.Hour()*3600 + t.Minute()*60 + t.Second()
+		return mchID + t.Format("20060102") + fmt.Sprintf("%05d%05d", secOfDay, seq)
+	}
+}
+
+// next 分配当前秒内的序号，序号用尽时返回距下一秒的等待时长
+func (g *MchBillNoGenerator) next() (time.Time, int, time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	t := g.now()
+	if t.Unix() != g.sec {
+		g.sec = t.Unix()
+		g.start = randSeq()
+		g.count = 0
+	}
+	if g.count >= billNoSeqSize {
+		return t, 0, time.Unix(g.sec+1, 0).Sub(t)
+	}
+
+	seq := (g.start + g.count) % billNoSeqSize
+	g.count++
+
+	return t, seq, 0
+}
+
+func randSeq() int {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return int(time.Now().UnixNano() % billNoSeqSize)
+	}
+
+	return int(binary.BigEndian.Uint32(b) % billNoSeqSize)
+}
+
+var defaultMchBillNoGenerator = NewMchBillNoGenerator()
+
+// GenMchBillNo 使用默认生成器为 QQ.MchID 生成商户订单号
+func (qq *QQ) GenMchBillNo() string {
+	return defaultMchBillNoGenerator.Next(qq.MchID)
+}
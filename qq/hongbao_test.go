@@ -0,0 +1,72 @@
+package qq
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMchBillNoGenerator(t *testing.T) {
+	g := NewMchBillNoGenerator()
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.Local)
+	g.now = func() time.Time { return now }
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]bool)
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				no := g.Next(qq.MchID)
+				mu.Lock()
+				if seen[no] {
+					t.Errorf("duplicate mch_billno %s", no)
+				}
+				seen[no] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for no := range seen {
+		if len(no) != len(qq.MchID)+18 || no[:len(qq.MchID)+13] != qq.MchID+"2021010211045" {
+			t.Fatalf("unexpected mch_billno %s", no)
+		}
+		break
+	}
+
+	// 序号用尽时返回等待时长，等待期间不持有锁
+	now = now.Add(time.Second + 300*time.Millisecond)
+	g.Next(qq.MchID)
+	g.count = billNoSeqSize
+	if _, _, wait := g.next(); wait != 700*time.Millisecond {
+		t.Fatalf("wait %v, want 700ms", wait)
+	}
+	if !g.mu.TryLock() {
+		t.Fatal("generator should not hold the lock while waiting")
+	}
+	g.mu.Unlock()
+}
+
+func TestDecodeHBResponse(t *testing.T) {
+	var res qPayHBQueryRes
+	body := []byte(`{"retcode":"0","retmsg":"ok","listid":"l1","state":2,"total_num":1,"recv_num":1,"total_amount":100,"recv_amount":100,"recv_details":[{"uin":"o1","amount":100,"create_time":"2021-01-02 03:04:05"}]}`)
+	if err := decodeHBResponse(body, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.State != HBStateReceived || res.RecvAmount != 100 || len(res.RecvDetails) != 1 {
+		t.Fatalf("decoded %+v", res)
+	}
+
+	var hbErr *HBError
+	fail := []byte(`{"retcode":"66227001","retmsg":"余额不足"}`)
+	if err := decodeHBResponse(fail, &res); !errors.As(err, &hbErr) || hbErr.RetCode != "66227001" {
+		t.Fatalf("want HBError, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package qq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/MangoMilk/go-kit/net"
+	"github.com/MangoMilk/go-sdk/miniprogram"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+const (
+	jsCode2SessionUrl = "https://api.q.qq.com/sns/jscode2session"
+	accessTokenUrl    = "https://api.q.qq.com/api/getToken"
+	subscribeSendUrl  = "https://api.q.qq.com/api/json/subscribe/SendSubscriptionMessage"
+	createMiniCodeUrl = "https://api.q.qq.com/api/json/qqa/CreateMiniCode"
+)
+
+var _ miniprogram.Client = (*QQ)(nil)
+
+// ==================== 授权 ====================
+func (qq *QQ) JsCode2Session(code string) (*miniprogram.Session, error) {
+	queryParam := url.Values{
+		"appid":      {qq.AppID},
+		"secret":     {qq.AppSecret},
+		"js_code":    {code},
+		"grant_type": {"authorization_code"},
+	}
+	res, httpErr := net.HttpGet(jsCode2SessionUrl+"?"+queryParam.Encode(), nil)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	var data miniprogram.Session
+
+	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	return &data, nil
+}
+
+// ==================== 获取access_token ====================
+func (qq *QQ) GetAccessToken() (*miniprogram.AccessToken, error) {
+	queryParam := url.Values{
+		"grant_type": {"client_credential"},
+		"appid":      {qq.AppID},
+		"secret":     {qq.AppSecret},
+	}
+	res, httpErr := net.HttpGet(accessTokenUrl+"?"+queryParam.Encode(), nil)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	var data miniprogram.AccessToken
+
+	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	return &data, nil
+}
+
+// AccessToken 返回缓存的 access_token，过期前自动调用 GetAccessToken 刷新
+func (qq *QQ) AccessToken() (string, error) {
+	qq.tokenOnce.Do(func() {
+		qq.tokenCache = miniprogram.NewTokenCache(qq)
+	})
+
+	return qq.tokenCache.Token()
+}
+
+// ==================== 发送订阅通知 ====================
+func (qq *QQ) SubscribeSend(accessToken string, req *miniprogram.SubscribeSendReq) (*miniprogram.Result, error) {
+	queryParam := "?access_token=" + url.QueryEscape(accessToken)
+	res, httpErr := net.HttpPost(subscribeSendUrl+queryParam, *req, nil, false, "", "")
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	var data miniprogram.Result
+	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	return &data, nil
+}
+
+// ==================== 获取二维码 ====================
+type CreateMiniCodeReq struct {
+	AppID string `json:"appid"` // 是 小程序appid，不传默认为QQ.AppID
+	Path  string `json:"path"`  // 是 扫码进入的小程序页面路径，可携带参数，例如 pages/index/index?foo=bar
+}
+
+type createMiniCodeRes struct {
+	ErrCode float64 `json:"errcode"`
+	ErrMsg  string  `json:"errmsg"`
+	Buffer  []byte  `json:"buffer"`
+}
+
+func (qq *QQ) CreateMiniCode(accessToken string, req *CreateMiniCodeReq) (*createMiniCodeRes, error) {
+	if req.AppID == "" {
+		req.AppID = qq.AppID
+	}
+
+	return postMiniCode(createMiniCodeUrl+"?access_token="+url.QueryEscape(accessToken), req)
+}
+
+// postMiniCode 接口成功时返回图片，失败时返回 json 格式的错误信息，以 Content-Type 区分
+func postMiniCode(api string, req interface{}) (*createMiniCodeRes, error) {
+	reqBody, jsonErr := json.Marshal(req)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	resp, httpErr := httpClient.Post(api, "application/json", bytes.NewReader(reqBody))
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	defer resp.Body.Close()
+
+	body, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if strings.HasPrefix(mediaType, "image/") {
+		return &createMiniCodeRes{Buffer: body}, nil
+	}
+
+	var data createMiniCodeRes
+	if jsonErr := json.Unmarshal(body, &data); jsonErr != nil {
+		return nil, fmt.Errorf("unexpected content type %q: %w", contentType, jsonErr)
+	}
+
+	return &data, nil
+}
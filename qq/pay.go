@@ -0,0 +1,440 @@
+package qq
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/MangoMilk/go-sdk/money"
+	"github.com/MangoMilk/go-sdk/validate"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+/*
+ QQ钱包商户支付（小程序/公众号/APP/扫码）
+*/
+
+const (
+	unifiedOrderUrl = "https://qpay.qq.com/cgi-bin/pay/qpay_unified_order.cgi"
+	orderQueryUrl   = "https://qpay.qq.com/cgi-bin/pay/qpay_order_query.cgi"
+	closeOrderUrl   = "https://qpay.qq.com/cgi-bin/pay/qpay_close_order.cgi"
+	refundUrl       = "https://api.qpay.qq.com/cgi-bin/pay/qpay_refund.cgi"
+	refundQueryUrl  = "https://qpay.qq.com/cgi-bin/pay/qpay_refund_query.cgi"
+)
+
+// fillCommon 补全 appid、mch_id、nonce_str
+func (qq *QQ) fillCommon(appID, mchID, nonceStr *string) {
+	if *appID == "" {
+		*appID = qq.AppID
+	}
+	if *mchID == "" {
+		*mchID = qq.MchID
+	}
+	if *nonceStr == "" {
+		*nonceStr = genNonceStr()
+	}
+}
+
+// ==================== 统一下单 ====================
+type TradeType string
+
+const (
+	TradeTypeJsapi   = TradeType("JSAPI")   // 公众号支付
+	TradeTypeNative  = TradeType("NATIVE")  // 原生扫码支付
+	TradeTypeApp     = TradeType("APP")     // app支付
+	TradeTypeMiniApp = TradeType("MINIAPP") // QQ小程序支付
+)
+
+type UnifiedOrderReq struct {
+	XMLName        xml.Name  `xml:"xml"`
+	AppID          string    `xml:"appid"`            // 否，QQ钱包分配的公众号/小程序ID，不传默认为QQ.AppID
+	MchID          string    `xml:"mch_id"`           // 是，QQ钱包分配的商户号，不传默认为QQ.MchID
+	NonceStr       string    `xml:"nonce_str"`        // 是，随机字符串，不长于32位，不传自动生成
+	Sign           string    `xml:"sign"`             // 是，签名，自动生成
+	Body           string    `xml:"body"`             // 是，商品描述
+	Attach         string    `xml:"attach"`           // 否，附加数据，在查询API和支付通知中原样返回
+	OutTradeNo     string    `xml:"out_trade_no"`     // 是，商户订单号，32个字符内、可包含字母
+	FeeType        string    `xml:"fee_type"`         // 否，货币类型，默认CNY
+	TotalFee       money.Fen `xml:"total_fee"`        // 是，订单总金额，单位为分
+	SpbillCreateIP string    `xml:"spbill_create_ip"` // 是，用户端实际IP
+	TimeStart      string    `xml:"time_start"`       // 否，交易起始时间，格式为yyyyMMddHHmmss
+	TimeExpire     string    `xml:"time_expire"`      // 否，交易结束时间，格式为yyyyMMddHHmmss
+	LimitPay       string    `xml:"limit_pay"`        // 否，no_balance/no_anyone/no_ccp/no_credit，限制支付方式
+	ContractCode   string    `xml:"contract_code"`    // 否，合约协议号
+	PromotionTag   string    `xml:"promotion_tag"`    // 否，QQ钱包活动标识
+	TradeType      TradeType `xml:"trade_type"`       // 是，JSAPI/NATIVE/APP/MINIAPP
+	NotifyUrl      string    `xml:"notify_url"`       // 是，支付结果通知地址，不能携带参数
+	DeviceInfo     string    `xml:"device_info"`      // 否，调用接口提交的终端设备号
+}
+
+type unifiedOrderRes struct {
+	ReturnCode string    `xml:"return_code"`
+	ReturnMsg  string    `xml:"return_msg"`
+	RetCode    string    `xml:"retcode"`
+	RetMsg     string    `xml:"retmsg"`
+	AppID      string    `xml:"appid"`
+	MchID      string    `xml:"mch_id"`
+	NonceStr   string    `xml:"nonce_str"`
+	Sign       string    `xml:"sign"`
+	ResultCode string    `xml:"result_code"`
+	ErrCode    string    `xml:"err_code"`
+	ErrCodeDes string    `xml:"err_code_des"`
+	TradeType  TradeType `xml:"trade_type"`
+	PrepayID   string    `xml:"prepay_id"` // 预支付交易会话标识，有效期为2小时
+	CodeUrl    string    `xml:"code_url"`  // 二维码链接，trade_type为NATIVE时返回
+}
+
+func (qq *QQ) UnifiedOrder(req *UnifiedOrderReq) (*unifiedOrderRes, error) {
+	qq.fillCommon(&req.AppID, &req.MchID, &req.NonceStr)
+
+	var data unifiedOrderRes
+	if _, err := qq.post(unifiedOrderUrl, req, false, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// ==================== 订单查询 ====================
+type TradeState string
+
+const (
+	TradeStateSuccess    = TradeState("SUCCESS")    // 支付成功
+	TradeStateRefund     = TradeState("REFUND")     // 转入退款
+	TradeStateNotPay     = TradeState("NOTPAY")     // 未支付
+	TradeStateClosed     = TradeState("CLOSED")     // 已关闭
+	TradeStateRevoked    = TradeState("REVOKED")    // 已冲正
+	TradeStateUserPaying = TradeState("USERPAYING") // 用户支付中
+	TradeStatePayError   = TradeState("PAYERROR")   // 支付失败
+)
+
+type OrderQueryReq struct {
+	XMLName       xml.Name `xml:"xml"`
+	AppID         string   `xml:"appid"`          // 否，不传默认为QQ.AppID
+	MchID         string   `xml:"mch_id"`         // 是，不传默认为QQ.MchID
+	NonceStr      string   `xml:"nonce_str"`      // 是，不传自动生成
+	Sign          string   `xml:"sign"`           // 是，签名，自动生成
+	TransactionID string   `xml:"transaction_id"` // 二选一，QQ钱包订单号，优先使用
+	OutTradeNo    string   `xml:"out_trade_no"`   // 二选一，商户订单号
+}
+
+type orderQueryRes struct {
+	ReturnCode     string     `xml:"return_code"`
+	ReturnMsg      string     `xml:"return_msg"`
+	RetCode        string     `xml:"retcode"`
+	RetMsg         string     `xml:"retmsg"`
+	AppID          string     `xml:"appid"`
+	MchID          string     `xml:"mch_id"`
+	NonceStr       string     `xml:"nonce_str"`
+	Sign           string     `xml:"sign"`
+	ResultCode     string     `xml:"result_code"`
+	ErrCode        string     `xml:"err_code"`
+	ErrCodeDes     string     `xml:"err_code_des"`
+	DeviceInfo     string     `xml:"device_info"`
+	TradeType      TradeType  `xml:"trade_type"`
+	TradeState     TradeState `xml:"trade_state"`
+	TradeStateDesc string     `xml:"trade_state_desc"`
+	BankType       string     `xml:"bank_type"`
+	FeeType        string     `xml:"fee_type"`
+	TotalFee       money.Fen  `xml:"total_fee"`  // 订单金额，单位为分
+	CashFee        money.Fen  `xml:"cash_fee"`   // 用户本次交易中，实际支付的金额，单位为分
+	CouponFee      money.Fen  `xml:"coupon_fee"` // 本次交易中，QQ钱包提供的优惠金额，单位为分
+	TransactionID  string     `xml:"transaction_id"`
+	OutTradeNo     string     `xml:"out_trade_no"`
+	Attach         string     `xml:"attach"`
+	TimeEnd        string     `xml:"time_end"` // 支付完成时间，格式为yyyyMMddHHmmss
+	OpenID         string     `xml:"openid"`
+}
+
+func (qq *QQ) OrderQuery(req *OrderQueryReq) (*orderQueryRes, error) {
+	qq.fillCommon(&req.AppID, &req.MchID, &req.NonceStr)
+
+	var data orderQueryRes
+	if _, err := qq.post(orderQueryUrl, req, false, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// ==================== 关闭订单 ====================
+type CloseOrderReq struct {
+	XMLName    xml.Name `xml:"xml"`
+	AppID      string   `xml:"appid"`        // 否，不传默认为QQ.AppID
+	MchID      string   `xml:"mch_id"`       // 是，不传默认为QQ.MchID
+	NonceStr   string   `xml:"nonce_str"`    // 是，不传自动生成
+	Sign       string   `xml:"sign"`         // 是，签名，自动生成
+	OutTradeNo string   `xml:"out_trade_no"` // 是，商户订单号
+}
+
+type closeOrderRes struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	RetCode    string `xml:"retcode"`
+	RetMsg     string `xml:"retmsg"`
+	AppID      string `xml:"appid"`
+	MchID      string `xml:"mch_id"`
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+}
+
+func (qq *QQ) CloseOrder(req *CloseOrderReq) (*closeOrderRes, error) {
+	qq.fillCommon(&req.AppID, &req.MchID, &req.NonceStr)
+
+	var data closeOrderRes
+	if _, err := qq.post(closeOrderUrl, req, false, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// ==================== 申请退款（需要双向证书） ====================
+type RefundReq struct {
+	XMLName       xml.Name  `xml:"xml"`
+	AppID         string    `xml:"appid"`          // 否，不传默认为QQ.AppID
+	MchID         string    `xml:"mch_id"`         // 是，不传默认为QQ.MchID
+	NonceStr      string    `xml:"nonce_str"`      // 是，不传自动生成
+	Sign          string    `xml:"sign"`           // 是，签名，自动生成
+	TransactionID string    `xml:"transaction_id"` // 二选一，QQ钱包订单号，优先使用
+	OutTradeNo    string    `xml:"out_trade_no"`   // 二选一，商户订单号
+	OutRefundNo   string    `xml:"out_refund_no"`  // 是，商户退款单号，同一退款单号多次请求只退一笔
+	RefundFee     money.Fen `xml:"refund_fee"`     // 是，本次退款申请的退回金额，单位为分
+	OpUserID      string    `xml:"op_user_id"`     // 是，操作员帐号，不传默认为QQ.OPUserID
+	OpUserPasswd  string    `xml:"op_user_passwd"` // 是，操作员密码的MD5值，不传默认为QQ.OPUserPassword（原样使用，不做MD5）
+	RefundAccount string    `xml:"refund_account"` // 否，退款资金来源：1 未结算资金退款（默认），2 可用余额退款
+}
+
+type refundRes struct {
+	ReturnCode    string    `xml:"return_code"`
+	ReturnMsg     string    `xml:"return_msg"`
+	RetCode       string    `xml:"retcode"`
+	RetMsg        string    `xml:"retmsg"`
+	AppID         string    `xml:"appid"`
+	MchID         string    `xml:"mch_id"`
+	NonceStr      string    `xml:"nonce_str"`
+	Sign          string    `xml:"sign"`
+	ResultCode    string    `xml:"result_code"`
+	ErrCode       string    `xml:"err_code"`
+	ErrCodeDes    string    `xml:"err_code_des"`
+	TransactionID string    `xml:"transaction_id"`
+	OutTradeNo    string    `xml:"out_trade_no"`
+	TotalFee      money.Fen `xml:"total_fee"` // 订单总金额，单位为分
+	CashFee       money.Fen `xml:"cash_fee"`  // 用户实际支付金额，单位为分
+	OutRefundNo   string    `xml:"out_refund_no"`
+	RefundID      string    `xml:"refund_id"`      // QQ钱包退款单号
+	RefundChannel string    `xml:"refund_channel"` // 退款渠道
+	RefundFee     money.Fen `xml:"refund_fee"`     // 申请退款金额，单位为分
+}
+
+func (qq *QQ) Refund(req *RefundReq) (*refundRes, error) {
+	qq.fillCommon(&req.AppID, &req.MchID, &req.NonceStr)
+	if req.OpUserID == "" {
+		req.OpUserID = qq.OPUserID
+	}
+	if req.OpUserPasswd == "" {
+		req.OpUserPasswd = qq.OPUserPassword
+	}
+
+	var data refundRes
+	if _, err := qq.post(refundUrl, req, true, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// ==================== 退款查询 ====================
+type RefundStatus string
+
+const (
+	RefundStatusSuccess    = RefundStatus("SUCCESS")    // 退款成功
+	RefundStatusFail       = RefundStatus("FAIL")       // 退款失败
+	RefundStatusProcessing = RefundStatus("PROCESSING") // 退款处理中
+	RefundStatusChange     = RefundStatus("CHANGE")     // 转入代发，需要商户人工干预
+)
+
+type RefundQueryReq struct {
+	XMLName       xml.Name `xml:"xml"`
+	AppID         string   `xml:"appid"`          // 否，不传默认为QQ.AppID
+	MchID         string   `xml:"mch_id"`         // 是，不传默认为QQ.MchID
+	NonceStr      string   `xml:"nonce_str"`      // 是，不传自动生成
+	Sign          string   `xml:"sign"`           // 是，签名，自动生成
+	RefundID      string   `xml:"refund_id"`      // 四选一，QQ钱包退款单号，优先级最高
+	OutRefundNo   string   `xml:"out_refund_no"`  // 四选一，商户退款单号
+	TransactionID string   `xml:"transaction_id"` // 四选一，QQ钱包订单号
+	OutTradeNo    string   `xml:"out_trade_no"`   // 四选一，商户订单号
+}
+
+type RefundItem struct {
+	OutRefundNo   string       // 商户退款单号
+	RefundID      string       // QQ钱包退款单号
+	RefundChannel string       // 退款渠道
+	RefundFee     money.Fen    // 退款金额，单位为分
+	RefundStatus  RefundStatus // 退款状态
+}
+
+type refundQueryRes struct {
+	ReturnCode    string       `xml:"return_code"`
+	ReturnMsg     string       `xml:"return_msg"`
+	RetCode       string       `xml:"retcode"`
+	RetMsg        string       `xml:"retmsg"`
+	AppID         string       `xml:"appid"`
+	MchID         string       `xml:"mch_id"`
+	NonceStr      string       `xml:"nonce_str"`
+	Sign          string       `xml:"sign"`
+	ResultCode    string       `xml:"result_code"`
+	ErrCode       string       `xml:"err_code"`
+	ErrCodeDes    string       `xml:"err_code_des"`
+	TransactionID string       `xml:"transaction_id"`
+	OutTradeNo    string       `xml:"out_trade_no"`
+	TotalFee      money.Fen    `xml:"total_fee"`
+	CashFee       money.Fen    `xml:"cash_fee"`
+	RefundCount   int64        `xml:"refund_count"` // 退款笔数
+	Refunds       []RefundItem `xml:"-"`            // 由 out_refund_no_$n、refund_id_$n 等字段解析而来
+}
+
+func (qq *QQ) RefundQuery(req *RefundQueryReq) (*refundQueryRes, error) {
+	qq.fillCommon(&req.AppID, &req.MchID, &req.NonceStr)
+
+	var data refundQueryRes
+	params, err := qq.post(refundQueryUrl, req, false, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	refunds, parseErr := parseRefundItems(params, data.RefundCount)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	data.Refunds = refunds
+
+	return &data, nil
+}
+
+// parseRefundItems 解析 out_refund_no_$n、refund_fee_$n 等退款明细字段
+func parseRefundItems(params map[string]string, count int64) ([]RefundItem, error) {
+	refunds := make([]RefundItem, 0, count)
+	for i := int64(0); i < count; i++ {
+		n := strconv.FormatInt(i, 10)
+		refundFee, parseErr := strconv.ParseInt(params["refund_fee_"+n], 10, 64)
+		if parseErr != nil {
+			return nil, fmt.Errorf("qpay invalid refund_fee_%s %q: %w", n, params["refund_fee_"+n], parseErr)
+		}
+
+		refunds = append(refunds, RefundItem{
+			OutRefundNo:   params["out_refund_no_"+n],
+			RefundID:      params["refund_id_"+n],
+			RefundChannel: params["refund_channel_"+n],
+			RefundFee:     money.Fen(refundFee),
+			RefundStatus:  RefundStatus(params["refund_status_"+n]),
+		})
+	}
+
+	return refunds, nil
+}
+
+// ==================== 支付结果通知 ====================
+type PaymentNotifyReq struct {
+	AppID         string     `xml:"appid" validate:"required"`
+	MchID         string     `xml:"mch_id" validate:"required"`
+	NonceStr      string     `xml:"nonce_str" validate:"required"`
+	Sign          string     `xml:"sign" validate:"required"`
+	DeviceInfo    string     `xml:"device_info"`
+	TradeType     TradeType  `xml:"trade_type" validate:"required"`
+	TradeState    TradeState `xml:"trade_state" validate:"required"`
+	BankType      string     `xml:"bank_type"`
+	FeeType       string     `xml:"fee_type"`
+	TotalFee      money.Fen  `xml:"total_fee" validate:"required"`
+	CashFee       money.Fen  `xml:"cash_fee"`
+	CouponFee     money.Fen  `xml:"coupon_fee"`
+	TransactionID string     `xml:"transaction_id" validate:"required"`
+	OutTradeNo    string     `xml:"out_trade_no" validate:"required"`
+	Attach        string     `xml:"attach"`
+	TimeEnd       string     `xml:"time_end" validate:"required"`
+	OpenID        string     `xml:"openid"`
+}
+
+// Validate 校验通知的必填参数
+func (req *PaymentNotifyReq) Validate() error {
+	return validate.Struct(req)
+}
+
+type NotifyRes struct {
+	XMLName    xml.Name `xml:"xml"`
+	ReturnCode string   `xml:"return_code"`
+	ReturnMsg  string   `xml:"return_msg,omitempty"`
+}
+
+// DecodePaymentNotify 校验签名并解析支付结果通知，缺少必填参数时返回 *validate.FieldError
+func (qq *QQ) DecodePaymentNotify(body []byte) (*PaymentNotifyReq, error) {
+	params, xmlErr := xmlToParams(body)
+	if xmlErr != nil {
+		return nil, xmlErr
+	}
+
+	if signErr := checkSign(params, qq.ApiKey); signErr != nil {
+		return nil, signErr
+	}
+
+	var data PaymentNotifyReq
+	if xmlErr := xml.Unmarshal(body, &data); xmlErr != nil {
+		return nil, xmlErr
+	}
+	if err := data.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// maxNotifyBodySize 支付结果通知请求体的最大长度
+const maxNotifyBodySize = 1 << 20
+
+// PaymentNotifyHandler 支付结果通知处理，签名校验通过后调用 fn，fn 返回 nil 时应答 SUCCESS，否则应答 FAIL 等待QQ钱包重发；
+// 应答的 return_msg 为固定文案，不包含内部错误信息
+func (qq *QQ) PaymentNotifyHandler(fn func(req *PaymentNotifyReq) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, readErr := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxNotifyBodySize))
+		if readErr != nil {
+			writeNotifyRes(w, http.StatusBadRequest, "读取请求失败")
+			return
+		}
+
+		req, decodeErr := qq.DecodePaymentNotify(body)
+		if decodeErr != nil {
+			msg := "参数格式错误"
+			if errors.Is(decodeErr, ErrSignature) {
+				msg = "签名错误"
+			} else if _, ok := decodeErr.(*validate.FieldError); ok {
+				msg = "参数错误"
+			}
+			writeNotifyRes(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		if err := fn(req); err != nil {
+			writeNotifyRes(w, http.StatusInternalServerError, "处理失败")
+			return
+		}
+
+		writeNotifyRes(w, http.StatusOK, "")
+	})
+}
+
+func writeNotifyRes(w http.ResponseWriter, status int, msg string) {
+	res := NotifyRes{ReturnCode: ReturnCodeSuccess}
+	if status != http.StatusOK {
+		res.ReturnCode = ReturnCodeFail
+		res.ReturnMsg = msg
+	}
+
+	data, _ := xml.Marshal(res)
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
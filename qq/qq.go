@@ -1,157 +1,363 @@
 package qq
 
 import (
+	"bytes"
 	"crypto/md5"
+	"crypto/rand"
 	"encoding/hex"
-	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
-	"github.com/MangoMilk/go-kit/net"
-	goKitTime "github.com/MangoMilk/go-kit/time"
+	"github.com/MangoMilk/go-sdk/mchcert"
+	"github.com/MangoMilk/go-sdk/miniprogram"
+	"github.com/MangoMilk/go-sdk/money"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
-	PayB2CApi = "https://api.qpay.qq.com/cgi-bin/epay/qpay_epay_b2c.cgi"
-	PayHBApi  = "https://api.qpay.qq.com/cgi-bin/hongbao/qpay_hb_mch_send.cgi"
+	PayB2CApi     = "https://api.qpay.qq.com/cgi-bin/epay/qpay_epay_b2c.cgi"
+	PayHBApi      = "https://api.qpay.qq.com/cgi-bin/hongbao/qpay_hb_mch_send.cgi"
+	PayHBQueryApi = "https://api.qpay.qq.com/cgi-bin/mch_query/qpay_hb_mch_list_query.cgi"
 )
 
 type QQ struct {
-	AppID string
-	MchID string
-	OPUserID string
-	OPUserPassword string
-	ApiKey string
-	CertFile string
-	KeyFile string
+	AppID          string
+	AppSecret      string
+	MchID          string
+	OPUserID       string
+	OPUserPassword string // 操作员密码的MD5值，不是明文密码
+	ApiKey         string
+	CertFile       string
+	KeyFile        string
+	Cert           *mchcert.Certificate // 商户API证书，设置后优先于 CertFile/KeyFile
+
+	certFiles  mchcert.Files
+	tokenOnce  sync.Once
+	tokenCache *miniprogram.TokenCache
 }
 
 func NewQQ() *QQ {
-	return &QQ{
+	return &QQ{}
+}
+
+// ==================== 签名 ====================
+var ErrSignature = errors.New("check signature fail")
+
+// genSign 参数按 key 升序以 k=v& 拼接（忽略空值和 sign），末尾拼接 &key=apiKey 后取 md5 并转大写
+func genSign(params map[string]string, apiKey string) string {
+	var keys []string
+	for k, v := range params {
+		if v != "" && k != "sign" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
 
+	var signStr strings.Builder
+	for _, k := range keys {
+		signStr.WriteString(k + "=" + params[k] + "&")
 	}
+	signStr.WriteString("key=" + apiKey)
+
+	h := md5.New()
+	h.Write([]byte(signStr.String()))
+
+	return strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
 }
 
-func (qq *QQ)QPayB2C(clientIp string, openId string, apiKey string, tradeNo string, nonceStr string, totalFree int) error {
-	var data = make(map[string]interface{})
-	data["input_charset"] = "UTF-8"
-	data["appid"] = qq.AppID
-	data["openid"] = openId
-	data["mch_id"] = qq.MchID
-	data["nonce_str"] = nonceStr
-	data["out_trade_no"] = tradeNo
-	data["total_fee"] = totalFree
-	data["op_user_id"] = qq.OPUserID
-	data["op_user_passwd"] = qq.OPUserPassword
-	data["spbill_create_ip"] = clientIp
+func checkSign(params map[string]string, apiKey string) error {
+	if params["sign"] == "" || params["sign"] != genSign(params, apiKey) {
+		return ErrSignature
+	}
+
+	return nil
+}
 
-	// 生成签名（sign）和请求参数（xml）
-	var dataKeys []string
-	for k, _ := range data {
-		dataKeys = append(dataKeys, k)
+func genNonceStr() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
 	}
-	sort.Strings(dataKeys)
 
-	// joint data
-	var xmlParams string = "<xml>"
-	var signStr string = ""
-	for _, v := range dataKeys {
-		val := data[v].(string)
+	return hex.EncodeToString(b)
+}
+
+// ==================== 请求/响应 ====================
+const (
+	ReturnCodeSuccess = "SUCCESS"
+	ReturnCodeFail    = "FAIL"
+)
+
+// Error return_code 或 result_code 不为 SUCCESS 时返回
+type Error struct {
+	ReturnCode string
+	ReturnMsg  string
+	ResultCode string
+	ErrCode    string
+	ErrCodeDes string
+}
 
-		xmlParams += "<" + v + ">" + val + "</" + v + ">"
-		signStr += (v + "=" + val + "&")
+func (e *Error) Error() string {
+	if e.ReturnCode != ReturnCodeSuccess {
+		return fmt.Sprintf("qpay return_code=%s return_msg=%s", e.ReturnCode, e.ReturnMsg)
 	}
 
-	h := md5.New()
-	signByte := []byte(strings.Trim(signStr, "&") + "&key=" + apiKey)
-	h.Write(signByte)
-	var sign string = strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
+	return fmt.Sprintf("qpay result_code=%s err_code=%s err_code_des=%s", e.ResultCode, e.ErrCode, e.ErrCodeDes)
+}
 
-	xmlParams += "<sign>" + sign + "</sign>"
-	xmlParams += "</xml>"
-	res,httpErr:=net.HttpPost(PayB2CApi, xmlParams, nil, true,qq.CertFile,qq.KeyFile)
-	if httpErr != nil {
-		return httpErr
+// structToParams 按 xml tag 把请求结构体转换为参数，零值字段不参与请求和签名
+func structToParams(req interface{}) map[string]string {
+	params := make(map[string]string)
+
+	refVal := reflect.Indirect(reflect.ValueOf(req))
+	refType := refVal.Type()
+	for i := 0; i < refVal.NumField(); i++ {
+		key := strings.Split(refType.Field(i).Tag.Get("xml"), ",")[0]
+		switch key {
+		case "", "-", "xml", "sign":
+			continue
+		}
+
+		field := refVal.Field(i)
+		if field.IsZero() {
+			continue
+		}
+		params[key] = fmt.Sprintf("%v", field.Interface())
+	}
+
+	return params
+}
+
+func paramsToXml(params map[string]string) string {
+	var keys []string
+	for k := range params {
+		keys = append(keys, k)
 	}
-	var response = make(map[string]interface{})
+	sort.Strings(keys)
 
-	if err := xml.Unmarshal(res, &response);err != nil {
-		return err
+	var buf bytes.Buffer
+	buf.WriteString("<xml>")
+	for _, k := range keys {
+		buf.WriteString("<" + k + ">")
+		xml.EscapeText(&buf, []byte(params[k]))
+		buf.WriteString("</" + k + ">")
 	}
+	buf.WriteString("</xml>")
 
-	return nil
+	return buf.String()
+}
 
-	//if int(response["retcode"].(float64)) != 0 || response["return_code"].(string) != "SUCCESS" || response["result_code"].(string) != "SUCCESS" {
-	//}
-}
-
-type QPayHBRes struct {
-	RetCode string `retcode`
-	ListID  string `listid`
-}
-
-func (qq *QQ)QPayHB(openId string, iconID string, bannerID string, apiKey string, mchBillno string, sender string, nonceStr string, totalFree int, actName string, wishing string) (*QPayHBRes,error) {
-	var totalFreeStr = strconv.Itoa(totalFree)
-	var data = make(map[string]string)
-	data["charset"] = "1"
-	data["nonce_str"] = nonceStr
-	// mchBillno, _ := strconv.ParseInt(genMchBillNo(Conf.QQ_MCH_ID), 10, 64)
-	data["mch_billno"] = mchBillno //genMchBillNo(mchID)
-	data["mch_id"] = qq.MchID
-	data["mch_name"] = sender //红包发送者名称
-	data["qqappid"] = qq.AppID
-	data["re_openid"] = openId
-	data["total_amount"] = totalFreeStr //发放总金额(单位：分)
-	data["total_num"] = "1"             //红包发送总人数(目前限制为1)
-	data["wishing"] = wishing           //红包祝福语
-	data["act_name"] = actName          //活动名称
-	data["icon_id"] = iconID            //qq coin_id
-	data["banner_id"] = bannerID        //qq banner_id
-	data["min_value"] = "1"             //单个红包的最小金额(单位：分)
-	data["max_value"] = totalFreeStr    //单个红包的最大金额(单位：分)
-
-	// 生成签名（sign）和请求参数（xml）
-	var dataKeys []string
-	for k, _ := range data {
-		dataKeys = append(dataKeys, k)
-	}
-	sort.Strings(dataKeys)
-
-	// joint data
-	var signStr string = ""
-	var val string
-	for _, v := range dataKeys {
-		val = data[v]
-		signStr += (v + "=" + val + "&")
-	}
-	// md5 && upper
-	h := md5.New()
-	signByte := []byte(strings.Trim(signStr, "&") + "&key=" + apiKey)
-	h.Write(signByte)
-	data["sign"] = strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
+// xmlToParams 解析 <xml><k>v</k>...</xml> 形式的报文
+func xmlToParams(data []byte) (map[string]string, error) {
+	params := make(map[string]string)
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	var key string
+	var val strings.Builder
+	for {
+		token, tokenErr := decoder.Token()
+		if tokenErr == io.EOF {
+			break
+		}
+		if tokenErr != nil {
+			return nil, tokenErr
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				key = t.Name.Local
+				val.Reset()
+			}
+		case xml.CharData:
+			if depth == 2 {
+				val.Write(t)
+			}
+		case xml.EndElement:
+			if depth == 2 {
+				params[key] = strings.TrimSpace(val.String())
+			}
+			depth--
+		}
+	}
+
+	return params, nil
+}
+
+// verifyResponse 校验 return_code、签名和 result_code，返回报文参数
+func (qq *QQ) verifyResponse(data []byte) (map[string]string, error) {
+	params, xmlErr := xmlToParams(data)
+	if xmlErr != nil {
+		return nil, xmlErr
+	}
+
+	if params["return_code"] != ReturnCodeSuccess {
+		return nil, &Error{ReturnCode: params["return_code"], ReturnMsg: params["return_msg"]}
+	}
 
+	if signErr := checkSign(params, qq.ApiKey); signErr != nil {
+		return nil, signErr
+	}
+
+	if params["result_code"] != ReturnCodeSuccess {
+		return nil, &Error{
+			ReturnCode: params["return_code"],
+			ReturnMsg:  params["return_msg"],
+			ResultCode: params["result_code"],
+			ErrCode:    params["err_code"],
+			ErrCodeDes: params["err_code_des"],
+		}
+	}
+
+	return params, nil
+}
+
+// decodeResponse 校验报文后将其解码到 res
+func (qq *QQ) decodeResponse(data []byte, res interface{}) (map[string]string, error) {
+	params, verifyErr := qq.verifyResponse(data)
+	if verifyErr != nil {
+		return nil, verifyErr
+	}
+
+	if xmlErr := xml.Unmarshal(data, res); xmlErr != nil {
+		return nil, xmlErr
+	}
+
+	return params, nil
+}
+
+func (qq *QQ) post(api string, req interface{}, isUseClientCert bool, res interface{}) (map[string]string, error) {
+	params := structToParams(req)
+	params["sign"] = genSign(params, qq.ApiKey)
 
-	res,httpErr:=net.HttpPost(PayHBApi, data, nil, true,qq.CertFile,qq.KeyFile)
+	client := httpClient
+	if isUseClientCert {
+		var certErr error
+		if client, certErr = qq.certClient(); certErr != nil {
+			return nil, certErr
+		}
+	}
+
+	data, httpErr := httpPost(client, api, "text/xml", paramsToXml(params))
 	if httpErr != nil {
-		return nil,httpErr
+		return nil, httpErr
 	}
 
-	var response QPayHBRes
+	return qq.decodeResponse(data, res)
+}
+
+// ==================== 商户证书请求 ====================
+var httpClient = &http.Client{Timeout: 30 * time.Second}
 
-	if jsonErr:=json.Unmarshal(res,&response);jsonErr!=nil{
-		return nil,jsonErr
+// certClient 返回携带商户证书的 http.Client，优先使用 QQ.Cert，其次按 CertFile/KeyFile 加载（文件变化时重新加载）
+func (qq *QQ) certClient() (*http.Client, error) {
+	if qq.Cert != nil {
+		return qq.Cert.Client(), nil
+	}
+	if qq.CertFile == "" {
+		return nil, mchcert.ErrNoCertificate
 	}
 
-	//if response.RetCode != "0" {
-	//}
+	c, loadErr := qq.certFiles.Load(qq.CertFile, qq.KeyFile)
+	if loadErr != nil {
+		return nil, loadErr
+	}
 
-	return &response,nil
+	return c.Client(), nil
 }
 
-func (qq *QQ)genMchBillNo(mchId string) string {
-	// Long() mch_id+yyyymmdd+10位一天内不能重复的的数字，如：144124610120161010 1234567890
-	return mchId + time.Now().Format(goKitTime.FormatSeamlessDate) + fmt.Sprintf("%010d", goKitTime.GetCurDayPassMS())
+func httpPost(client *http.Client, api, contentType, body string) ([]byte, error) {
+	resp, httpErr := client.Post(api, contentType, strings.NewReader(body))
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	defer resp.Body.Close()
+
+	res, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		return nil, readErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return res, fmt.Errorf("request fail: http status code is %d", resp.StatusCode)
+	}
+
+	return res, nil
+}
+
+// ==================== 企业付款 ====================
+type QPayB2CReq struct {
+	XMLName        xml.Name  `xml:"xml"`
+	InputCharset   string    `xml:"input_charset"`    // 否，字符编码，默认UTF-8
+	AppID          string    `xml:"appid"`            // 否，商户在QQ开放平台申请的应用ID，不传默认为QQ.AppID
+	OpenID         string    `xml:"openid"`           // 二选一，用户在appid下的唯一标识
+	Uin            string    `xml:"uin"`              // 二选一，收款用户的QQ号码
+	MchID          string    `xml:"mch_id"`           // 是，QQ钱包分配的商户号，不传默认为QQ.MchID
+	NonceStr       string    `xml:"nonce_str"`        // 是，随机字符串，不长于32位，不传自动生成
+	Sign           string    `xml:"sign"`             // 是，签名，自动生成
+	OutTradeNo     string    `xml:"out_trade_no"`     // 是，商户订单号，需保持唯一性
+	FeeType        string    `xml:"fee_type"`         // 否，币种，默认CNY
+	TotalFee       money.Fen `xml:"total_fee"`        // 是，付款金额，单位为分
+	Memo           string    `xml:"memo"`             // 否，付款备注，展示给用户
+	CheckName      string    `xml:"check_name"`       // 否，收款用户姓名校验：FORCE_CHECK 强校验，false 不校验
+	ReUserName     string    `xml:"re_user_name"`     // 否，收款用户真实姓名，check_name为FORCE_CHECK时必填
+	CheckRealName  string    `xml:"check_real_name"`  // 否，是否校验用户已实名：1 校验，0 不校验
+	OpUserID       string    `xml:"op_user_id"`       // 是，操作员帐号，不传默认为QQ.OPUserID
+	OpUserPasswd   string    `xml:"op_user_passwd"`   // 是，操作员密码的MD5值，不传默认为QQ.OPUserPassword（原样使用，不做MD5）
+	SpbillCreateIP string    `xml:"spbill_create_ip"` // 是，调用接口的机器IP
+	NotifyUrl      string    `xml:"notify_url"`       // 否，付款结果通知地址
+}
+
+type QPayB2CRes struct {
+	ReturnCode    string    `xml:"return_code"`
+	ReturnMsg     string    `xml:"return_msg"`
+	RetCode       string    `xml:"retcode"`
+	RetMsg        string    `xml:"retmsg"`
+	AppID         string    `xml:"appid"`
+	MchID         string    `xml:"mch_id"`
+	NonceStr      string    `xml:"nonce_str"`
+	Sign          string    `xml:"sign"`
+	ResultCode    string    `xml:"result_code"`
+	ErrCode       string    `xml:"err_code"`
+	ErrCodeDes    string    `xml:"err_code_des"`
+	OutTradeNo    string    `xml:"out_trade_no"`   // 商户订单号
+	TransactionID string    `xml:"transaction_id"` // QQ钱包企业付款单号
+	TotalFee      money.Fen `xml:"total_fee"`      // 付款金额，单位为分
+}
+
+func (qq *QQ) QPayB2C(req *QPayB2CReq) (*QPayB2CRes, error) {
+	if req.InputCharset == "" {
+		req.InputCharset = "UTF-8"
+	}
+	if req.AppID == "" {
+		req.AppID = qq.AppID
+	}
+	if req.MchID == "" {
+		req.MchID = qq.MchID
+	}
+	if req.OpUserID == "" {
+		req.OpUserID = qq.OPUserID
+	}
+	if req.OpUserPasswd == "" {
+		req.OpUserPasswd = qq.OPUserPassword
+	}
+	if req.NonceStr == "" {
+		req.NonceStr = genNonceStr()
+	}
+
+	var data QPayB2CRes
+	if _, err := qq.post(PayB2CApi, req, true, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
 }
@@ -0,0 +1,241 @@
+package qq
+
+import (
+	"errors"
+	"github.com/MangoMilk/go-sdk/mchcert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	qq *QQ
+
+	apiKey = "8934e7d15453e97507ef794cf7b0519d"
+)
+
+func setup() {
+	qq = NewQQ()
+	qq.AppID = "1104606261"
+	qq.MchID = "1900000109"
+	qq.ApiKey = apiKey
+}
+
+func teardown() {
+
+}
+
+func TestMain(m *testing.M) {
+	setup()
+	m.Run()
+	teardown()
+}
+
+func signedXml(params map[string]string) []byte {
+	params["sign"] = genSign(params, apiKey)
+	return []byte(paramsToXml(params))
+}
+
+func TestGenSign(t *testing.T) {
+	req := QPayB2CReq{
+		InputCharset:   "UTF-8",
+		OpenID:         "o1",
+		MchID:          qq.MchID,
+		NonceStr:       "n1",
+		OutTradeNo:     "t1",
+		TotalFee:       100,
+		SpbillCreateIP: "127.0.0.1",
+	}
+
+	params := structToParams(&req)
+	if params["total_fee"] != "100" {
+		t.Fatalf("total_fee = %q", params["total_fee"])
+	}
+	if _, ok := params["memo"]; ok {
+		t.Fatal("empty field should not be signed")
+	}
+
+	params["sign"] = genSign(params, apiKey)
+	if err := checkSign(params, apiKey); err != nil {
+		t.Fatal(err)
+	}
+
+	params["total_fee"] = "1000"
+	if err := checkSign(params, apiKey); !errors.Is(err, ErrSignature) {
+		t.Fatalf("tampered params should fail, got %v", err)
+	}
+}
+
+func TestDecodeResponse(t *testing.T) {
+	body := signedXml(map[string]string{
+		"return_code":    "SUCCESS",
+		"retcode":        "0",
+		"mch_id":         qq.MchID,
+		"nonce_str":      "n1",
+		"result_code":    "SUCCESS",
+		"out_trade_no":   "t1",
+		"transaction_id": "1000000101201601011234567890",
+		"total_fee":      "100",
+	})
+
+	var res QPayB2CRes
+	if _, err := qq.decodeResponse(body, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.TransactionID != "1000000101201601011234567890" || res.TotalFee != 100 {
+		t.Fatalf("decoded %+v", res)
+	}
+}
+
+func TestDecodeResponseError(t *testing.T) {
+	var res QPayB2CRes
+
+	fail := []byte(`<xml><return_code>FAIL</return_code><return_msg>sign error</return_msg></xml>`)
+	var qErr *Error
+	if _, err := qq.decodeResponse(fail, &res); !errors.As(err, &qErr) || qErr.ReturnMsg != "sign error" {
+		t.Fatalf("want return_code error, got %v", err)
+	}
+
+	bizFail := signedXml(map[string]string{
+		"return_code":  "SUCCESS",
+		"result_code":  "FAIL",
+		"err_code":     "NOTENOUGH",
+		"err_code_des": "余额不足",
+	})
+	if _, err := qq.decodeResponse(bizFail, &res); !errors.As(err, &qErr) || qErr.ErrCode != "NOTENOUGH" {
+		t.Fatalf("want result_code error, got %v", err)
+	}
+
+	forged := []byte(`<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><sign>FORGED</sign></xml>`)
+	if _, err := qq.decodeResponse(forged, &res); !errors.Is(err, ErrSignature) {
+		t.Fatalf("want signature error, got %v", err)
+	}
+}
+
+func TestPaymentNotifyHandler(t *testing.T) {
+	var got *PaymentNotifyReq
+	handler := qq.PaymentNotifyHandler(func(req *PaymentNotifyReq) error {
+		got = req
+		return nil
+	})
+
+	body := signedXml(map[string]string{
+		"appid":          qq.AppID,
+		"mch_id":         qq.MchID,
+		"nonce_str":      "n1",
+		"trade_type":     "MINIAPP",
+		"trade_state":    "SUCCESS",
+		"total_fee":      "1",
+		"transaction_id": "1000000101201601011234567890",
+		"out_trade_no":   "t1",
+		"time_end":       "20210101120000",
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(string(body))))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "SUCCESS") {
+		t.Fatalf("response %d %s", w.Code, w.Body.String())
+	}
+	if got == nil || got.OutTradeNo != "t1" || got.TradeState != TradeStateSuccess || got.TotalFee != 1 {
+		t.Fatalf("notify %+v", got)
+	}
+
+	got = nil
+	forged := strings.Replace(string(body), "<total_fee>1</total_fee>", "<total_fee>100</total_fee>", 1)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(forged)))
+	if w.Code == http.StatusOK || got != nil || !strings.Contains(w.Body.String(), "签名错误") {
+		t.Fatalf("forged notify should be rejected, got %d %s", w.Code, w.Body.String())
+	}
+
+	incomplete := signedXml(map[string]string{
+		"appid":       qq.AppID,
+		"mch_id":      qq.MchID,
+		"nonce_str":   "n2",
+		"trade_type":  "MINIAPP",
+		"trade_state": "SUCCESS",
+		"total_fee":   "1",
+	})
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(string(incomplete))))
+	if w.Code != http.StatusBadRequest || got != nil || !strings.Contains(w.Body.String(), "参数错误") {
+		t.Fatalf("incomplete notify should be rejected, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(strings.Repeat(" ", maxNotifyBodySize+1))))
+	if w.Code != http.StatusBadRequest || got != nil {
+		t.Fatalf("oversized notify should be rejected, got %d %s", w.Code, w.Body.String())
+	}
+
+	failing := qq.PaymentNotifyHandler(func(req *PaymentNotifyReq) error {
+		return errors.New("db: connection refused")
+	})
+	w = httptest.NewRecorder()
+	failing.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(string(body))))
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "connection refused") {
+		t.Fatalf("handler error should not be exposed, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCertClient(t *testing.T) {
+	if _, err := NewQQ().certClient(); err != mchcert.ErrNoCertificate {
+		t.Fatalf("want ErrNoCertificate, got %v", err)
+	}
+
+	cert := &mchcert.Certificate{}
+	q := &QQ{CertFile: "missing.pem", KeyFile: "missing.key", Cert: cert}
+	if client, err := q.certClient(); err != nil || client != cert.Client() {
+		t.Fatalf("Cert should take precedence over CertFile, got %v", err)
+	}
+}
+
+func TestPostMiniCode(t *testing.T) {
+	// 图片内容恰好是合法 json 时也按 Content-Type 识别为图片
+	image := []byte(`{"errcode":0}`)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("access_token") {
+		case "ok":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write(image)
+		case "html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html>bad gateway</html>"))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"errcode":40001,"errmsg":"invalid access_token"}`))
+		}
+	}))
+	defer ts.Close()
+
+	req := &CreateMiniCodeReq{AppID: "1104606261", Path: "pages/index/index"}
+	res, err := postMiniCode(ts.URL+"?access_token=ok", req)
+	if err != nil || string(res.Buffer) != string(image) {
+		t.Fatalf("got %+v %v", res, err)
+	}
+
+	if res, err := postMiniCode(ts.URL+"?access_token=expired", req); err != nil || res.ErrCode != 40001 || res.Buffer != nil {
+		t.Fatalf("got %+v %v", res, err)
+	}
+
+	if _, err := postMiniCode(ts.URL+"?access_token=html", req); err == nil {
+		t.Fatal("unexpected content type should fail")
+	}
+}
+
+func TestParseRefundItems(t *testing.T) {
+	params := map[string]string{
+		"out_refund_no_0": "R1", "refund_id_0": "1", "refund_fee_0": "60", "refund_status_0": "SUCCESS",
+		"out_refund_no_1": "R2", "refund_id_1": "2", "refund_fee_1": "", "refund_status_1": "PROCESSING",
+	}
+
+	refunds, err := parseRefundItems(params, 1)
+	if err != nil || len(refunds) != 1 || refunds[0].RefundFee != 60 || refunds[0].OutRefundNo != "R1" {
+		t.Fatalf("got %+v %v", refunds, err)
+	}
+
+	if _, err := parseRefundItems(params, 2); err == nil || !strings.Contains(err.Error(), "refund_fee_1") {
+		t.Fatalf("want refund_fee_1 error, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+ 参数校验
+
+ 按字段的 validate 标签校验结构体，多个规则以逗号分隔：
+   required  不能为零值（字符串不能为空、数字不能为 0、切片不能为空）
+   max=N     字符串的字符数或切片的长度不超过 N
+   min=N     字符串的字符数或切片的长度不少于 N，零值不校验（是否必填由 required 决定）
+   -         跳过该字段及其嵌套字段，由 Validate 方法按条件使用 Field 校验
+ 嵌套的结构体、结构体指针和结构体切片逐个校验；错误中的字段名取 json 或 xml 标签。
+ 依赖其他字段的条件规则由各请求类型的 Validate 方法自行校验，并返回 *FieldError。
+*/
+
+// FieldError 字段校验失败
+type FieldError struct {
+	Field string // 字段名，嵌套字段以 . 连接，如 product_list[0].sku_name
+	Rule  string // 未通过的规则，如 required、max=10
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("validate: field %s failed on %s", e.Field, e.Rule)
+}
+
+// Struct 按 validate 标签校验结构体或结构体指针，返回第一个未通过的字段
+func Struct(v interface{}) error {
+	return Field("", v)
+}
+
+// Field 按 validate 标签校验嵌套的结构体 v，错误中的字段名以 name 为前缀；
+// 用于在 Validate 方法中按条件校验标记为 "-" 的字段
+func Field(name string, v interface{}) error {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+
+	if name != "" {
+		name += "."
+	}
+
+	return checkStruct(val, name)
+}
+
+func checkStruct(val reflect.Value, prefix string) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag := field.Tag.Get("validate")
+		if tag == "-" {
+			continue
+		}
+
+		name := prefix + fieldName(field)
+		fieldVal := val.Field(i)
+
+		if tag != "" {
+			for _, rule := range strings.Split(tag, ",") {
+				if !checkRule(fieldVal, strings.TrimSpace(rule)) {
+					return &FieldError{Field: name, Rule: rule}
+				}
+			}
+		}
+
+		if err := checkNested(fieldVal, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkNested(val reflect.Value, name string) error {
+	switch val.Kind() {
+	case reflect.Ptr:
+		if !val.IsNil() && val.Elem().Kind() == reflect.Struct {
+			return checkStruct(val.Elem(), name+".")
+		}
+	case reflect.Struct:
+		return checkStruct(val, name+".")
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			if err := checkNested(val.Index(i), fmt.Sprintf("%s[%d]", name, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fieldName 依次取 json、xml 标签中的名称，都没有时使用字段名
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "xml"} {
+		if name := strings.Split(field.Tag.Get(key), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+func checkRule(val reflect.Value, rule string) bool {
+	name, param := rule, ""
+	if i := strings.Index(rule, "="); i >= 0 {
+		name, param = rule[:i], rule[i+1:]
+	}
+
+	switch name {
+	case "required":
+		return !val.IsZero()
+	case "max", "min":
+		limit, parseErr := strconv.Atoi(param)
+		if parseErr != nil {
+			panic("validate: invalid rule " + rule)
+		}
+		n, ok := length(val)
+		if !ok || n == 0 {
+			return true
+		}
+		if name == "max" {
+			return n <= limit
+		}
+		return n >= limit
+	}
+
+	panic("validate: unknown rule " + rule)
+}
+
+// length 字符串按字符计数，切片、数组、map 按元素个数计数
+func length(val reflect.Value) (int, bool) {
+	switch val.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(val.String()), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return val.Len(), true
+	}
+
+	return 0, false
+}
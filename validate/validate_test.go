@@ -0,0 +1,69 @@
+package validate
+
+import (
+	"errors"
+	"testing"
+)
+
+type item struct {
+	Name string `json:"name" validate:"required,max=4"`
+}
+
+type req struct {
+	ID     string  `json:"id" validate:"required"`
+	Count  int64   `xml:"count" validate:"required"`
+	Mark   string  `json:"mark,omitempty" validate:"min=2,max=3"`
+	Items  []item  `json:"items"`
+	Detail *item   `json:"detail"`
+	Tags   []int64 `validate:"max=2"`
+	Extra  item    `json:"extra" validate:"-"`
+	ignore string  `validate:"required"`
+}
+
+func TestStruct(t *testing.T) {
+	valid := func() *req {
+		return &req{ID: "1", Count: 1, Items: []item{{Name: "达达快送"}}}
+	}
+
+	cases := []struct {
+		modify func(r *req)
+		field  string
+		rule   string
+	}{
+		{func(r *req) {}, "", ""},
+		{func(r *req) { r.ID = "" }, "id", "required"},
+		{func(r *req) { r.Count = 0 }, "count", "required"},
+		{func(r *req) { r.Mark = "a" }, "mark", "min=2"},
+		{func(r *req) { r.Mark = "abcd" }, "mark", "max=3"},
+		{func(r *req) { r.Items = append(r.Items, item{}) }, "items[1].name", "required"},
+		{func(r *req) { r.Items[0].Name = "达达快送啊" }, "items[0].name", "max=4"},
+		{func(r *req) { r.Detail = &item{} }, "detail.name", "required"},
+		{func(r *req) { r.Tags = []int64{1, 2, 3} }, "Tags", "max=2"},
+	}
+
+	for _, c := range cases {
+		r := valid()
+		c.modify(r)
+		err := Struct(r)
+		if c.field == "" {
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			continue
+		}
+
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Field != c.field || fieldErr.Rule != c.rule {
+			t.Fatalf("got %v, want %s %s", err, c.field, c.rule)
+		}
+	}
+
+	var fieldErr *FieldError
+	if err := Field("extra", &item{}); !errors.As(err, &fieldErr) || fieldErr.Field != "extra.name" {
+		t.Fatalf("want extra.name error, got %v", err)
+	}
+
+	if err := Struct((*req)(nil)); err != nil {
+		t.Fatalf("nil pointer should pass, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package wechat
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/MangoMilk/go-sdk/miniprogram"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Error 接口返回 errcode 不为 0 时返回
+type Error = miniprogram.Error
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// postJSON 以 json 格式请求接口，返回响应的 Content-Type 和 body
+func postJSON(api string, req interface{}) (string, []byte, error) {
+	reqBody, jsonErr := json.Marshal(req)
+	if jsonErr != nil {
+		return "", nil, jsonErr
+	}
+
+	resp, httpErr := httpClient.Post(api, "application/json", bytes.NewReader(reqBody))
+	if httpErr != nil {
+		return "", nil, httpErr
+	}
+	defer resp.Body.Close()
+
+	body, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		return "", nil, readErr
+	}
+
+	return resp.Header.Get("Content-Type"), body, nil
+}
+
+// decodeJSON errcode 不为 0 时返回 Error，否则将 body 解码到 res
+func decodeJSON(body []byte, res interface{}) error {
+	var result miniprogram.Result
+	if jsonErr := json.Unmarshal(body, &result); jsonErr != nil {
+		return jsonErr
+	}
+	if result.ErrCode != 0 {
+		return &Error{ErrCode: result.ErrCode, ErrMsg: result.ErrMsg}
+	}
+
+	return json.Unmarshal(body, res)
+}
+
+func (wx *Wechat) postJSON(api, accessToken string, req interface{}, res interface{}) error {
+	_, body, httpErr := postJSON(api+"?access_token="+accessToken, req)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return decodeJSON(body, res)
+}
+
+func (wx *Wechat) getJSON(api, accessToken string, query url.Values, res interface{}) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("access_token", accessToken)
+
+	resp, httpErr := httpClient.Get(api + "?" + query.Encode())
+	if httpErr != nil {
+		return httpErr
+	}
+	defer resp.Body.Close()
+
+	body, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		return readErr
+	}
+
+	return decodeJSON(body, res)
+}
+
+// upload 以 multipart/form-data 上传文件
+func (wx *Wechat) upload(api, accessToken string, query url.Values, field, filename string, file io.Reader, res interface{}) error {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, createErr := writer.CreateFormFile(field, filename)
+	if createErr != nil {
+		return createErr
+	}
+	if _, copyErr := io.Copy(part, file); copyErr != nil {
+		return copyErr
+	}
+	if closeErr := writer.Close(); closeErr != nil {
+		return closeErr
+	}
+
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("access_token", accessToken)
+
+	resp, httpErr := httpClient.Post(api+"?"+query.Encode(), writer.FormDataContentType(), &buf)
+	if httpErr != nil {
+		return httpErr
+	}
+	defer resp.Body.Close()
+
+	body, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		return readErr
+	}
+
+	return decodeJSON(body, res)
+}
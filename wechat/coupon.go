@@ -0,0 +1,204 @@
+package wechat
+
+import (
+	"encoding/xml"
+	"github.com/MangoMilk/go-sdk/money"
+)
+
+const (
+	sendCouponUrl       = "https://api.mch.weixin.qq.com/mmpaymkttransfers/send_coupon"
+	queryCouponStockUrl = "https://api.mch.weixin.qq.com/mmpaymkttransfers/query_coupon_stock"
+	queryCouponInfoUrl  = "https://api.mch.weixin.qq.com/mmpaymkttransfers/querycouponsinfo"
+)
+
+// ==================== 发放代金券 ====================
+type SendCouponReq struct {
+	XMLName        xml.Name `xml:"xml"`
+	CouponStockID  string   `xml:"coupon_stock_id"`  // 是，代金券批次id
+	OpenIDCount    int      `xml:"openid_count"`     // 是，openid记录数（目前支持num=1），不传默认为1
+	PartnerTradeNo string   `xml:"partner_trade_no"` // 是，商户此次发放凭据号，商户片内不能重复
+	OpenID         string   `xml:"openid"`           // 是，用户openid
+	AppID          string   `xml:"appid"`            // 是，公众账号ID，固定为Wechat.AppID
+	MchID          string   `xml:"mch_id"`           // 是，微信支付分配的商户号
+	OpUserID       string   `xml:"op_user_id"`       // 否，操作员帐号，默认为商户号
+	DeviceInfo     string   `xml:"device_info"`      // 否，微信支付分配的终端设备号
+	NonceStr       string   `xml:"nonce_str"`        // 是，随机字符串，不传自动生成
+	Sign           string   `xml:"sign"`             // 是，签名，自动生成
+	Version        string   `xml:"version"`          // 否，协议版本，默认1.0
+	Type           string   `xml:"type"`             // 否，协议类型，XML【目前仅支持默认XML】
+}
+
+type sendCouponRes struct {
+	ReturnCode    string `xml:"return_code"`
+	ReturnMsg     string `xml:"return_msg"`
+	ResultCode    string `xml:"result_code"`
+	ErrCode       string `xml:"err_code"`
+	ErrCodeDes    string `xml:"err_code_des"`
+	AppID         string `xml:"appid"`
+	MchID         string `xml:"mch_id"`
+	DeviceInfo    string `xml:"device_info"`
+	NonceStr      string `xml:"nonce_str"`
+	Sign          string `xml:"sign"`
+	CouponStockID string `xml:"coupon_stock_id"` // 代金券批次id
+	RespCount     int    `xml:"resp_count"`      // 返回记录数
+	SuccessCount  int    `xml:"success_count"`   // 成功记录数
+	FailedCount   int    `xml:"failed_count"`    // 失败记录数
+	OpenID        string `xml:"openid"`          // 用户openid
+	RetCode       string `xml:"ret_code"`        // 返回码，SUCCESS/FAILED
+	CouponID      string `xml:"coupon_id"`       // 对一个用户成功发放代金券则返回代金券id，即ret_code为SUCCESS的时候
+	RetMsg        string `xml:"ret_msg"`         // 返回信息，当返回码是FAILED的时候填写
+}
+
+// SendCoupon 向用户发放代金券
+func (wx *Wechat) SendCoupon(req *SendCouponReq, apiKey, certKey, cert string) (*sendCouponRes, error) {
+	req.AppID = wx.AppID
+	if req.OpenIDCount == 0 {
+		req.OpenIDCount = 1
+	}
+	if req.NonceStr == "" {
+		req.NonceStr = genNonceStr()
+	}
+
+	client, certErr := wx.certClient(cert, certKey)
+	if certErr != nil {
+		return nil, certErr
+	}
+
+	var data sendCouponRes
+	if err := wx.postPay(sendCouponUrl, req, &req.Sign, apiKey, client, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// ==================== 查询代金券批次 ====================
+type QueryCouponStockReq struct {
+	XMLName       xml.Name `xml:"xml"`
+	CouponStockID string   `xml:"coupon_stock_id"` // 是，代金券批次id
+	AppID         string   `xml:"appid"`           // 是，公众账号ID，固定为Wechat.AppID
+	MchID         string   `xml:"mch_id"`          // 是，微信支付分配的商户号
+	OpUserID      string   `xml:"op_user_id"`      // 否，操作员帐号，默认为商户号
+	DeviceInfo    string   `xml:"device_info"`     // 否，微信支付分配的终端设备号
+	NonceStr      string   `xml:"nonce_str"`       // 是，随机字符串，不传自动生成
+	Sign          string   `xml:"sign"`            // 是，签名，自动生成
+	Version       string   `xml:"version"`         // 否，协议版本，默认1.0
+	Type          string   `xml:"type"`            // 否，协议类型，XML【目前仅支持默认XML】
+}
+
+// CouponStockStatus 批次状态
+type CouponStockStatus int
+
+const (
+	CouponStockStatusInactive    CouponStockStatus = 1  // 未激活
+	CouponStockStatusAuditing    CouponStockStatus = 2  // 审批中
+	CouponStockStatusActivated   CouponStockStatus = 4  // 已激活
+	CouponStockStatusInvalidated CouponStockStatus = 8  // 已作废
+	CouponStockStatusStopped     CouponStockStatus = 16 // 中止发放
+)
+
+type queryCouponStockRes struct {
+	ReturnCode        string            `xml:"return_code"`
+	ReturnMsg         string            `xml:"return_msg"`
+	ResultCode        string            `xml:"result_code"`
+	ErrCode           string            `xml:"err_code"`
+	ErrCodeDes        string            `xml:"err_code_des"`
+	AppID             string            `xml:"appid"`
+	MchID             string            `xml:"mch_id"`
+	CouponStockID     string            `xml:"coupon_stock_id"`     // 代金券批次ID
+	CouponName        string            `xml:"coupon_name"`         // 代金券名称
+	CouponValue       money.Fen         `xml:"coupon_value"`        // 代金券面值，单位是分
+	CouponMininumn    money.Fen         `xml:"coupon_mininumn"`     // 代金券使用最低限额，单位是分
+	CouponStockStatus CouponStockStatus `xml:"coupon_stock_status"` // 批次状态
+	CouponTotal       int               `xml:"coupon_total"`        // 代金券数量
+	MaxQuota          int               `xml:"max_quota"`           // 代金券每个人最多能领取的数量，如果为0，则表示没有限制
+	IsSendNum         int               `xml:"is_send_num"`         // 代金券已经发送的数量
+	BeginTime         string            `xml:"begin_time"`          // 生效开始时间，格式为时间戳
+	EndTime           string            `xml:"end_time"`            // 生效结束时间，格式为时间戳
+	CreateTime        string            `xml:"create_time"`         // 创建时间，格式为时间戳
+	CouponBudget      money.Fen         `xml:"coupon_budget"`       // 代金券预算额度
+}
+
+// QueryCouponStock 查询代金券批次信息，无需商户证书
+func (wx *Wechat) QueryCouponStock(req *QueryCouponStockReq, apiKey string) (*queryCouponStockRes, error) {
+	req.AppID = wx.AppID
+	if req.NonceStr == "" {
+		req.NonceStr = genNonceStr()
+	}
+
+	var data queryCouponStockRes
+	if err := wx.postPay(queryCouponStockUrl, req, &req.Sign, apiKey, nil, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// ==================== 查询代金券信息 ====================
+type QueryCouponInfoReq struct {
+	XMLName    xml.Name `xml:"xml"`
+	CouponID   string   `xml:"coupon_id"`   // 是，代金券id
+	OpenID     string   `xml:"openid"`      // 是，用户openid
+	AppID      string   `xml:"appid"`       // 是，公众账号ID，固定为Wechat.AppID
+	MchID      string   `xml:"mch_id"`      // 是，微信支付分配的商户号
+	StockID    string   `xml:"stock_id"`    // 是，代金劵对应的批次号
+	OpUserID   string   `xml:"op_user_id"`  // 否，操作员帐号，默认为商户号
+	DeviceInfo string   `xml:"device_info"` // 否，微信支付分配的终端设备号
+	NonceStr   string   `xml:"nonce_str"`   // 是，随机字符串，不传自动生成
+	Sign       string   `xml:"sign"`        // 是，签名，自动生成
+	Version    string   `xml:"version"`     // 否，协议版本，默认1.0
+	Type       string   `xml:"type"`        // 否，协议类型，XML【目前仅支持默认XML】
+}
+
+// CouponState 代金券状态
+type CouponState string
+
+const (
+	CouponStateSendable CouponState = "SENDABLE" // 可用
+	CouponStateUsed     CouponState = "USED"     // 已实扣
+	CouponStateExpired  CouponState = "EXPIRED"  // 已过期
+)
+
+type queryCouponInfoRes struct {
+	ReturnCode        string      `xml:"return_code"`
+	ReturnMsg         string      `xml:"return_msg"`
+	ResultCode        string      `xml:"result_code"`
+	ErrCode           string      `xml:"err_code"`
+	ErrCodeDes        string      `xml:"err_code_des"`
+	AppID             string      `xml:"appid"`
+	MchID             string      `xml:"mch_id"`
+	CouponStockID     string      `xml:"coupon_stock_id"`     // 代金券批次ID
+	CouponID          string      `xml:"coupon_id"`           // 代金券ID
+	CouponValue       money.Fen   `xml:"coupon_value"`        // 代金券面值，单位是分
+	CouponMininum     money.Fen   `xml:"coupon_mininum"`      // 代金券使用最低限额，单位是分
+	CouponName        string      `xml:"coupon_name"`         // 代金券名称
+	CouponState       CouponState `xml:"coupon_state"`        // 代金券状态
+	CouponDesc        string      `xml:"coupon_desc"`         // 代金券描述
+	CouponUseValue    money.Fen   `xml:"coupon_use_value"`    // 代金券实际使用金额
+	CouponRemainValue money.Fen   `xml:"coupon_remain_value"` // 代金券剩余金额
+	BeginTime         string      `xml:"begin_time"`          // 生效开始时间
+	EndTime           string      `xml:"end_time"`            // 生效结束时间
+	SendTime          string      `xml:"send_time"`           // 发放时间
+	UseTime           string      `xml:"use_time"`            // 使用时间
+	TradeNo           string      `xml:"trade_no"`            // 使用单号
+	ConsumerMchID     string      `xml:"consumer_mch_id"`     // 消耗方商户id
+	ConsumerMchName   string      `xml:"consumer_mch_name"`   // 消耗方商户名称
+	ConsumerMchAppID  string      `xml:"consumer_mch_appid"`  // 消耗方商户appid
+	SendSource        string      `xml:"send_source"`         // 发放来源
+	IsPartialUse      string      `xml:"is_partial_use"`      // 该代金券是否允许部分使用标识，1表示支持部分使用
+}
+
+// QueryCouponInfo 查询代金券信息，无需商户证书
+func (wx *Wechat) QueryCouponInfo(req *QueryCouponInfoReq, apiKey string) (*queryCouponInfoRes, error) {
+	req.AppID = wx.AppID
+	if req.NonceStr == "" {
+		req.NonceStr = genNonceStr()
+	}
+
+	var data queryCouponInfoRes
+	if err := wx.postPay(queryCouponInfoUrl, req, &req.Sign, apiKey, nil, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
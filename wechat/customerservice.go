@@ -0,0 +1,117 @@
+package wechat
+
+import (
+	"io"
+	"net/url"
+)
+
+const (
+	customSendUrl   = "https://api.weixin.qq.com/cgi-bin/message/custom/send"
+	customTypingUrl = "https://api.weixin.qq.com/cgi-bin/message/custom/typing"
+	mediaUploadUrl  = "https://api.weixin.qq.com/cgi-bin/media/upload"
+)
+
+// ==================== 客服消息 ====================
+type CustomText struct {
+	Content string `json:"content"` // 文本消息内容
+}
+
+type CustomImage struct {
+	MediaID string `json:"media_id"` // 发送的图片的媒体ID，通过 UploadTempMedia 上传图片文件获得
+}
+
+type CustomLink struct {
+	Title       string `json:"title"`       // 消息标题
+	Description string `json:"description"` // 图文链接消息
+	Url         string `json:"url"`         // 图文链接消息被点击后跳转的链接
+	ThumbUrl    string `json:"thumb_url"`   // 图文链接消息的图片链接，支持 JPG、PNG 格式，较好的效果为大图 640 X 320，小图 80 X 80
+}
+
+type CustomMiniProgramPage struct {
+	Title        string `json:"title"`          // 消息标题
+	PagePath     string `json:"pagepath"`       // 小程序的页面路径，跟app.json对齐，支持参数，比如pages/index/index?foo=bar
+	ThumbMediaID string `json:"thumb_media_id"` // 小程序消息卡片的封面，image 类型的 media_id，通过 UploadTempMedia 上传图片文件获得，建议大小为 520*416
+}
+
+// CustomMessageReq 客服消息，MsgType 与对应的消息内容字段须一致，可使用 NewCustomXxxMessage 构造
+type CustomMessageReq struct {
+	ToUser          string                 `json:"touser"`                    // 是 用户的 OpenID
+	MsgType         MsgType                `json:"msgtype"`                   // 是 消息类型：text、image、link、miniprogrampage
+	Text            *CustomText            `json:"text,omitempty"`            // 否 文本消息，msgtype="text" 时必填
+	Image           *CustomImage           `json:"image,omitempty"`           // 否 图片消息，msgtype="image" 时必填
+	Link            *CustomLink            `json:"link,omitempty"`            // 否 图文链接，msgtype="link" 时必填
+	MiniProgramPage *CustomMiniProgramPage `json:"miniprogrampage,omitempty"` // 否 小程序卡片，msgtype="miniprogrampage" 时必填
+}
+
+func NewCustomTextMessage(toUser, content string) *CustomMessageReq {
+	return &CustomMessageReq{ToUser: toUser, MsgType: MsgTypeText, Text: &CustomText{Content: content}}
+}
+
+func NewCustomImageMessage(toUser, mediaID string) *CustomMessageReq {
+	return &CustomMessageReq{ToUser: toUser, MsgType: MsgTypeImage, Image: &CustomImage{MediaID: mediaID}}
+}
+
+func NewCustomLinkMessage(toUser string, link *CustomLink) *CustomMessageReq {
+	return &CustomMessageReq{ToUser: toUser, MsgType: MsgTypeLink, Link: link}
+}
+
+func NewCustomMiniProgramPageMessage(toUser string, page *CustomMiniProgramPage) *CustomMessageReq {
+	return &CustomMessageReq{ToUser: toUser, MsgType: MsgTypeMiniProgramPage, MiniProgramPage: page}
+}
+
+type customSendRes struct {
+	ErrCode float64 `json:"errcode"`
+	ErrMsg  string  `json:"errmsg"`
+}
+
+// CustomSend 发送客服消息，用户发送消息或进入客服会话后 48 小时内可下发
+func (wx *Wechat) CustomSend(accessToken string, req *CustomMessageReq) (*customSendRes, error) {
+	var data customSendRes
+	if err := wx.postJSON(customSendUrl, accessToken, req, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// TypingCommand 客服输入状态
+type TypingCommand string
+
+const (
+	TypingCommandTyping       TypingCommand = "Typing"       // 对用户下发"正在输入"状态
+	TypingCommandCancelTyping TypingCommand = "CancelTyping" // 取消对用户的"正在输入"状态
+)
+
+type customTypingReq struct {
+	ToUser  string        `json:"touser"`
+	Command TypingCommand `json:"command"`
+}
+
+// CustomTyping 下发客服当前输入状态给用户
+func (wx *Wechat) CustomTyping(accessToken, toUser string, command TypingCommand) (*customSendRes, error) {
+	var data customSendRes
+	if err := wx.postJSON(customTypingUrl, accessToken, customTypingReq{ToUser: toUser, Command: command}, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// ==================== 临时素材 ====================
+type uploadTempMediaRes struct {
+	ErrCode   float64 `json:"errcode"`
+	ErrMsg    string  `json:"errmsg"`
+	Type      string  `json:"type"`       // 文件类型
+	MediaID   string  `json:"media_id"`   // 媒体文件上传后，获取标识，3天内有效
+	CreatedAt int64   `json:"created_at"` // 媒体文件上传时间戳
+}
+
+// UploadTempMedia 上传临时素材，目前小程序仅支持 image 类型，用于发送客服消息
+func (wx *Wechat) UploadTempMedia(accessToken, filename string, file io.Reader) (*uploadTempMediaRes, error) {
+	var data uploadTempMediaRes
+	if err := wx.upload(mediaUploadUrl, accessToken, url.Values{"type": {"image"}}, "media", filename, file, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
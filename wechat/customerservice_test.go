@@ -0,0 +1,47 @@
+package wechat
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCustomMessageJSON(t *testing.T) {
+	b, err := json.Marshal(NewCustomMiniProgramPageMessage("o1", &CustomMiniProgramPage{Title: "t", PagePath: "pages/index/index", ThumbMediaID: "m1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"touser":"o1","msgtype":"miniprogrampage","miniprogrampage":{"title":"t","pagepath":"pages/index/index","thumb_media_id":"m1"}}`
+	if string(b) != want {
+		t.Fatalf("encoded %s", b)
+	}
+}
+
+func TestUploadTempMedia(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("media")
+		if err != nil || r.URL.Query().Get("type") != "image" || r.URL.Query().Get("access_token") != "token" {
+			w.Write([]byte(`{"errcode":40004,"errmsg":"invalid media type"}`))
+			return
+		}
+		content, _ := ioutil.ReadAll(file)
+		if header.Filename != "a.png" || string(content) != "png" {
+			w.Write([]byte(`{"errcode":40005,"errmsg":"invalid file type"}`))
+			return
+		}
+		w.Write([]byte(`{"type":"image","media_id":"m1","created_at":1600000000}`))
+	}))
+	defer ts.Close()
+
+	var res uploadTempMediaRes
+	if err := wx.upload(ts.URL, "token", url.Values{"type": {"image"}}, "media", "a.png", strings.NewReader("png"), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.MediaID != "m1" {
+		t.Fatalf("uploaded %+v", res)
+	}
+}
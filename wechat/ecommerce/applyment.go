@@ -1,9 +1,9 @@
 package ecommerce
 
 import (
-	"encoding/json"
 	"fmt"
-	"github.com/MangoMilk/go-kit/net"
+	"github.com/MangoMilk/go-sdk/money"
+	"github.com/MangoMilk/go-sdk/validate"
 )
 
 // ==================== 进件 ====================
@@ -29,14 +29,14 @@ const (
 )
 
 type ApplyReq struct {
-	OutRequestNo string `json:"out_request_no"`
+	OutRequestNo string `json:"out_request_no" validate:"required,max=124"`
 	/* 必填，业务申请编号，长度 1~124
 	1、服务商自定义的商户唯一编号。
 	2、每个编号对应一个申请单，每个申请单审核通过后会生成一个微信支付商户号。
 	3、若申请单被驳回，可填写相同的“业务申请编号”，即可覆盖修改原申请单信息 。
 	示例值：APPLYMENT_00000000001
 	*/
-	OrganizationType OrganizationType `json:"organization_type"`
+	OrganizationType OrganizationType `json:"organization_type" validate:"required,max=4"`
 	/* 必填，主体类型，长度 1~4
 	非小微的主体类型需与营业执照/登记证书上一致，可参考选择主体指引，枚举值如下。
 	2401：小微商户，指无营业执照的个人商家。
@@ -47,20 +47,20 @@ type ApplyReq struct {
 	1708：其他组织，不属于企业、政府/事业单位的组织机构（如社会团体、民办非企业、基金会），要求机构已办理组织机构代码证。
 	示例值：2401
 	*/
-	BusinessLicenseInfo businessLicenseInfo `json:"business_license_info"`
+	BusinessLicenseInfo businessLicenseInfo `json:"business_license_info" validate:"-"`
 	/* 条件选填，营业执照/登记证书信息
 	1、主体为“小微/个人卖家”时，不填。
 	2、主体为“个体工商户/企业”时，请上传营业执照。
 	3、主体为“党政、机关及事业单位/其他组织”时，请上传登记证书。
 	*/
-	OrganizationCertInfo organizationCertInfo `json:"organization_cert_info"`
+	OrganizationCertInfo organizationCertInfo `json:"organization_cert_info" validate:"-"`
 	/* 条件选填，组织机构代码证信息
 	主体为企业/党政、机关及事业单位/其他组织，且证件号码不是18位时必填。
 	注：
 	若营业执照未三证合一 ，该参数必传;
 	若营业执照三证合一 ，该参数可不传。
 	*/
-	IdDocType IdDocType `json:"id_doc_type"`
+	IdDocType IdDocType `json:"id_doc_type" validate:"max=64"`
 	/* 否，经营者/法人证件类型，长度1~64
 	1、主体为“小微/个人卖家”，可选择：身份证。
 	2、主体为“个体户/企业/党政、机关及事业单位/其他组织”，可选择：以下任一证件类型。
@@ -73,12 +73,12 @@ type ApplyReq struct {
 	IDENTIFICATION_TYPE_TAIWAN：中国台湾居民–来往大陆通行证
 	示例值：IDENTIFICATION_TYPE_MACAO
 	*/
-	IdCardInfo idCardInfo `json:"id_card_info"`
+	IdCardInfo idCardInfo `json:"id_card_info" validate:"-"`
 	/* 条件选填，经营者/法人身份证信息
 	请填写经营者/法人的身份证信息
 	证件类型为“身份证”时填写。
 	*/
-	IdDocInfo idDocInfo `json:"id_doc_info"`
+	IdDocInfo idDocInfo `json:"id_doc_info" validate:"-"`
 	/* 条件选填，经营者/法人其他类型证件信息
 	证件类型为“来往内地通行证、来往大陆通行证、护照”时填写。
 	*/
@@ -91,7 +91,7 @@ type ApplyReq struct {
 	3、当超级管理员类型为负责人时，该字段只能传true，即结算银行账户必填
 	示例值：true
 	*/
-	AccountInfo accountInfo `json:"account_info"`
+	AccountInfo accountInfo `json:"account_info" validate:"-"`
 	/* 条件选填    结算银行账户
 	   若"是否填写结算账户信息"填写为“true”, 则必填，填写为“false”不填 。
 	*/
@@ -101,24 +101,24 @@ type ApplyReq struct {
 	   超级管理员需在开户后进行签约，并可接收日常重要管理信息和进行资金操作，请确定其为商户法定代表人或负责人。
 	*/
 	SalesSceneInfo    salesSceneInfo `json:"sales_scene_info"` // 是  店铺信息 请填写店铺信息
-	MerchantShortname string         `json:"merchant_shortname"`
+	MerchantShortname string         `json:"merchant_shortname" validate:"required"`
 	/* 是   商户简称 [1,64]
 	UTF-8格式，中文占3个字节，即最多21个汉字长度。将在支付完成页向买家展示，需与商家的实际售卖商品相符 。
 	示例值：腾讯
 	*/
-	Qualifications string `json:"qualifications"`
+	Qualifications string `json:"qualifications" validate:"max=1024"`
 	/* 否 特殊资质  [1,1024]
 	1、根据商户经营业务要求提供相关资质，详情查看《行业对应特殊资质》。
 	2、请提供为“申请商家主体”所属的特殊资质，可授权使用总公司/分公司的特殊资 质；
 	3、最多可上传5张照片，请填写通过图片上传接口预先上传图片生成好的MediaID 。
 	示例值：[\"jTpGmxUX3FBWVQ5NJInE4d2I6_H7I4\"]
 	*/
-	BusinessAdditionPics string `json:"business_addition_pics"`
+	BusinessAdditionPics string `json:"business_addition_pics" validate:"max=1024"`
 	/*  否   补充材料  [1,1024]
 	根据实际审核情况，额外要求提供。最多可上传5张照片，请填写通过图片上传接口预先上传图片生成好的MediaID 。
 	示例值：[\"jTpGmg05InE4d2I6_H7I4\"]
 	*/
-	BusinessAdditionDesc string `json:"business_addition_desc"`
+	BusinessAdditionDesc string `json:"business_addition_desc" validate:"max=512"`
 	/* 否   补充说明   [1,256]
 	1、可填写512字以内 。
 	2、若主体为“个人卖家”，该字段必传，则需填写描述“ 该商户已持续从事电子商务经营活动满6个月，且期间经营收入累计超过20万元。”
@@ -126,8 +126,75 @@ type ApplyReq struct {
 	*/
 }
 
+// Validate 校验必填参数和长度，并按主体类型、证件类型、是否填写结算账户校验条件必填的参数
+func (req *ApplyReq) Validate() error {
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	// 商户简称按 UTF-8 字节计算长度，中文占 3 个字节
+	if len(req.MerchantShortname) > 64 {
+		return &validate.FieldError{Field: "merchant_shortname", Rule: "max=64"}
+	}
+
+	personal := req.OrganizationType == OrganizationTypeMicroStore || req.OrganizationType == OrganizationTypePersonSeller
+	organization := req.OrganizationType == OrganizationTypeGovernment || req.OrganizationType == OrganizationTypeOther
+
+	if !personal {
+		if err := validate.Field("business_license_info", &req.BusinessLicenseInfo); err != nil {
+			return err
+		}
+		if organization && req.BusinessLicenseInfo.CompanyAddress == "" {
+			return &validate.FieldError{Field: "business_license_info.company_address", Rule: "required_if=organization_type " + string(req.OrganizationType)}
+		}
+		if organization && req.BusinessLicenseInfo.BusinessTime == "" {
+			return &validate.FieldError{Field: "business_license_info.business_time", Rule: "required_if=organization_type " + string(req.OrganizationType)}
+		}
+		// 未三证合一（证件号码不是 18 位）的企业、党政机关及事业单位、其他组织需填写组织机构代码证
+		if (req.OrganizationType == OrganizationTypeCompany || organization) && len(req.BusinessLicenseInfo.BusinessLicenseNumber) != 18 {
+			if err := validate.Field("organization_cert_info", &req.OrganizationCertInfo); err != nil {
+				return err
+			}
+		}
+		if req.ContactInfo.ContactEmail == "" {
+			return &validate.FieldError{Field: "contact_info.contact_email", Rule: "required_if=organization_type " + string(req.OrganizationType)}
+		}
+	}
+
+	if req.OrganizationType == OrganizationTypePersonSeller && req.BusinessAdditionDesc == "" {
+		return &validate.FieldError{Field: "business_addition_desc", Rule: "required_if=organization_type " + string(req.OrganizationType)}
+	}
+
+	if req.IdDocType == "" || req.IdDocType == IdDocTypeMainlandIDCard {
+		if err := validate.Field("id_card_info", &req.IdCardInfo); err != nil {
+			return err
+		}
+	} else if err := validate.Field("id_doc_info", &req.IdDocInfo); err != nil {
+		return err
+	}
+
+	if req.ContactInfo.ContactType == ContactTypePersonInCharge && !req.NeedAccountInfo {
+		return &validate.FieldError{Field: "need_account_info", Rule: "required_if=contact_info.contact_type " + string(ContactTypePersonInCharge)}
+	}
+	if req.NeedAccountInfo {
+		if err := validate.Field("account_info", &req.AccountInfo); err != nil {
+			return err
+		}
+		// 非直连银行需填写开户银行全称（含支行）或开户银行联行号
+		if req.AccountInfo.AccountBank == AccountBankOther && req.AccountInfo.BankBranchID == "" && req.AccountInfo.BankName == "" {
+			return &validate.FieldError{Field: "account_info.bank_name", Rule: "required_without=account_info.bank_branch_id"}
+		}
+	}
+
+	if req.SalesSceneInfo.StoreUrl == "" && req.SalesSceneInfo.StoreQrCode == "" {
+		return &validate.FieldError{Field: "sales_scene_info.store_url", Rule: "required_without=sales_scene_info.store_qr_code"}
+	}
+
+	return nil
+}
+
 type businessLicenseInfo struct {
-	BusinessLicenseCopy string `json:"business_license_copy"`
+	BusinessLicenseCopy string `json:"business_license_copy" validate:"required,max=256"`
 	/* 必填，证件扫描件，长度 1~256
 		1、主体为“个体工商户/企业”时，请上传营业执照的证件图片。
 	2、主体为“党政、机关及事业单位/其他组织”时，请上传登记证书的证件图片。
@@ -139,7 +206,7 @@ type businessLicenseInfo struct {
 	（4）不接受二次剪裁、翻拍、PS的证件照片。
 	示例值： 47ZC6GC-vnrbEny_Ie_An5-tCpqxucuxi-vByf3Gjm7KE53JXvGy9tqZm2XAUf-4KGprrKhpVBDIUv0OF4wFNIO4kqg05InE4d2I6_H7I4
 	*/
-	BusinessLicenseNumber string `json:"business_license_number"`
+	BusinessLicenseNumber string `json:"business_license_number" validate:"required,min=15,max=18"`
 	/*是，证件注册号，长度 15~ 18
 	1、主体为“个体工商户/企业”时，请填写营业执照上的注册号/统一社会信用代码，须为15位数字或 18位数字|大写字母。
 	2、主体为“党政、机关及事业单位/其他组织”时，请填写登记证书的证书编号。
@@ -149,9 +216,9 @@ type businessLicenseInfo struct {
 	3、个体工商户，若营业执照上商户名称为空或为“无”，请填写"个体户+经营者姓名"，如“个体户张三” 。
 	示例值：腾讯科技有限公司
 	*/
-	LegalPerson    string `json:"legal_person"`    //是，经营者/法定代表人姓名，长度 1~128	请填写证件的经营者/法定代表人姓名。示例值：张三
-	CompanyAddress string `json:"company_address"` //条件选填，注册地址，长度 1~128	主体为“党政、机关及事业单位/其他组织”时必填，请填写登记证书的注册地址。	示例值：深圳南山区科苑路
-	BusinessTime   string `json:"business_time"`
+	LegalPerson    string `json:"legal_person" validate:"required,max=128"` //是，经营者/法定代表人姓名，长度 1~128	请填写证件的经营者/法定代表人姓名。示例值：张三
+	CompanyAddress string `json:"company_address" validate:"max=128"`       //条件选填，注册地址，长度 1~128	主体为“党政、机关及事业单位/其他组织”时必填，请填写登记证书的注册地址。	示例值：深圳南山区科苑路
+	BusinessTime   string `json:"business_time" validate:"max=256"`
 	/* 条件选填，营业期限，长度 1~256
 	1、主体为“党政、机关及事业单位/其他组织”时必填，请填写证件有效期。
 	2、若证件有效期为长期，请填写：长期。
@@ -162,18 +229,18 @@ type businessLicenseInfo struct {
 }
 
 type organizationCertInfo struct {
-	OrganizationCopy string `json:"organization_copy"`
+	OrganizationCopy string `json:"organization_copy" validate:"required,max=256"`
 	/*是，组织机构代码证照片，长度 1~256
 	可上传1张图片，请填写通过图片上传接口预先上传图片生成好的MediaID。
 	示例值：vByf3Gjm7KE53JXv\prrKhpVBDIUv0OF4wFNIO4kqg05InE4d2I6_H7I4
 	*/
-	OrganizationNumber string `json:"organization_number"`
+	OrganizationNumber string `json:"organization_number" validate:"required,max=256"`
 	/* 是，组织机构代码，长度 1~256
 	1、请填写组织机构代码证上的组织机构代码。
 	2、可填写9或10位 数字|字母|连字符。
 	示例值：12345679-A
 	*/
-	OrganizationTime string `json:"organization_time"`
+	OrganizationTime string `json:"organization_time" validate:"required,max=256"`
 	/* 是，组织机构代码有效期限	，1~256
 	1、请填写组织机构代码证的有效期限，注意参照示例中的格式。
 	2、若证件有效期为长期，请填写：长期。
@@ -184,31 +251,31 @@ type organizationCertInfo struct {
 }
 
 type idCardInfo struct {
-	IdCardCopy string `json:"id_card_copy"`
+	IdCardCopy string `json:"id_card_copy" validate:"required,max=256"`
 	/* 身份证人像面照片	[1,256]	是
 	1、请上传经营者/法定代表人的身份证人像面照片。
 	2、可上传1张图片，请填写通过图片上传接口预先上传图片生成好的MediaID。
 	示例值：xpnFuAxhBTEO_PvWkfSCJ3zVIn001D8daLC-ehEuo0BJqRTvDujqhThn4ReFxikqJ5YW6zFQ
 	*/
-	IdCardNational string `json:"id_card_national"`
+	IdCardNational string `json:"id_card_national" validate:"required,max=256"`
 	/* 身份证国徽面照片，[1,256]	是
 	1、请上传经营者/法定代表人的身份证国徽面照片。
 	2、可上传1张图片，请填写通过图片上传接口预先上传图片生成好的MediaID 。
 	示例值：vByf3Gjm7KE53JXvGy9tqZm2XAUf-4KGprrKhpVBDIUv0OF4wFNIO4kqg05InE4d2I6_H7I4
 	*/
-	IdCardName string `json:"id_card_name"`
+	IdCardName string `json:"id_card_name" validate:"required"`
 	/* 身份证姓名，[1,256]	是
 	1、请填写经营者/法定代表人对应身份证的姓名，2~30个中文字符、英文字符、符号。
 	2、该字段需进行加密处理，加密方法详见敏感信息加密说明。(提醒：必须在HTTP头中上送Wechatpay-Serial)
 	示例值：pVd1HJ6v/69bDnuC4EL5Kz4jBHLiCa8MRtelw/wDa4SzfeespQO/0kjiwfqdfg==
 	*/
-	IdCardNumber string `json:"id_card_number"`
+	IdCardNumber string `json:"id_card_number" validate:"required"`
 	/* 身份证号码	[15,18]	是
 	1、请填写经营者/法定代表人对应身份证的号码。
 	2、15位数字或17位数字+1位数字|X ，该字段需进行加密处理，加密方法详见敏感信息加密说明。(提醒：必须在HTTP头中上送Wechatpay-Serial)
 	示例值：zV+BEmytMNQCqQ8juwEc4P4TG5xzchG/5IL9DBd+Z0zZXkw==4
 	*/
-	IdCardValidTime string `json:"id_card_valid_time"`
+	IdCardValidTime string `json:"id_card_valid_time" validate:"required,max=128"`
 	/* 身份证有效期限	，[1,128]	是
 	1、请填写身份证有效期的结束时间，注意参照示例中的格式。
 	2、若证件有效期为长期，请填写：长期。
@@ -218,25 +285,25 @@ type idCardInfo struct {
 }
 
 type idDocInfo struct {
-	IdDocName string `json:"id_doc_name"`
+	IdDocName string `json:"id_doc_name" validate:"required"`
 	/* 证件姓名	[1,128]	是
 	1、请填写经营者/法人姓名。
 	2、该字段需进行加密处理，加密方法详见敏感信息加密说明。(提醒：必须在HTTP头中上送Wechatpay-Serial)
 	示例值：jTpGmxUX3FBWVQ5NJTZvlKX_gdU4LC-ehEuo0BJqRTvDujqhThn4ReFxikqJ5YW6zFQ
 	*/
-	IdDocNumber string `json:"id_doc_number"`
+	IdDocNumber string `json:"id_doc_number" validate:"required"`
 	/* 证件号码	[1,128]	是
 	7~11位 数字|字母|连字符 。
 	该字段需进行加密处理，加密方法详见敏感信息加密说明。(提醒：必须在HTTP头中上送Wechatpay-Serial)
 	示例值：jTpGmxUX3FBWVQ5NJTZvlKX_go0BJqRTvDujqhThn4ReFxikqJ5YW6zFQ
 	*/
-	IdDocCopy string `json:"id_doc_copy"`
+	IdDocCopy string `json:"id_doc_copy" validate:"required,max=256"`
 	/* 证件照片	[1,256]	是
 	1、可上传1张图片，请填写通过图片上传接口预先上传图片生成好的MediaID。
 	2、2M内的彩色图片，格式可为bmp、png、jpeg、jpg或gif 。
 	示例值：xi-vByf3Gjm7KE53JXvGy9tqZm2XAUf-4KGprrKhpVBDIUv0OF4wFNIO4kqg05InE4d2I6_H7I4
 	*/
-	DocPeriodEnd string `json:"doc_period_end"`
+	DocPeriodEnd string `json:"doc_period_end" validate:"required,max=128"`
 	/* 证件结束日期	 [1,128]	是
 	1、请按照示例值填写。
 	2、若证件有效期为长期，请填写：长期。
@@ -276,14 +343,14 @@ const (
 )
 
 type accountInfo struct {
-	BankAccountType BankAccountType `json:"bank_account_type"`
+	BankAccountType BankAccountType `json:"bank_account_type" validate:"required"`
 	/* 账户类型	[1,2]	是
 	1、若主体为企业/党政、机关及事业单位/其他组织，可填写：74-对公账户。
 	2、主体为“小微/个人卖家”，可选择：75-对私账户。
 	3、若主体为个体工商户，可填写：74-对公账户、75-对私账户。
 	示例值：75
 	*/
-	AccountBank AccountBank `json:"account_bank"`
+	AccountBank AccountBank `json:"account_bank" validate:"required"`
 	/* 开户银行	 [1,10]	是
 	详细参见开户银行对照表。
 	注：
@@ -291,34 +358,34 @@ type accountInfo struct {
 	非17家直连银行，该参数请填写为“其他银行”。
 	示例值：工商银行
 	*/
-	AccountName string `json:"account_name"`
+	AccountName string `json:"account_name" validate:"required"`
 	/* 开户名称	[1,128]	是
 	1、选择经营者个人银行卡时，开户名称必须与身份证姓名一致。
 	2、选择对公账户时，开户名称必须与营业执照上的“商户名称”一致。
 	3、该字段需进行加密处理，加密方法详见敏感信息加密说明。(提醒：必须在HTTP头中上送Wechatpay-Serial)
 	示例值：AOZdYGISxo4yw96uY1Pk7Rq79Jtt7+I8juwEc4P4TG5xzchG/5IL9DBd+Z0zZXkw==
 	*/
-	BankAddressCode string `json:"bank_address_code"`
+	BankAddressCode string `json:"bank_address_code" validate:"required,max=12"`
 	/* 开户银行省市编码	[1,12]	是
 	至少精确到市，详细参见省市区编号对照表。
 	注：
 	仅当省市区编号对照表中无对应的省市区编号时，可向上取该银行对应市级编号或省级编号。
 	示例值：110000
 	*/
-	BankBranchID string `json:"bank_branch_id"`
+	BankBranchID string `json:"bank_branch_id" validate:"max=64"`
 	/* 开户银行联行号	 [1,64]	条件选填
 	1、17家直连银行无需填写，如为其他银行，开户银行全称（含支行）和开户银行联行号二选一。
 	2、详细参见开户银行全称（含支行）对照表。
 	示例值：402713354941
 	*/
-	BankName string `json:"bank_name"`
+	BankName string `json:"bank_name" validate:"max=128"`
 	/* 开户银行全称 （含支行）[1,128]	条件选填
 	1、17家直连银行无需填写，如为其他银行，开户银行全称（含支行）和开户银行联行号二选一。
 	2、需填写银行全称，如"深圳农村商业银行XXX支行" 。
 	3、详细参见开户银行全称（含支行）对照表。
 	示例值：施秉县农村信用合作联社城关信用社
 	*/
-	AccountNumber string `json:"account_number"`
+	AccountNumber string `json:"account_number" validate:"required"`
 	/* 银行账号	[1,128]	是
 	1、数字，长度遵循系统支持的对公/对私卡号长度要求表。
 	2、该字段需进行加密处理，加密方法详见敏感信息加密说明。(提醒：必须在HTTP头中上送Wechatpay-Serial)
@@ -334,13 +401,13 @@ const (
 )
 
 type contactInfo struct {
-	ContactType ContactType `json:"contact_type"`
+	ContactType ContactType `json:"contact_type" validate:"required"`
 	/* 超级管理员类型	[1,2]	是
 	1、主体为“小微/个人卖家 ”，可选择：65-经营者/法人。
 	2、主体为“个体工商户/企业/党政、机关及事业单位/其他组织”，可选择：65-经营者/法人、66- 负责人。 （负责人：经商户授权办理微信支付业务的人员，授权范围包括但不限于签约，入驻过程需完成账户验证）。
 	示例值：65
 	*/
-	ContactName string `json:"contact_name"`
+	ContactName string `json:"contact_name" validate:"required"`
 	/* 超级管理员姓名 [1,256]	是
 	1、若管理员类型为“法人”，则该姓名需与法人身份证姓名一致。
 	2、若管理员类型为“负责人”，则可填写实际负责人的姓名。
@@ -348,7 +415,7 @@ type contactInfo struct {
 	（后续该管理员需使用实名微信号完成签约）
 	示例值： pVd1HJ6zyvPedzGaV+X3IdGdbDnuC4Eelw/wDa4SzfeespQO/0kjiwfqdfg==
 	*/
-	ContactIdCardNumber string `json:"contact_id_card_number"`
+	ContactIdCardNumber string `json:"contact_id_card_number" validate:"required"`
 	/* 超级管理员身份证件号码 [1,256]	是
 	1、若管理员类型为法人，则该身份证号码需与法人身份证号码一致。若管理员类型为负责人，则可填写实际负责人的身份证号码。
 	2、可传身份证、来往内地通行证、来往大陆通行证、护照等证件号码。
@@ -356,7 +423,7 @@ type contactInfo struct {
 	4、该字段需进行加密处理，加密方法详见敏感信息加密说明。(提醒：必须在HTTP头中上送Wechatpay-Serial)
 	示例值：pVd1HJ6zmty7/mYNxLMpRSvMRtelw/wDa4SzfeespQO/0kjiwfqdfg==
 	*/
-	MobilePhone string `json:"mobile_phone"`
+	MobilePhone string `json:"mobile_phone" validate:"required"`
 	/* 超级管理员手机	 [1,256]	是
 	1、请填写管理员的手机号，11位数字， 用于接收微信支付的重要管理信息及日常操作验证码 。
 	2、该字段需进行加密处理，加密方法详见敏感信息加密说明。(提醒：必须在HTTP头中上送Wechatpay-Serial)
@@ -373,21 +440,21 @@ type contactInfo struct {
 }
 
 type salesSceneInfo struct {
-	StoreName string `json:"store_name"` //店铺名称	 [1,256]	是	请填写店铺全称。	示例值：爱烧烤
-	StoreUrl  string `json:"store_url"`
+	StoreName string `json:"store_name" validate:"required,max=256"` //店铺名称	 [1,256]	是	请填写店铺全称。	示例值：爱烧烤
+	StoreUrl  string `json:"store_url" validate:"max=1024"`
 	/* 店铺链接	[1,1024]	二选一
 	1、店铺二维码or店铺链接二选一必填。
 	2、请填写店铺主页链接，需符合网站规范。
 	示例值：http://www.qq.com
 	*/
-	StoreQrCode string `json:"store_qr_code"`
+	StoreQrCode string `json:"store_qr_code" validate:"max=256"`
 	/* 店铺二维码[1,256]
 	1、店铺二维码 or 店铺链接二选一必填。
 	2、若为电商小程序，可上传店铺页面的小程序二维码。
 	3、请填写通过图片上传接口预先上传图片生成好的MediaID，仅能上传1张图片 。
 	示例值：jTpGmxUX3FBWVQ5NJTZvlKX_gdU4cRz7z5NxpnFuAxhBTEO1D8daLC-ehEuo0BJqRTvDujqhThn4ReFxikqJ5YW6zFQ
 	*/
-	MiniProgramSubAppID string `json:"mini_program_sub_appid"`
+	MiniProgramSubAppID string `json:"mini_program_sub_appid" validate:"max=256"`
 	/* 小程序AppID [1,256]	否
 	1、商户自定义字段，可填写已认证的小程序AppID，认证主体需与二级商户主体一致；
 	2、完成入驻后， 系统发起二级商户号与该AppID的绑定（即配置为sub_appid，可在发起支付时传入）
@@ -404,19 +471,15 @@ type applyRes struct {
 	*/
 }
 
-func Apply(req *ApplyReq) (*applyRes, error) {
-	api := "https://api.mch.weixin.qq.com/v3/ecommerce/applyments/"
-	res, httpErr := net.HttpPost(api, *req, nil, false, "", "")
-	if httpErr != nil {
-		return nil, httpErr
+func (c *Client) Apply(req *ApplyReq) (*applyRes, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
 	}
 
-	fmt.Println("=================Apply===============Apply")
-	fmt.Println(string(res))
-
+	api := c.BaseUrl + "/v3/ecommerce/applyments/"
 	var data applyRes
-	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
-		//data.Buffer = res
+	if err := c.post(api, req, &data); err != nil {
+		return nil, err
 	}
 
 	return &data, nil
@@ -498,13 +561,13 @@ type accountValidation struct {
 	该字段需进行解密处理，解密方法详见敏感信息加解密说明。
 	示例值：9nZYDEvBT4rDdICA3ZYXshYqeOSslSjSauAE+as7mAEDJly+DgRuVs74msmKUH8pl+3oA==
 	*/
-	PayAmount                int    `json:"pay_amount"`                 // 汇款金额	是	需要汇款的金额(单位：分)。	示例值：124
-	DestinationAccountNumber string `json:"destination_account_number"` // 收款卡号	[1,128]	是	收款账户的卡号。	示例值：7222223333322332
-	DestinationAccountName   string `json:"destination_account_name"`   // 收款户名	[1,128]	是	收款账户名。	示例值：财付通支付科技有限公司
-	DestinationAccountBank   string `json:"destination_account_bank"`   // 开户银行	[1,128]	是	收款账户的开户银行名称。	示例值：招商银行威盛大厦支行
-	City                     string `json:"city"`                       // 省市信息	[1,128]	是	收款账户的省市。	示例值：深圳
-	Remark                   string `json:"remark"`                     // 备注信息	[1,128]	是	商户汇款时，需要填写的备注信息。	示例值：入驻账户验证
-	Deadline                 string `json:"deadline"`                   // 汇款截止时间	[1,20]	是	请在此时间前完成汇款。	示例值：2018-12-10 17:09:01
+	PayAmount                money.Fen `json:"pay_amount"`                 // 汇款金额	是	需要汇款的金额(单位：分)。	示例值：124
+	DestinationAccountNumber string    `json:"destination_account_number"` // 收款卡号	[1,128]	是	收款账户的卡号。	示例值：7222223333322332
+	DestinationAccountName   string    `json:"destination_account_name"`   // 收款户名	[1,128]	是	收款账户名。	示例值：财付通支付科技有限公司
+	DestinationAccountBank   string    `json:"destination_account_bank"`   // 开户银行	[1,128]	是	收款账户的开户银行名称。	示例值：招商银行威盛大厦支行
+	City                     string    `json:"city"`                       // 省市信息	[1,128]	是	收款账户的省市。	示例值：深圳
+	Remark                   string    `json:"remark"`                     // 备注信息	[1,128]	是	商户汇款时，需要填写的备注信息。	示例值：入驻账户验证
+	Deadline                 string    `json:"deadline"`                   // 汇款截止时间	[1,20]	是	请在此时间前完成汇款。	示例值：2018-12-10 17:09:01
 }
 
 type auditDetail struct {
@@ -512,32 +575,22 @@ type auditDetail struct {
 	RejectReason string `json:"reject_reason"` // 	驳回原因	[1,32]	是	提交资料项被驳回原因。示例值：身份证背面识别失败，请上传更清晰的身份证图片
 }
 
-func GetApplyStatusByApplymentID(applymentID uint64) (*getApplyStatusRes, error) {
-
-	api := fmt.Sprintf("https://api.mch.weixin.qq.com/v3/ecommerce/applyments/%v", applymentID)
-	res, httpErr := net.HttpGet(api, nil)
-	if httpErr != nil {
-		return nil, httpErr
-	}
+func (c *Client) GetApplyStatusByApplymentID(applymentID uint64) (*getApplyStatusRes, error) {
 
+	api := c.BaseUrl + fmt.Sprintf("/v3/ecommerce/applyments/%v", applymentID)
 	var data getApplyStatusRes
-	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
-		return nil, jsonErr
+	if err := c.get(api, &data); err != nil {
+		return nil, err
 	}
 
 	return &data, nil
 }
 
-func GetApplyStatusByOutRequestNo(outRequestNo string) (*getApplyStatusRes, error) {
-
-	api := fmt.Sprintf("https://api.mch.weixin.qq.com/v3/ecommerce/applyments/out-request-no/%v", outRequestNo)
-	res, httpErr := net.HttpGet(api, nil)
-	if httpErr != nil {
-		return nil, httpErr
-	}
+func (c *Client) GetApplyStatusByOutRequestNo(outRequestNo string) (*getApplyStatusRes, error) {
+	api := c.BaseUrl + fmt.Sprintf("/v3/ecommerce/applyments/out-request-no/%v", outRequestNo)
 	var data getApplyStatusRes
-	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
-		return nil, jsonErr
+	if err := c.get(api, &data); err != nil {
+		return nil, err
 	}
 
 	return &data, nil
@@ -0,0 +1,83 @@
+package ecommerce
+
+import (
+	"errors"
+	"github.com/MangoMilk/go-sdk/validate"
+	"testing"
+)
+
+func microApplyReq() ApplyReq {
+	return ApplyReq{
+		OutRequestNo:     "APPLYMENT_00000000001",
+		OrganizationType: OrganizationTypeMicroStore,
+		IdCardInfo: idCardInfo{
+			IdCardCopy:      "copy",
+			IdCardNational:  "national",
+			IdCardName:      "name",
+			IdCardNumber:    "number",
+			IdCardValidTime: "2026-06-06",
+		},
+		ContactInfo: contactInfo{
+			ContactType:         ContactTypeLegalPerson,
+			ContactName:         "name",
+			ContactIdCardNumber: "number",
+			MobilePhone:         "phone",
+		},
+		SalesSceneInfo:    salesSceneInfo{StoreName: "爱烧烤", StoreUrl: "http://www.qq.com"},
+		MerchantShortname: "腾讯",
+	}
+}
+
+func TestApplyReqValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(req *ApplyReq)
+		field  string
+	}{
+		{"micro store", func(req *ApplyReq) {}, ""},
+		{"out_request_no", func(req *ApplyReq) { req.OutRequestNo = "" }, "out_request_no"},
+		{"shortname bytes", func(req *ApplyReq) {
+			req.MerchantShortname = "腾讯腾讯腾讯腾讯腾讯腾讯腾讯腾讯腾讯腾讯腾讯腾"
+		}, "merchant_shortname"},
+		{"store", func(req *ApplyReq) { req.SalesSceneInfo.StoreUrl = "" }, "sales_scene_info.store_url"},
+		{"person seller desc", func(req *ApplyReq) { req.OrganizationType = OrganizationTypePersonSeller }, "business_addition_desc"},
+		{"passport", func(req *ApplyReq) { req.IdDocType = IdDocTypeOverseaPassport }, "id_doc_info.id_doc_name"},
+		{"account", func(req *ApplyReq) { req.NeedAccountInfo = true }, "account_info.bank_account_type"},
+		{"company license", func(req *ApplyReq) { req.OrganizationType = OrganizationTypeCompany }, "business_license_info.business_license_copy"},
+		{"company cert", func(req *ApplyReq) {
+			req.OrganizationType = OrganizationTypeCompany
+			req.BusinessLicenseInfo = businessLicenseInfo{BusinessLicenseCopy: "copy", BusinessLicenseNumber: "123456789012345", LegalPerson: "张三"}
+		}, "organization_cert_info.organization_copy"},
+		{"company email", func(req *ApplyReq) {
+			req.OrganizationType = OrganizationTypeCompany
+			req.BusinessLicenseInfo = businessLicenseInfo{BusinessLicenseCopy: "copy", BusinessLicenseNumber: "123456789012345678", LegalPerson: "张三"}
+		}, "contact_info.contact_email"},
+		{"government address", func(req *ApplyReq) {
+			req.OrganizationType = OrganizationTypeGovernment
+			req.BusinessLicenseInfo = businessLicenseInfo{BusinessLicenseCopy: "copy", BusinessLicenseNumber: "123456789012345678", LegalPerson: "张三"}
+		}, "business_license_info.company_address"},
+		{"person in charge", func(req *ApplyReq) {
+			req.OrganizationType = OrganizationTypeIICH
+			req.BusinessLicenseInfo = businessLicenseInfo{BusinessLicenseCopy: "copy", BusinessLicenseNumber: "123456789012345", LegalPerson: "张三"}
+			req.ContactInfo.ContactEmail = "email"
+			req.ContactInfo.ContactType = ContactTypePersonInCharge
+		}, "need_account_info"},
+	}
+
+	for _, c := range cases {
+		req := microApplyReq()
+		c.modify(&req)
+		err := req.Validate()
+		if c.field == "" {
+			if err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			}
+			continue
+		}
+
+		var fieldErr *validate.FieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Field != c.field {
+			t.Fatalf("%s: got %v, want %s", c.name, err, c.field)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package ecommerce
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultBaseUrl 支付接口地址
+const DefaultBaseUrl = "https://api.mch.weixin.qq.com"
+
+var ErrNoCredential = errors.New("wechatpay: no apiv3 credential configured")
+
+// ==================== APIv3 请求签名 ====================
+const AuthorizationSchema = "WECHATPAY2-SHA256-RSA2048"
+
+// Credential 服务商 APIv3 请求签名凭证
+type Credential struct {
+	MchID      string          // 服务商户号
+	SerialNo   string          // 商户API证书序列号
+	PrivateKey *rsa.PrivateKey // 商户API证书私钥
+}
+
+// Client 服务商 APIv3 客户端，每个服务商户号使用各自的客户端
+type Client struct {
+	BaseUrl           string         // 支付接口地址，可替换为本地测试服务（如 wechattest.Server.URL）
+	Credential        *Credential    // 请求签名凭证，未设置时请求返回 ErrNoCredential
+	PlatformPublicKey *rsa.PublicKey // 微信支付平台证书公钥，设置后校验应答的 Wechatpay-Signature，失败时返回 ErrSignature
+	HttpClient        *http.Client   // 默认 30 秒超时
+}
+
+func NewClient(credential *Credential) *Client {
+	return &Client{
+		BaseUrl:    DefaultBaseUrl,
+		Credential: credential,
+		HttpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SignMessage 签名串为 method\nurl\ntimestamp\nnonce\nbody\n，应答和回调为 timestamp\nnonce\nbody\n
+func SignMessage(parts ...string) []byte {
+	var buf bytes.Buffer
+	for _, part := range parts {
+		buf.WriteString(part)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes()
+}
+
+func sign(privateKey *rsa.PrivateKey, message []byte) (string, error) {
+	hashed := sha256.Sum256(message)
+	signature, signErr := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hashed[:])
+	if signErr != nil {
+		return "", signErr
+	}
+
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+func (c *Credential) authorization(method, urlPath string, body []byte) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	nonceStr := hex.EncodeToString(nonce)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	signature, signErr := sign(c.PrivateKey, SignMessage(method, urlPath, timestamp, nonceStr, string(body)))
+	if signErr != nil {
+		return "", signErr
+	}
+
+	return fmt.Sprintf(`%s mchid="%s",nonce_str="%s",signature="%s",timestamp="%s",serial_no="%s"`,
+		AuthorizationSchema, c.MchID, nonceStr, signature, timestamp, c.SerialNo), nil
+}
+
+var ErrSignature = errors.New("wechatpay signature verify fail")
+
+// VerifySignature 使用微信支付平台证书公钥验证应答或回调通知的签名，
+// timestamp、nonce、signature 分别取自 Wechatpay-Timestamp、Wechatpay-Nonce、Wechatpay-Signature 头
+func VerifySignature(publicKey *rsa.PublicKey, timestamp, nonce string, body []byte, signature string) error {
+	sig, decodeErr := base64.StdEncoding.DecodeString(signature)
+	if decodeErr != nil {
+		return ErrSignature
+	}
+
+	hashed := sha256.Sum256(SignMessage(timestamp, nonce, string(body)))
+	if rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hashed[:], sig) != nil {
+		return ErrSignature
+	}
+
+	return nil
+}
+
+// ==================== 请求 ====================
+// Error 接口返回非 2xx 状态码时返回
+type Error struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("wechatpay http status %d code=%s message=%s", e.StatusCode, e.Code, e.Message)
+}
+
+func (c *Client) get(api string, res interface{}) error {
+	return c.request(http.MethodGet, api, nil, res)
+}
+
+func (c *Client) post(api string, req interface{}, res interface{}) error {
+	body, jsonErr := json.Marshal(req)
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	return c.request(http.MethodPost, api, body, res)
+}
+
+func (c *Client) request(method, api string, body []byte, res interface{}) error {
+	if c.Credential == nil {
+		return ErrNoCredential
+	}
+
+	u, urlErr := url.Parse(api)
+	if urlErr != nil {
+		return urlErr
+	}
+	auth, authErr := c.Credential.authorization(method, u.RequestURI(), body)
+	if authErr != nil {
+		return authErr
+	}
+
+	req, reqErr := http.NewRequest(method, api, bytes.NewReader(body))
+	if reqErr != nil {
+		return reqErr
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", auth)
+
+	httpClient := c.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, httpErr := httpClient.Do(req)
+	if httpErr != nil {
+		return httpErr
+	}
+	defer resp.Body.Close()
+
+	data, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		return readErr
+	}
+	if c.PlatformPublicKey != nil {
+		if verifyErr := VerifySignature(c.PlatformPublicKey, resp.Header.Get("Wechatpay-Timestamp"), resp.Header.Get("Wechatpay-Nonce"), data, resp.Header.Get("Wechatpay-Signature")); verifyErr != nil {
+			return verifyErr
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		e := &Error{StatusCode: resp.StatusCode}
+		if jsonErr := json.Unmarshal(data, e); jsonErr != nil {
+			return fmt.Errorf("wechatpay http status %d, decode error body %q: %w", resp.StatusCode, data, jsonErr)
+		}
+		return e
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, res)
+}
+
+// ==================== 回调通知解密 ====================
+const AlgorithmAEADAES256GCM = "AEAD_AES_256_GCM"
+
+// Decrypt 使用 APIv3 密钥解密通知资源数据
+func (r *resource) Decrypt(apiV3Key string) ([]byte, error) {
+	if r.Algorithm != AlgorithmAEADAES256GCM {
+		return nil, fmt.Errorf("unsupported algorithm %s", r.Algorithm)
+	}
+
+	ciphertext, decodeErr := base64.StdEncoding.DecodeString(r.Ciphertext)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	block, aesErr := aes.NewCipher([]byte(apiV3Key))
+	if aesErr != nil {
+		return nil, aesErr
+	}
+	gcm, gcmErr := cipher.NewGCMWithNonceSize(block, len(r.Nonce))
+	if gcmErr != nil {
+		return nil, gcmErr
+	}
+
+	return gcm.Open(nil, []byte(r.Nonce), ciphertext, []byte(r.AssociatedData))
+}
+
+// DecodeTransaction 解密支付成功通知（TRANSACTION.SUCCESS）
+func (n *NotifyReq) DecodeTransaction(apiV3Key string) (*orderDetail, error) {
+	plaintext, decryptErr := n.Resource.Decrypt(apiV3Key)
+	if decryptErr != nil {
+		return nil, decryptErr
+	}
+
+	var data orderDetail
+	if jsonErr := json.Unmarshal(plaintext, &data); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	return &data, nil
+}
+
+// DecodeRefund 解密退款结果通知（REFUND.*）
+func (n *NotifyReq) DecodeRefund(apiV3Key string) (*RefundCiphertext, error) {
+	plaintext, decryptErr := n.Resource.Decrypt(apiV3Key)
+	if decryptErr != nil {
+		return nil, decryptErr
+	}
+
+	var data RefundCiphertext
+	if jsonErr := json.Unmarshal(plaintext, &data); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	return &data, nil
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"github.com/MangoMilk/go-kit/encode"
 	"github.com/MangoMilk/go-kit/encrypt"
-	"github.com/MangoMilk/go-kit/net"
+	"github.com/MangoMilk/go-sdk/money"
 )
 
 // #################### 普通支付 ####################
@@ -67,11 +67,11 @@ type settleInfo struct {
 }
 
 type amount struct {
-	Total    int    `json:"total"`    // 总金额		是	订单总金额，单位为分。示例值：100
-	Currency string `json:"currency"` // 货币类型[1,16]	否	CNY：人民币，境内商户号仅支持人民币。示例值：CNY
+	Total    money.Fen `json:"total"`    // 总金额		是	订单总金额，单位为分。示例值：100
+	Currency string    `json:"currency"` // 货币类型[1,16]	否	CNY：人民币，境内商户号仅支持人民币。示例值：CNY
 	// 支付回调时有下面2个字段
-	PayerTotal    int    `json:"payer_total"`    //用户支付金额		否	用户支付金额，单位为分。示例值：100
-	PayerCurrency string `json:"payer_currency"` //用户支付币种	[1,16]	否	用户支付币种。	示例值：CNY
+	PayerTotal    money.Fen `json:"payer_total"`    //用户支付金额		否	用户支付金额，单位为分。示例值：100
+	PayerCurrency string    `json:"payer_currency"` //用户支付币种	[1,16]	否	用户支付币种。	示例值：CNY
 }
 
 type payer struct {
@@ -134,19 +134,11 @@ type miniProgramPayRes struct {
 	//CodeUrl    string    `json:"code_url"`
 }
 
-func MiniProgramPay(req *MiniProgramPayReq) (*miniProgramPayRes, error) {
-	api := "https://api.mch.weixin.qq.com/v3/pay/partner/transactions/jsapi"
-	res, httpErr := net.HttpPost(api, *req, nil, false, "", "")
-	if httpErr != nil {
-		return nil, httpErr
-	}
-
-	fmt.Println("====================MiniProgramPay")
-	fmt.Println(string(res))
-
+func (c *Client) MiniProgramPay(req *MiniProgramPayReq) (*miniProgramPayRes, error) {
+	api := c.BaseUrl + "/v3/pay/partner/transactions/jsapi"
 	var data miniProgramPayRes
-	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
-		return nil, jsonErr
+	if err := c.post(api, req, &data); err != nil {
+		return nil, err
 	}
 
 	return &data, nil
@@ -338,38 +330,22 @@ type NotifyRes struct {
 }
 
 // ==================== 查询订单(微信支付订单号查询) ====================
-func QueryOrderByTransactionID(spMchID, subMchID, transactionID string) (*orderDetail, error) {
-	api := fmt.Sprintf("https://api.mch.weixin.qq.com/v3/pay/partner/transactions/id/%v?sp_mchid=%v&sub_mchid=%v", transactionID, spMchID, subMchID)
-	res, httpErr := net.HttpGet(api, nil)
-	if httpErr != nil {
-		return nil, httpErr
-	}
-
-	fmt.Println("====================QueryOrderByTransactionID")
-	fmt.Println(string(res))
-
+func (c *Client) QueryOrderByTransactionID(spMchID, subMchID, transactionID string) (*orderDetail, error) {
+	api := c.BaseUrl + fmt.Sprintf("/v3/pay/partner/transactions/id/%v?sp_mchid=%v&sub_mchid=%v", transactionID, spMchID, subMchID)
 	var data orderDetail
-	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
-		return nil, jsonErr
+	if err := c.get(api, &data); err != nil {
+		return nil, err
 	}
 
 	return &data, nil
 }
 
 // ==================== 查询订单(商户订单号查询) ====================
-func QueryOrderByOutTradeNo(spMchID, subMchID, outTradeNo string) (*orderDetail, error) {
-	api := fmt.Sprintf("https://api.mch.weixin.qq.com/v3/pay/partner/transactions/out-trade-no/%v?sp_mchid=%v&sub_mchid=%v", outTradeNo, spMchID, subMchID)
-	res, httpErr := net.HttpGet(api, nil)
-	if httpErr != nil {
-		return nil, httpErr
-	}
-
-	fmt.Println("====================QueryOrderByOutTradeNo")
-	fmt.Println(string(res))
-
+func (c *Client) QueryOrderByOutTradeNo(spMchID, subMchID, outTradeNo string) (*orderDetail, error) {
+	api := c.BaseUrl + fmt.Sprintf("/v3/pay/partner/transactions/out-trade-no/%v?sp_mchid=%v&sub_mchid=%v", outTradeNo, spMchID, subMchID)
 	var data orderDetail
-	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
-		return nil, jsonErr
+	if err := c.get(api, &data); err != nil {
+		return nil, err
 	}
 
 	return &data, nil
@@ -1,9 +1,8 @@
 package ecommerce
 
 import (
-	"encoding/json"
 	"fmt"
-	"github.com/MangoMilk/go-kit/net"
+	"github.com/MangoMilk/go-sdk/money"
 )
 
 // ==================== 分账 ====================
@@ -29,6 +28,9 @@ const (
 	ReceiverTypePersonamSubOpenID = ReceiverType("PERSONAL_SUB_OPENID") //个人sub_openid（由品牌主的APPID转换得到）
 )
 
+// ProfitSharingReceiver 分账接收方，用于在包外构造 ProfitSharingReq.Receivers
+type ProfitSharingReceiver = receiver
+
 type receiver struct {
 	Type ReceiverType `json:"type"`
 	/*分账接收方类型	[1,32]	是	分账接收方类型，枚举值：
@@ -42,9 +44,9 @@ type receiver struct {
 	类型是PERSONAL_OPENID时，是个人openid，openid获取方法
 	示例值：1900000109
 	*/
-	Amount       int    `json:"amount"`      //分账金额	是	分账金额，单位为分，只能为整数，不能超过原订单支付金额及最大分账比例金额。示例值：190
-	Description  string `json:"description"` //分账描述	[1,80]	是	分账的原因描述，分账账单中需要体现。示例值：分给商户1900000109
-	ReceiverName string `json:"receiver_name"`
+	Amount       money.Fen `json:"amount"`      //分账金额	是	分账金额，单位为分，只能为整数，不能超过原订单支付金额及最大分账比例金额。示例值：190
+	Description  string    `json:"description"` //分账描述	[1,80]	是	分账的原因描述，分账账单中需要体现。示例值：分给商户1900000109
+	ReceiverName string    `json:"receiver_name"`
 	/*分账个人姓名	[1, 10240]	条件选填	可选项，在接收方类型为个人的时可选填，若有值，会检查与 receiver_name 是否实名匹配，不匹配会拒绝分账请求
 	1、分账接收方类型是PERSONAL_OPENID时，是个人姓名的密文（选传，传则校验） 此字段的加密方法详见：敏感信息加密说明
 	2、使用微信支付平台证书中的公钥
@@ -93,7 +95,7 @@ const (
 )
 
 type resReceiver struct {
-	Amount      int        `json:"amount"`      //分账金额	是	分账金额，单位为分，只能为整数，不能超过原订单支付金额及最大分账比例金额。示例值：190
+	Amount      money.Fen  `json:"amount"`      //分账金额	是	分账金额，单位为分，只能为整数，不能超过原订单支付金额及最大分账比例金额。示例值：190
 	Description string     `json:"description"` //分账描述	[1,80]	是	分账的原因描述，分账账单中需要体现。示例值：分给商户1900000109
 	FailReason  FailReason `json:"fail_reason"`
 	/*分账失败原因	[1,32]	否	分账失败原因，当分账结果result为RETURNED（已转回分账方）或CLOSED（已关闭）时，返回该字段
@@ -139,20 +141,12 @@ type resReceiver struct {
 	*/
 }
 
-func ProfitSharing(req *ProfitSharingReq) (*profitSharingRes, error) {
-
-	api := "https://api.mch.weixin.qq.com/v3/ecommerce/profitsharing/orders"
-	res, httpErr := net.HttpPost(api, *req, nil, false, "", "")
-	if httpErr != nil {
-		return nil, httpErr
-	}
-
-	fmt.Println("====================ProfitSharing")
-	fmt.Println(string(res))
+func (c *Client) ProfitSharing(req *ProfitSharingReq) (*profitSharingRes, error) {
 
+	api := c.BaseUrl + "/v3/ecommerce/profitsharing/orders"
 	var data profitSharingRes
-	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
-		return nil, jsonErr
+	if err := c.post(api, req, &data); err != nil {
+		return nil, err
 	}
 
 	return &data, nil
@@ -171,23 +165,15 @@ type queryProfitSharingRes struct {
 	示例值：FINISHED
 	*/
 	Receivers         []resReceiver `json:"receivers"`          //分账接收方列表	否	分账接收方列表。当查询分账完结的执行结果时，不返回该字段
-	FinishAmount      int           `json:"finish_amount"`      //分账完结金额		否	分账完结的分账金额，单位为分， 仅当查询分账完结的执行结果时，存在本字段。示例值：100
+	FinishAmount      money.Fen     `json:"finish_amount"`      //分账完结金额		否	分账完结的分账金额，单位为分， 仅当查询分账完结的执行结果时，存在本字段。示例值：100
 	FinishDescription string        `json:"finish_description"` //分账完结描述	[1,80]	否	分账完结的原因描述，仅当查询分账完结的执行结果时，存在本字段。示例值：分账完结
 }
 
-func QueryProfitSharing(subMchID, transactionID, outOrderNo string) (*queryProfitSharingRes, error) {
-	api := fmt.Sprintf("https://api.mch.weixin.qq.com/v3/ecommerce/profitsharing/orders?sub_mchid=%v&transaction_id=%v&out_order_no=%v", subMchID, transactionID, outOrderNo)
-	res, httpErr := net.HttpGet(api, nil)
-	if httpErr != nil {
-		return nil, httpErr
-	}
-
-	fmt.Println("====================QueryProfitSharing")
-	fmt.Println(string(res))
-
+func (c *Client) QueryProfitSharing(subMchID, transactionID, outOrderNo string) (*queryProfitSharingRes, error) {
+	api := c.BaseUrl + fmt.Sprintf("/v3/ecommerce/profitsharing/orders?sub_mchid=%v&transaction_id=%v&out_order_no=%v", subMchID, transactionID, outOrderNo)
 	var data queryProfitSharingRes
-	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
-		return nil, jsonErr
+	if err := c.get(api, &data); err != nil {
+		return nil, err
 	}
 
 	return &data, nil
@@ -195,23 +181,15 @@ func QueryProfitSharing(subMchID, transactionID, outOrderNo string) (*queryProfi
 
 // ==================== 查询订单剩余待分账金额 ====================
 type queryProfitSharingOrderAmountsRes struct {
-	TransactionID string `json:"transaction_id"` //微信订单号	[1,32]	是	微信支付订单号。示例值：4208450740201411110007820472
-	UnSplitAmount int    `json:"unsplit_amount"` //订单剩余待分金额	是	订单剩余待分金额，整数，单位为分。示例值：1000
+	TransactionID string    `json:"transaction_id"` //微信订单号	[1,32]	是	微信支付订单号。示例值：4208450740201411110007820472
+	UnSplitAmount money.Fen `json:"unsplit_amount"` //订单剩余待分金额	是	订单剩余待分金额，整数，单位为分。示例值：1000
 }
 
-func QueryProfitSharingOrderAmounts(transactionID string) (*queryProfitSharingOrderAmountsRes, error) {
-	api := fmt.Sprintf("https://api.mch.weixin.qq.com/v3/ecommerce/profitsharing/orders/%v/amounts", transactionID)
-	res, httpErr := net.HttpGet(api, nil)
-	if httpErr != nil {
-		return nil, httpErr
-	}
-
-	fmt.Println("====================QueryProfitSharingOrderAmounts")
-	fmt.Println(string(res))
-
+func (c *Client) QueryProfitSharingOrderAmounts(transactionID string) (*queryProfitSharingOrderAmountsRes, error) {
+	api := c.BaseUrl + fmt.Sprintf("/v3/ecommerce/profitsharing/orders/%v/amounts", transactionID)
 	var data queryProfitSharingOrderAmountsRes
-	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
-		return nil, jsonErr
+	if err := c.get(api, &data); err != nil {
+		return nil, err
 	}
 
 	return &data, nil
@@ -232,20 +210,12 @@ type finishProfitSharingRes struct {
 	OrderID       string `json:"order_id"`       //微信分账单号	[1,64]	是	微信分账单号，微信系统返回的唯一标识。示例值： 008450740201411110007820472
 }
 
-func FinishProfitSharing(req *FinishProfitSharingReq) (*finishProfitSharingRes, error) {
-
-	api := "https://api.mch.weixin.qq.com/v3/ecommerce/profitsharing/finish-order"
-	res, httpErr := net.HttpPost(api, *req, nil, false, "", "")
-	if httpErr != nil {
-		return nil, httpErr
-	}
-
-	fmt.Println("====================FinishProfitSharing")
-	fmt.Println(string(res))
+func (c *Client) FinishProfitSharing(req *FinishProfitSharingReq) (*finishProfitSharingRes, error) {
 
+	api := c.BaseUrl + "/v3/ecommerce/profitsharing/finish-order"
 	var data finishProfitSharingRes
-	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
-		return nil, jsonErr
+	if err := c.post(api, req, &data); err != nil {
+		return nil, err
 	}
 
 	return &data, nil
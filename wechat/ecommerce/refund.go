@@ -1,9 +1,8 @@
 package ecommerce
 
 import (
-	"encoding/json"
 	"fmt"
-	"github.com/MangoMilk/go-kit/net"
+	"github.com/MangoMilk/go-sdk/money"
 )
 
 // ==================== 退款 ====================
@@ -24,7 +23,7 @@ type RefundReq struct {
 	示例值：1217752501201407033233368018
 	*/
 	TransactionID string `json:"transaction_id"` //微信支付订单号	[1,32]	否	微信支付系统生成的订单号。	示例值：1217752501201407033233368018
-	OutRefundNo   string //商户退款单号	[1,64]	是	body 商户系统内部的退款单号，商户系统内部唯一，只能是数字、大小写字母_-|*@，同一退款单号多次请求只退一笔。示例值：1217752501201407033233368018
+	OutRefundNo   string `json:"out_refund_no"`  //商户退款单号	[1,64]	是	body 商户系统内部的退款单号，商户系统内部唯一，只能是数字、大小写字母_-|*@，同一退款单号多次请求只退一笔。示例值：1217752501201407033233368018
 	Reason        string `json:"reason"`
 	/*退款原因	[1,80]	否	body 若商户传入，会在下发给用户的退款消息中体现退款原因。
 	注意：若订单退款金额≤1元，且属于部分退款，则不会在退款消息中体现退款原因
@@ -53,13 +52,13 @@ type RefundReq struct {
 }
 
 type refundAmount struct {
-	Total          int    `json:"total"`           // 是，原支付交易的订单总金额，币种的最小单位，只能为整数。示例值：888
-	Currency       string `json:"currency"`        // 是，退款币种 符合ISO 4217标准的三位字母代码，目前只支持人民币：CNY。
-	Refund         int    `json:"refund"`          // 是，退款金额，币种的最小单位，只能为整数，不能超过原订单支付金额。示例值：888
-	PayerRefund    int    `json:"payer_refund"`    //用户退款金额	是	退款给用户的金额，不包含所有优惠券金额。示例值：888
-	DiscountRefund int    `json:"discount_refund"` //优惠退款金额		是	优惠券的退款金额，原支付单的优惠按比例退款。	示例值：888
+	Total          money.Fen `json:"total"`           // 是，原支付交易的订单总金额，币种的最小单位，只能为整数。示例值：888
+	Currency       string    `json:"currency"`        // 是，退款币种 符合ISO 4217标准的三位字母代码，目前只支持人民币：CNY。
+	Refund         money.Fen `json:"refund"`          // 是，退款金额，币种的最小单位，只能为整数，不能超过原订单支付金额。示例值：888
+	PayerRefund    money.Fen `json:"payer_refund"`    //用户退款金额	是	退款给用户的金额，不包含所有优惠券金额。示例值：888
+	DiscountRefund money.Fen `json:"discount_refund"` //优惠退款金额		是	优惠券的退款金额，原支付单的优惠按比例退款。	示例值：888
 	// 退款回调时有下面1个字段
-	PayerTotal int `json:"payer_total"` //用户支付金额		否	用户支付金额，单位为分。示例值：100
+	PayerTotal money.Fen `json:"payer_total"` //用户支付金额		否	用户支付金额，单位为分。示例值：100
 }
 
 type refundRes struct {
@@ -90,23 +89,15 @@ type refundPromotionDetail struct {
 	DISCOUNT：免充值型优惠券，商户不需要预先充值营销经费
 	示例值：DISCOUNT
 	*/
-	Amount       int `json:"amount"`        //优惠券面额	是	用户享受优惠的金额（优惠券面额=微信出资金额+商家出资金额+其他出资方金额 ）。示例值：5
-	RefundAmount int `json:"refund_amount"` //优惠退款金额	是	代金券退款金额<=退款金额，退款金额-代金券或立减优惠退款金额为现金，说明详见《代金券或立减优惠》 。示例值：100
+	Amount       money.Fen `json:"amount"`        //优惠券面额	是	用户享受优惠的金额（优惠券面额=微信出资金额+商家出资金额+其他出资方金额 ）。示例值：5
+	RefundAmount money.Fen `json:"refund_amount"` //优惠退款金额	是	代金券退款金额<=退款金额，退款金额-代金券或立减优惠退款金额为现金，说明详见《代金券或立减优惠》 。示例值：100
 }
 
-func Refund(req *RefundReq) (*refundRes, error) {
-	api := "https://api.mch.weixin.qq.com/v3/ecommerce/refunds/apply"
-	res, httpErr := net.HttpPost(api, *req, nil, false, "", "")
-	if httpErr != nil {
-		return nil, httpErr
-	}
-
-	fmt.Println("====================Refund")
-	fmt.Println(string(res))
-
+func (c *Client) Refund(req *RefundReq) (*refundRes, error) {
+	api := c.BaseUrl + "/v3/ecommerce/refunds/apply"
 	var data refundRes
-	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
-		return nil, jsonErr
+	if err := c.post(api, req, &data); err != nil {
+		return nil, err
 	}
 
 	return &data, nil
@@ -198,7 +189,7 @@ type refundDetail struct {
 	特殊规则：最小字符长度为6
 	示例值：1217752501201407033233368018
 	*/
-	channel Channel `json:"channel"`
+	Channel Channel `json:"channel"`
 	/*退款渠道	[1,16]	是	ORIGINAL：原路退款
 	BALANCE：退回到余额
 	OTHER_BALANCE：原账户异常退到其他余额账户
@@ -270,38 +261,22 @@ type refundDetail struct {
 }
 
 // ==================== 查询退款(微信支付退款单号查询) ====================
-func QueryRefundByRefundID(subMchID, refundID string) (*refundDetail, error) {
-	api := fmt.Sprintf("https://api.mch.weixin.qq.com/v3/ecommerce/refunds/id/%v?sub_mchid=%v", refundID, subMchID)
-	res, httpErr := net.HttpGet(api, nil)
-	if httpErr != nil {
-		return nil, httpErr
-	}
-
-	fmt.Println("====================QueryRefundByRefundID")
-	fmt.Println(string(res))
-
+func (c *Client) QueryRefundByRefundID(subMchID, refundID string) (*refundDetail, error) {
+	api := c.BaseUrl + fmt.Sprintf("/v3/ecommerce/refunds/id/%v?sub_mchid=%v", refundID, subMchID)
 	var data refundDetail
-	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
-		return nil, jsonErr
+	if err := c.get(api, &data); err != nil {
+		return nil, err
 	}
 
 	return &data, nil
 }
 
 // ==================== 查询退款(商户退款单号查询) ====================
-func QueryRefundByOutRefundNo(subMchID, outRefundNo string) (*refundDetail, error) {
-	api := fmt.Sprintf("https://api.mch.weixin.qq.com/v3/ecommerce/refunds/out-refund-no/%v?sub_mchid=%v", outRefundNo, subMchID)
-	res, httpErr := net.HttpGet(api, nil)
-	if httpErr != nil {
-		return nil, httpErr
-	}
-
-	fmt.Println("====================QueryRefundByOutRefundNo")
-	fmt.Println(string(res))
-
+func (c *Client) QueryRefundByOutRefundNo(subMchID, outRefundNo string) (*refundDetail, error) {
+	api := c.BaseUrl + fmt.Sprintf("/v3/ecommerce/refunds/out-refund-no/%v?sub_mchid=%v", outRefundNo, subMchID)
 	var data refundDetail
-	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
-		return nil, jsonErr
+	if err := c.get(api, &data); err != nil {
+		return nil, err
 	}
 
 	return &data, nil
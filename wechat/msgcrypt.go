@@ -0,0 +1,110 @@
+package wechat
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrEncodingAESKey = errors.New("invalid encoding aes key")
+	ErrMsgDecrypt     = errors.New("decrypt push message fail")
+	ErrMsgAppID       = errors.New("push message appid mismatch")
+)
+
+// ==================== 消息加解密 ====================
+// MsgCrypt 消息推送安全模式加解密：
+// AESKey = Base64_Decode(EncodingAESKey + "=")，AES-256-CBC，IV 取 AESKey 前 16 字节，PKCS#7 按 32 字节填充，
+// 明文为 random(16B) + msg_len(4B，网络字节序) + msg + appid
+type MsgCrypt struct {
+	token string
+	appID string
+	key   []byte
+}
+
+func NewMsgCrypt(token, encodingAESKey, appID string) (*MsgCrypt, error) {
+	if len(encodingAESKey) != 43 {
+		return nil, ErrEncodingAESKey
+	}
+
+	key, decodeErr := base64.StdEncoding.DecodeString(encodingAESKey + "=")
+	if decodeErr != nil {
+		return nil, ErrEncodingAESKey
+	}
+
+	return &MsgCrypt{token: token, appID: appID, key: key}, nil
+}
+
+// Signature msg_signature = sha1(sort(token, timestamp, nonce, encrypt) 拼接)
+func (c *MsgCrypt) Signature(timestamp, nonce, encrypt string) string {
+	return pushSignature(c.token, timestamp, nonce, encrypt)
+}
+
+// Encrypt 加密消息，返回 base64 编码的密文
+func (c *MsgCrypt) Encrypt(msg []byte) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	msgLen := make([]byte, 4)
+	binary.BigEndian.PutUint32(msgLen, uint32(len(msg)))
+
+	plaintext := bytes.Join([][]byte{random, msgLen, msg, []byte(c.appID)}, nil)
+	padding := 32 - len(plaintext)%32
+	plaintext = append(plaintext, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	block, aesErr := aes.NewCipher(c.key)
+	if aesErr != nil {
+		return "", aesErr
+	}
+
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, c.key[:aes.BlockSize]).CryptBlocks(ciphertext, plaintext)
+
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt 解密 base64 编码的密文，并校验 appid
+func (c *MsgCrypt) Decrypt(encrypted string) ([]byte, error) {
+	ciphertext, decodeErr := base64.StdEncoding.DecodeString(encrypted)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, ErrMsgDecrypt
+	}
+
+	block, aesErr := aes.NewCipher(c.key)
+	if aesErr != nil {
+		return nil, aesErr
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, c.key[:aes.BlockSize]).CryptBlocks(plaintext, ciphertext)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > 32 || padding > len(plaintext) {
+		return nil, ErrMsgDecrypt
+	}
+	plaintext = plaintext[:len(plaintext)-padding]
+	if len(plaintext) < 20 {
+		return nil, ErrMsgDecrypt
+	}
+
+	msgLen := int(binary.BigEndian.Uint32(plaintext[16:20]))
+	if msgLen > len(plaintext)-20 {
+		return nil, ErrMsgDecrypt
+	}
+
+	msg := plaintext[20 : 20+msgLen]
+	if c.appID != "" && string(plaintext[20+msgLen:]) != c.appID {
+		return nil, ErrMsgAppID
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,149 @@
+package wechat
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"github.com/MangoMilk/go-sdk/mchcert"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// ==================== 商户证书请求 ====================
+const (
+	PayReturnCodeSuccess = "SUCCESS"
+	PayReturnCodeFail    = "FAIL"
+)
+
+// PayError 支付接口 return_code 或 result_code 不为 SUCCESS 时返回
+type PayError struct {
+	ReturnCode string
+	ReturnMsg  string
+	ResultCode string
+	ErrCode    string
+	ErrCodeDes string
+}
+
+func (e *PayError) Error() string {
+	if e.ReturnCode != PayReturnCodeSuccess {
+		return fmt.Sprintf("wechat pay return_code=%s return_msg=%s", e.ReturnCode, e.ReturnMsg)
+	}
+
+	return fmt.Sprintf("wechat pay result_code=%s err_code=%s err_code_des=%s", e.ResultCode, e.ErrCode, e.ErrCodeDes)
+}
+
+// payResult 支付接口公共返回字段
+type payResult struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+}
+
+func (r *payResult) err() error {
+	if r.ReturnCode != PayReturnCodeSuccess || r.ResultCode != PayReturnCodeSuccess {
+		return &PayError{
+			ReturnCode: r.ReturnCode,
+			ReturnMsg:  r.ReturnMsg,
+			ResultCode: r.ResultCode,
+			ErrCode:    r.ErrCode,
+			ErrCodeDes: r.ErrCodeDes,
+		}
+	}
+
+	return nil
+}
+
+func genNonceStr() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// certClient 返回携带商户证书的 http.Client：传入了证书文件路径时按路径加载（文件变化时重新加载），否则使用 Wechat.Cert
+func (wx *Wechat) certClient(certFile, keyFile string) (*http.Client, error) {
+	if certFile != "" {
+		c, loadErr := wx.certFiles.Load(certFile, keyFile)
+		if loadErr != nil {
+			return nil, loadErr
+		}
+
+		return c.Client(), nil
+	}
+	if wx.Cert == nil {
+		return nil, mchcert.ErrNoCertificate
+	}
+
+	return wx.Cert.Client(), nil
+}
+
+// postXML 以 xml 格式请求支付接口，client 为 nil 时不使用商户证书
+func postXML(client *http.Client, api string, body []byte) ([]byte, error) {
+	if client == nil {
+		client = httpClient
+	}
+
+	resp, httpErr := client.Post(api, "text/xml", bytes.NewReader(body))
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	defer resp.Body.Close()
+
+	res, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		return nil, readErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return res, fmt.Errorf("request fail: http status code is %d", resp.StatusCode)
+	}
+
+	return res, nil
+}
+
+// postXML 以 xml 格式请求 v2 支付接口，沙箱环境下请求沙箱地址
+func (wx *Wechat) postXML(client *http.Client, api string, body []byte) ([]byte, error) {
+	return postXML(client, wx.mchUrl(api), body)
+}
+
+// postPay 对 req（结构体指针）签名并写入 sign 后请求支付接口，client 为 nil 时不使用商户证书，
+// return_code 或 result_code 不为 SUCCESS 时返回 PayError
+func (wx *Wechat) postPay(api string, req interface{}, sign *string, apiKey string, client *http.Client, res interface{}) error {
+	signStr, signErr := wx.Sign(reflect.ValueOf(req).Elem().Interface(), apiKey)
+	if signErr != nil {
+		return signErr
+	}
+	*sign = signStr
+
+	body, xmlErr := xml.Marshal(req)
+	if xmlErr != nil {
+		return xmlErr
+	}
+
+	data, httpErr := wx.postXML(client, api, body)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return decodePayResult(data, res)
+}
+
+func decodePayResult(data []byte, res interface{}) error {
+	var result payResult
+	if xmlErr := xml.Unmarshal(data, &result); xmlErr != nil {
+		return xmlErr
+	}
+	if err := result.err(); err != nil {
+		return err
+	}
+
+	return xml.Unmarshal(data, res)
+}
@@ -0,0 +1,71 @@
+package wechat
+
+import (
+	"encoding/xml"
+	"errors"
+	"github.com/MangoMilk/go-sdk/mchcert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDecodePayResult(t *testing.T) {
+	body := []byte(`<xml><return_code><![CDATA[SUCCESS]]></return_code><result_code><![CDATA[SUCCESS]]></result_code><mch_billno>10000098201411111234567890</mch_billno><status>RECEIVED</status><hb_type>GROUP</hb_type><total_num>2</total_num><total_amount>300</total_amount><hblist><hbinfo><openid>o1</openid><amount>100</amount><rcv_time>2021-01-01 12:00:00</rcv_time></hbinfo><hbinfo><openid>o2</openid><amount>200</amount><rcv_time>2021-01-01 12:01:00</rcv_time></hbinfo></hblist></xml>`)
+
+	var res getHbInfoRes
+	if err := decodePayResult(body, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != RedPackStatusReceived || len(res.HbList) != 2 || res.HbList[1].Amount != 200 {
+		t.Fatalf("decoded %+v", res)
+	}
+
+	var payErr *PayError
+	fail := []byte(`<xml><return_code>SUCCESS</return_code><result_code>FAIL</result_code><err_code>NOTENOUGH</err_code><err_code_des>帐号余额不足</err_code_des></xml>`)
+	if err := decodePayResult(fail, &res); !errors.As(err, &payErr) || payErr.ErrCode != "NOTENOUGH" {
+		t.Fatalf("want PayError, got %v", err)
+	}
+}
+
+func TestPostPaySign(t *testing.T) {
+	req := TransfersReq{
+		MchAppID:       "wx1",
+		MchID:          "1900000109",
+		NonceStr:       "n1",
+		PartnerTradeNo: "t1",
+		OpenID:         "o1",
+		CheckName:      CheckNameNo,
+		Amount:         100,
+		Desc:           "desc",
+	}
+	sign, signErr := wx.Sign(req, "key")
+	if signErr != nil {
+		t.Fatal(signErr)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got TransfersReq
+		if xmlErr := xml.NewDecoder(r.Body).Decode(&got); xmlErr != nil || got.Sign != sign || got.Amount != 100 {
+			t.Errorf("server got %+v, %v", got, xmlErr)
+		}
+		w.Write([]byte(`<xml><return_code>SUCCESS</return_code><result_code>SUCCESS</result_code><payment_no>p1</payment_no></xml>`))
+	}))
+	defer srv.Close()
+
+	var res transfersRes
+	if err := wx.postPay(srv.URL, &req, &req.Sign, "key", nil, &res); err != nil {
+		t.Fatal(err)
+	}
+	if req.Sign != sign || res.PaymentNo != "p1" {
+		t.Fatalf("sign %s, res %+v", req.Sign, res)
+	}
+}
+
+func TestCertClient(t *testing.T) {
+	if _, err := NewWechat("wx1", "").certClient("", ""); err != mchcert.ErrNoCertificate {
+		t.Fatalf("want ErrNoCertificate, got %v", err)
+	}
+	if _, err := wx.certClient("missing.pem", "missing.key"); err == nil {
+		t.Fatal("missing cert file should fail")
+	}
+}
@@ -0,0 +1,427 @@
+package wechat
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrPushSignature = errors.New("check push signature fail")
+	ErrPushEncrypted = errors.New("encrypted push message but EncodingAESKey not configured")
+)
+
+// ==================== 消息推送 ====================
+// PushDataType 消息推送数据格式
+type PushDataType string
+
+const (
+	PushDataTypeXML  PushDataType = "xml"
+	PushDataTypeJSON PushDataType = "json"
+)
+
+type MsgType string
+
+const (
+	MsgTypeText                    MsgType = "text"
+	MsgTypeImage                   MsgType = "image"
+	MsgTypeVoice                   MsgType = "voice"
+	MsgTypeVideo                   MsgType = "video"
+	MsgTypeShortVideo              MsgType = "shortvideo"
+	MsgTypeLocation                MsgType = "location"
+	MsgTypeLink                    MsgType = "link"
+	MsgTypeMiniProgramPage         MsgType = "miniprogrampage"
+	MsgTypeEvent                   MsgType = "event"
+	MsgTypeTransferCustomerService MsgType = "transfer_customer_service"
+)
+
+const (
+	EventSubscribe            = "subscribe"                  // 关注
+	EventUnsubscribe          = "unsubscribe"                // 取消关注
+	EventScan                 = "SCAN"                       // 已关注用户扫描带参数二维码
+	EventLocation             = "LOCATION"                   // 上报地理位置
+	EventClick                = "CLICK"                      // 点击菜单拉取消息
+	EventView                 = "VIEW"                       // 点击菜单跳转链接
+	EventUserEnterTempSession = "user_enter_tempsession"     // 用户进入小程序客服会话
+	EventSubscribeMsgPopup    = "subscribe_msg_popup_event"  // 用户操作订阅通知弹窗
+	EventSubscribeMsgChange   = "subscribe_msg_change_event" // 用户管理订阅通知
+	EventSubscribeMsgSent     = "subscribe_msg_sent_event"   // 发送订阅通知结果
+)
+
+// SubscribeMsgEvent 订阅通知事件中的单个模板
+type SubscribeMsgEvent struct {
+	TemplateID            string      `xml:"TemplateId" json:"TemplateId"`                       // 模板id
+	SubscribeStatusString string      `xml:"SubscribeStatusString" json:"SubscribeStatusString"` // 用户点击行为，accept 同意，reject 拒绝
+	PopupScene            string      `xml:"PopupScene" json:"PopupScene"`                       // 弹框场景，0 H5，1 图文消息，2 小程序
+	MsgID                 string      `xml:"MsgID" json:"MsgID"`                                 // 消息id（发送结果事件）
+	ErrorCode             json.Number `xml:"ErrorCode" json:"ErrorCode"`                         // 推送结果状态码，0表示成功（发送结果事件）
+	ErrorStatus           string      `xml:"ErrorStatus" json:"ErrorStatus"`                     // 推送结果状态码对应的含义（发送结果事件）
+}
+
+// SubscribeMsgEventList json 格式推送中 List 可能是对象或数组
+type SubscribeMsgEventList []SubscribeMsgEvent
+
+func (l *SubscribeMsgEventList) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var item SubscribeMsgEvent
+		if err := json.Unmarshal(trimmed, &item); err != nil {
+			return err
+		}
+		*l = SubscribeMsgEventList{item}
+		return nil
+	}
+
+	var items []SubscribeMsgEvent
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	*l = items
+
+	return nil
+}
+
+// xml 格式推送中订阅通知事件的 List 位于事件名对应的节点下
+type xmlSubscribeMsgEvents struct {
+	Popup  []SubscribeMsgEvent `xml:"SubscribeMsgPopupEvent>List"`
+	Change []SubscribeMsgEvent `xml:"SubscribeMsgChangeEvent>List"`
+	Sent   []SubscribeMsgEvent `xml:"SubscribeMsgSentEvent>List"`
+}
+
+// Message 推送的普通消息和事件
+type Message struct {
+	XMLName      xml.Name `xml:"xml" json:"-"`
+	ToUserName   string   `xml:"ToUserName" json:"ToUserName"`     // 开发者微信号/小程序原始ID
+	FromUserName string   `xml:"FromUserName" json:"FromUserName"` // 发送者的openid
+	CreateTime   int64    `xml:"CreateTime" json:"CreateTime"`     // 消息创建时间（整型）
+	MsgType      MsgType  `xml:"MsgType" json:"MsgType"`           // 消息类型
+	MsgID        int64    `xml:"MsgId" json:"MsgId"`               // 消息id，64位整型
+
+	// 普通消息
+	Content      string  `xml:"Content" json:"Content"`           // 文本消息内容
+	PicUrl       string  `xml:"PicUrl" json:"PicUrl"`             // 图片链接
+	MediaID      string  `xml:"MediaId" json:"MediaId"`           // 图片/语音/视频消息媒体id
+	Format       string  `xml:"Format" json:"Format"`             // 语音格式，如amr，speex等
+	Recognition  string  `xml:"Recognition" json:"Recognition"`   // 语音识别结果
+	ThumbMediaID string  `xml:"ThumbMediaId" json:"ThumbMediaId"` // 视频消息缩略图的媒体id
+	LocationX    float64 `xml:"Location_X" json:"Location_X"`     // 地理位置纬度
+	LocationY    float64 `xml:"Location_Y" json:"Location_Y"`     // 地理位置经度
+	Scale        int     `xml:"Scale" json:"Scale"`               // 地图缩放大小
+	Label        string  `xml:"Label" json:"Label"`               // 地理位置信息
+	Title        string  `xml:"Title" json:"Title"`               // 链接/小程序卡片标题
+	Description  string  `xml:"Description" json:"Description"`   // 链接描述
+	Url          string  `xml:"Url" json:"Url"`                   // 链接
+
+	// 小程序卡片消息
+	AppID    string `xml:"AppId" json:"AppId"`       // 小程序appid
+	PagePath string `xml:"PagePath" json:"PagePath"` // 小程序页面路径
+	ThumbUrl string `xml:"ThumbUrl" json:"ThumbUrl"` // 封面图片的临时cdn链接
+
+	// 事件
+	Event       string  `xml:"Event" json:"Event"`             // 事件类型
+	EventKey    string  `xml:"EventKey" json:"EventKey"`       // 事件KEY值
+	Ticket      string  `xml:"Ticket" json:"Ticket"`           // 二维码的ticket
+	Latitude    float64 `xml:"Latitude" json:"Latitude"`       // 地理位置纬度
+	Longitude   float64 `xml:"Longitude" json:"Longitude"`     // 地理位置经度
+	Precision   float64 `xml:"Precision" json:"Precision"`     // 地理位置精度
+	SessionFrom string  `xml:"SessionFrom" json:"SessionFrom"` // 进入客服会话时 button 的 session-from 属性
+
+	// 订阅通知事件（subscribe_msg_popup_event、subscribe_msg_change_event、subscribe_msg_sent_event）
+	SubscribeMsgEvents SubscribeMsgEventList `xml:"-" json:"List"`
+
+	DataType PushDataType `xml:"-" json:"-"` // 推送数据格式
+	Raw      []byte       `xml:"-" json:"-"` // 解密后的原始报文，可用于解析未列出的字段
+}
+
+// MediaCheckEvent 将 wxa_media_check 事件解析为检测结果
+func (m *Message) MediaCheckEvent() (*MediaCheckEvent, error) {
+	var event MediaCheckEvent
+	if err := m.decode(&event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func (m *Message) decode(v interface{}) error {
+	if m.DataType == PushDataTypeJSON {
+		return json.Unmarshal(m.Raw, v)
+	}
+
+	return xml.Unmarshal(m.Raw, v)
+}
+
+// ParseMessage 解析明文消息，自动识别 xml 和 json 格式
+func ParseMessage(body []byte) (*Message, error) {
+	msg := Message{DataType: detectDataType(body), Raw: body}
+	if err := msg.decode(&msg); err != nil {
+		return nil, err
+	}
+
+	if msg.DataType == PushDataTypeXML {
+		var events xmlSubscribeMsgEvents
+		if err := xml.Unmarshal(body, &events); err != nil {
+			return nil, err
+		}
+		msg.SubscribeMsgEvents = append(append(append(msg.SubscribeMsgEvents, events.Popup...), events.Change...), events.Sent...)
+	}
+
+	return &msg, nil
+}
+
+func detectDataType(body []byte) PushDataType {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		return PushDataTypeJSON
+	}
+
+	return PushDataTypeXML
+}
+
+// ==================== 被动回复 ====================
+type ReplyMedia struct {
+	MediaID string `xml:"MediaId" json:"MediaId"`
+}
+
+type ReplyVideo struct {
+	MediaID     string `xml:"MediaId" json:"MediaId"`
+	Title       string `xml:"Title,omitempty" json:"Title,omitempty"`
+	Description string `xml:"Description,omitempty" json:"Description,omitempty"`
+}
+
+type ReplyTransInfo struct {
+	KfAccount string `xml:"KfAccount" json:"KfAccount"` // 指定会话接入的客服账号
+}
+
+// Reply 被动回复消息，ToUserName、FromUserName、CreateTime 由 PushServer 填充
+type Reply struct {
+	XMLName      xml.Name        `xml:"xml" json:"-"`
+	ToUserName   string          `xml:"ToUserName" json:"ToUserName"`
+	FromUserName string          `xml:"FromUserName" json:"FromUserName"`
+	CreateTime   int64           `xml:"CreateTime" json:"CreateTime"`
+	MsgType      MsgType         `xml:"MsgType" json:"MsgType"`
+	Content      string          `xml:"Content,omitempty" json:"Content,omitempty"`
+	Image        *ReplyMedia     `xml:"Image,omitempty" json:"Image,omitempty"`
+	Voice        *ReplyMedia     `xml:"Voice,omitempty" json:"Voice,omitempty"`
+	Video        *ReplyVideo     `xml:"Video,omitempty" json:"Video,omitempty"`
+	TransInfo    *ReplyTransInfo `xml:"TransInfo,omitempty" json:"TransInfo,omitempty"`
+}
+
+func NewTextReply(content string) *Reply {
+	return &Reply{MsgType: MsgTypeText, Content: content}
+}
+
+func NewImageReply(mediaID string) *Reply {
+	return &Reply{MsgType: MsgTypeImage, Image: &ReplyMedia{MediaID: mediaID}}
+}
+
+func NewVoiceReply(mediaID string) *Reply {
+	return &Reply{MsgType: MsgTypeVoice, Voice: &ReplyMedia{MediaID: mediaID}}
+}
+
+func NewVideoReply(mediaID, title, description string) *Reply {
+	return &Reply{MsgType: MsgTypeVideo, Video: &ReplyVideo{MediaID: mediaID, Title: title, Description: description}}
+}
+
+// NewTransferCustomerServiceReply 将消息转发到客服，kfAccount 为空时由系统分配
+func NewTransferCustomerServiceReply(kfAccount string) *Reply {
+	reply := &Reply{MsgType: MsgTypeTransferCustomerService}
+	if kfAccount != "" {
+		reply.TransInfo = &ReplyTransInfo{KfAccount: kfAccount}
+	}
+
+	return reply
+}
+
+// 安全模式下的密文报文
+type encryptedMessage struct {
+	XMLName      xml.Name `xml:"xml" json:"-"`
+	ToUserName   string   `xml:"ToUserName,omitempty" json:"ToUserName,omitempty"`
+	Encrypt      string   `xml:"Encrypt" json:"Encrypt"`
+	MsgSignature string   `xml:"MsgSignature,omitempty" json:"MsgSignature,omitempty"`
+	TimeStamp    int64    `xml:"TimeStamp,omitempty" json:"TimeStamp,omitempty"`
+	Nonce        string   `xml:"Nonce,omitempty" json:"Nonce,omitempty"`
+}
+
+// ==================== 消息推送服务 ====================
+// MessageHandler 处理推送的消息，返回 nil 时回复 success；返回 error 时响应 500，微信服务器会重试
+type MessageHandler func(msg *Message) (*Reply, error)
+
+// PushServer 消息推送服务：GET 请求校验服务器地址并返回 echostr；
+// POST 请求支持明文、兼容、安全三种模式及 xml、json 两种数据格式，安全模式下自动加密回复
+type PushServer struct {
+	Token      string
+	Crypt      *MsgCrypt           // 消息加解密，为 nil 时只支持明文模式
+	Handler    MessageHandler      // 消息处理
+	MediaCheck *MediaCheckRegistry // 不为 nil 时 wxa_media_check 事件交由其关联 trace_id
+}
+
+// NewPushServer encodingAESKey 为空时只支持明文模式
+func NewPushServer(token, encodingAESKey, appID string, handler MessageHandler) (*PushServer, error) {
+	server := &PushServer{Token: token, Handler: handler}
+	if encodingAESKey != "" {
+		crypt, err := NewMsgCrypt(token, encodingAESKey, appID)
+		if err != nil {
+			return nil, err
+		}
+		server.Crypt = crypt
+	}
+
+	return server, nil
+}
+
+func (s *PushServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !checkPushSignature(s.Token, query.Get("timestamp"), query.Get("nonce"), query.Get("signature")) {
+		http.Error(w, ErrPushSignature.Error(), http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		w.Write([]byte(query.Get("echostr")))
+		return
+	}
+
+	body, readErr := ioutil.ReadAll(r.Body)
+	if readErr != nil {
+		http.Error(w, readErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	encrypted := query.Get("encrypt_type") == "aes"
+	if encrypted {
+		plaintext, decryptErr := s.decryptBody(body, query.Get("timestamp"), query.Get("nonce"), query.Get("msg_signature"))
+		if decryptErr != nil {
+			status := http.StatusBadRequest
+			if errors.Is(decryptErr, ErrPushSignature) {
+				status = http.StatusForbidden
+			}
+			http.Error(w, decryptErr.Error(), status)
+			return
+		}
+		body = plaintext
+	}
+
+	msg, parseErr := ParseMessage(body)
+	if parseErr != nil {
+		http.Error(w, parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reply, handleErr := s.handle(msg)
+	if handleErr != nil {
+		http.Error(w, handleErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	if reply == nil {
+		w.Write([]byte("success"))
+		return
+	}
+
+	reply.ToUserName = msg.FromUserName
+	reply.FromUserName = msg.ToUserName
+	reply.CreateTime = time.Now().Unix()
+
+	out, encodeErr := encodePush(msg.DataType, reply)
+	if encodeErr == nil && encrypted {
+		out, encodeErr = s.encryptReply(msg.DataType, out, query.Get("nonce"))
+	}
+	if encodeErr != nil {
+		http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if msg.DataType == PushDataTypeJSON {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	}
+	w.Write(out)
+}
+
+func (s *PushServer) handle(msg *Message) (*Reply, error) {
+	if s.MediaCheck != nil && msg.Event == EventMediaCheck {
+		event, err := msg.MediaCheckEvent()
+		if err != nil {
+			return nil, err
+		}
+		s.MediaCheck.Resolve(event)
+	}
+
+	if s.Handler == nil {
+		return nil, nil
+	}
+
+	return s.Handler(msg)
+}
+
+// decryptBody 校验 msg_signature 并解密安全模式/兼容模式下的 Encrypt 字段
+func (s *PushServer) decryptBody(body []byte, timestamp, nonce, msgSignature string) ([]byte, error) {
+	if s.Crypt == nil {
+		return nil, ErrPushEncrypted
+	}
+
+	var envelope encryptedMessage
+	if err := decodePush(detectDataType(body), body, &envelope); err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(s.Crypt.Signature(timestamp, nonce, envelope.Encrypt)), []byte(msgSignature)) != 1 {
+		return nil, ErrPushSignature
+	}
+
+	return s.Crypt.Decrypt(envelope.Encrypt)
+}
+
+func (s *PushServer) encryptReply(dataType PushDataType, reply []byte, nonce string) ([]byte, error) {
+	encrypt, err := s.Crypt.Encrypt(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := time.Now().Unix()
+	envelope := encryptedMessage{
+		Encrypt:      encrypt,
+		MsgSignature: s.Crypt.Signature(strconv.FormatInt(timestamp, 10), nonce, encrypt),
+		TimeStamp:    timestamp,
+		Nonce:        nonce,
+	}
+
+	return encodePush(dataType, envelope)
+}
+
+func decodePush(dataType PushDataType, body []byte, v interface{}) error {
+	if dataType == PushDataTypeJSON {
+		return json.Unmarshal(body, v)
+	}
+
+	return xml.Unmarshal(body, v)
+}
+
+func encodePush(dataType PushDataType, v interface{}) ([]byte, error) {
+	if dataType == PushDataTypeJSON {
+		return json.Marshal(v)
+	}
+
+	return xml.Marshal(v)
+}
+
+// checkPushSignature signature = sha1(sort(token, timestamp, nonce) 拼接)
+func checkPushSignature(token, timestamp, nonce, signature string) bool {
+	return subtle.ConstantTimeCompare([]byte(pushSignature(token, timestamp, nonce)), []byte(signature)) == 1
+}
+
+func pushSignature(params ...string) string {
+	sort.Strings(params)
+	sum := sha1.Sum([]byte(strings.Join(params, "")))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,141 @@
+package wechat
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	pushToken          = "push-token"
+	pushEncodingAESKey = "abcdefghijklmnopqrstuvwxyz0123456789ABCDEFG"
+	pushAppID          = "wx5823bf96d3bd56c7"
+)
+
+func pushQuery() url.Values {
+	query := url.Values{"timestamp": {"1600000000"}, "nonce": {"n1"}}
+	query.Set("signature", pushSignature(pushToken, "1600000000", "n1"))
+	return query
+}
+
+func newTestPushServer(t *testing.T, handler MessageHandler) *PushServer {
+	server, err := NewPushServer(pushToken, pushEncodingAESKey, pushAppID, handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func TestPushServerEcho(t *testing.T) {
+	server := newTestPushServer(t, nil)
+
+	query := pushQuery()
+	query.Set("echostr", "hello")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/push?"+query.Encode(), nil))
+	if w.Code != http.StatusOK || w.Body.String() != "hello" {
+		t.Fatalf("response %d %s", w.Code, w.Body.String())
+	}
+
+	query.Set("signature", "forged")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/push?"+query.Encode(), nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("forged signature should be rejected, got %d", w.Code)
+	}
+}
+
+func TestPushServerPlaintext(t *testing.T) {
+	server := newTestPushServer(t, func(msg *Message) (*Reply, error) {
+		if msg.MsgType != MsgTypeText || msg.MsgID != 1234567890123456 {
+			return nil, fmt.Errorf("message %+v", msg)
+		}
+		return NewTextReply("re: " + msg.Content), nil
+	})
+
+	body := `<xml><ToUserName><![CDATA[gh_1]]></ToUserName><FromUserName><![CDATA[o1]]></FromUserName><CreateTime>1600000000</CreateTime><MsgType><![CDATA[text]]></MsgType><Content><![CDATA[hi]]></Content><MsgId>1234567890123456</MsgId></xml>`
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/push?"+pushQuery().Encode(), strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("response %d %s", w.Code, w.Body.String())
+	}
+
+	var reply Reply
+	if err := xml.Unmarshal(w.Body.Bytes(), &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.ToUserName != "o1" || reply.FromUserName != "gh_1" || reply.Content != "re: hi" {
+		t.Fatalf("reply %+v", reply)
+	}
+}
+
+func TestPushServerSafeModeJSON(t *testing.T) {
+	var got *Message
+	server := newTestPushServer(t, func(msg *Message) (*Reply, error) {
+		got = msg
+		return NewTransferCustomerServiceReply(""), nil
+	})
+
+	plaintext := `{"ToUserName":"gh_1","FromUserName":"o1","CreateTime":1600000000,"MsgType":"event","Event":"subscribe_msg_popup_event","List":{"TemplateId":"tpl1","SubscribeStatusString":"accept","PopupScene":"2"}}`
+	encrypt, err := server.Crypt.Encrypt([]byte(plaintext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(encryptedMessage{ToUserName: "gh_1", Encrypt: encrypt})
+
+	query := pushQuery()
+	query.Set("encrypt_type", "aes")
+	query.Set("msg_signature", server.Crypt.Signature("1600000000", "n1", encrypt))
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/push?"+query.Encode(), strings.NewReader(string(body))))
+	if w.Code != http.StatusOK {
+		t.Fatalf("response %d %s", w.Code, w.Body.String())
+	}
+	if got == nil || got.Event != EventSubscribeMsgPopup || len(got.SubscribeMsgEvents) != 1 || got.SubscribeMsgEvents[0].TemplateID != "tpl1" {
+		t.Fatalf("message %+v", got)
+	}
+
+	var envelope encryptedMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if envelope.MsgSignature != server.Crypt.Signature(strconv.FormatInt(envelope.TimeStamp, 10), envelope.Nonce, envelope.Encrypt) {
+		t.Fatal("reply signature mismatch")
+	}
+	replyBody, err := server.Crypt.Decrypt(envelope.Encrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reply Reply
+	if err := json.Unmarshal(replyBody, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.MsgType != MsgTypeTransferCustomerService || reply.ToUserName != "o1" {
+		t.Fatalf("reply %+v", reply)
+	}
+
+	query.Set("msg_signature", "forged")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/push?"+query.Encode(), strings.NewReader(string(body))))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("forged msg_signature should be rejected, got %d", w.Code)
+	}
+}
+
+func TestParseMessageSubscribeEvents(t *testing.T) {
+	body := `<xml><ToUserName>gh_1</ToUserName><FromUserName>o1</FromUserName><CreateTime>1600000000</CreateTime><MsgType>event</MsgType><Event>subscribe_msg_sent_event</Event><SubscribeMsgSentEvent><List><TemplateId>tpl1</TemplateId><MsgID>1700827132819554304</MsgID><ErrorCode>0</ErrorCode><ErrorStatus>success</ErrorStatus></List><List><TemplateId>tpl2</TemplateId><MsgID>1700827132819554305</MsgID><ErrorCode>20001</ErrorCode><ErrorStatus>fail</ErrorStatus></List></SubscribeMsgSentEvent></xml>`
+	msg, err := ParseMessage([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.DataType != PushDataTypeXML || len(msg.SubscribeMsgEvents) != 2 || msg.SubscribeMsgEvents[1].ErrorCode != "20001" {
+		t.Fatalf("message %+v", msg)
+	}
+}
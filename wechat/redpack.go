@@ -0,0 +1,205 @@
+package wechat
+
+import (
+	"encoding/xml"
+	"github.com/MangoMilk/go-sdk/money"
+)
+
+const (
+	sendRedPackUrl      = "https://api.mch.weixin.qq.com/mmpaymkttransfers/sendredpack"
+	sendGroupRedPackUrl = "https://api.mch.weixin.qq.com/mmpaymkttransfers/sendgroupredpack"
+	getHbInfoUrl        = "https://api.mch.weixin.qq.com/mmpaymkttransfers/gethbinfo"
+)
+
+// ==================== 现金红包 ====================
+// RedPackScene 发放红包使用场景，红包金额大于200或者小于1元时必传
+type RedPackScene string
+
+const (
+	RedPackSceneProduct1 RedPackScene = "PRODUCT_1" // 商品促销
+	RedPackSceneProduct2 RedPackScene = "PRODUCT_2" // 抽奖
+	RedPackSceneProduct3 RedPackScene = "PRODUCT_3" // 虚拟物品兑奖
+	RedPackSceneProduct4 RedPackScene = "PRODUCT_4" // 企业内部福利
+	RedPackSceneProduct5 RedPackScene = "PRODUCT_5" // 渠道分润
+	RedPackSceneProduct6 RedPackScene = "PRODUCT_6" // 保险回馈
+	RedPackSceneProduct7 RedPackScene = "PRODUCT_7" // 彩票派奖
+	RedPackSceneProduct8 RedPackScene = "PRODUCT_8" // 税务刮奖
+)
+
+type SendRedPackReq struct {
+	XMLName     xml.Name     `xml:"xml"`
+	NonceStr    string       `xml:"nonce_str"`    // 是，随机字符串，不长于32位，不传自动生成
+	Sign        string       `xml:"sign"`         // 是，签名，自动生成
+	MchBillNo   string       `xml:"mch_billno"`   // 是，商户订单号（每个订单号必须唯一），组成：mch_id+yyyymmdd+10位一天内不能重复的数字
+	MchID       string       `xml:"mch_id"`       // 是，微信支付分配的商户号
+	WxAppID     string       `xml:"wxappid"`      // 是，微信分配的公众账号ID，不传默认为Wechat.AppID
+	SendName    string       `xml:"send_name"`    // 是，红包发送者名称
+	ReOpenID    string       `xml:"re_openid"`    // 是，接受红包的用户openid
+	TotalAmount money.Fen    `xml:"total_amount"` // 是，付款金额，单位分
+	TotalNum    int          `xml:"total_num"`    // 是，红包发放总人数，普通红包固定为1，不传默认为1
+	Wishing     string       `xml:"wishing"`      // 是，红包祝福语
+	ClientIP    string       `xml:"client_ip"`    // 是，调用接口的机器Ip地址
+	ActName     string       `xml:"act_name"`     // 是，活动名称
+	Remark      string       `xml:"remark"`       // 是，备注信息
+	SceneID     RedPackScene `xml:"scene_id"`     // 否，发放红包使用场景
+	RiskInfo    string       `xml:"risk_info"`    // 否，活动信息，urlencode(posttime=xx&mobile=xx&deviceid=xx)
+}
+
+type sendRedPackRes struct {
+	ReturnCode  string    `xml:"return_code"`
+	ReturnMsg   string    `xml:"return_msg"`
+	ResultCode  string    `xml:"result_code"`
+	ErrCode     string    `xml:"err_code"`
+	ErrCodeDes  string    `xml:"err_code_des"`
+	MchBillNo   string    `xml:"mch_billno"`   // 商户订单号
+	MchID       string    `xml:"mch_id"`       // 商户号
+	WxAppID     string    `xml:"wxappid"`      // 公众账号appid
+	ReOpenID    string    `xml:"re_openid"`    // 接受收红包的用户openid
+	TotalAmount money.Fen `xml:"total_amount"` // 付款金额，单位分
+	SendListID  string    `xml:"send_listid"`  // 微信红包订单号
+}
+
+// SendRedPack 发放普通红包；err_code 为 SYSTEMERROR 等时发放结果未知，需使用原商户订单号重试或调用 GetHbInfo 查询
+func (wx *Wechat) SendRedPack(req *SendRedPackReq, apiKey, certKey, cert string) (*sendRedPackRes, error) {
+	if req.WxAppID == "" {
+		req.WxAppID = wx.AppID
+	}
+	if req.TotalNum == 0 {
+		req.TotalNum = 1
+	}
+	if req.NonceStr == "" {
+		req.NonceStr = genNonceStr()
+	}
+
+	client, certErr := wx.certClient(cert, certKey)
+	if certErr != nil {
+		return nil, certErr
+	}
+
+	var data sendRedPackRes
+	if err := wx.postPay(sendRedPackUrl, req, &req.Sign, apiKey, client, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+type SendGroupRedPackReq struct {
+	XMLName     xml.Name     `xml:"xml"`
+	NonceStr    string       `xml:"nonce_str"`    // 是，随机字符串，不长于32位，不传自动生成
+	Sign        string       `xml:"sign"`         // 是，签名，自动生成
+	MchBillNo   string       `xml:"mch_billno"`   // 是，商户订单号（每个订单号必须唯一）
+	MchID       string       `xml:"mch_id"`       // 是，微信支付分配的商户号
+	WxAppID     string       `xml:"wxappid"`      // 是，微信分配的公众账号ID，不传默认为Wechat.AppID
+	SendName    string       `xml:"send_name"`    // 是，红包发送者名称
+	ReOpenID    string       `xml:"re_openid"`    // 是，接收红包的种子用户（首个用户）openid
+	TotalAmount money.Fen    `xml:"total_amount"` // 是，红包发放总金额，即一组红包金额总和，单位分
+	TotalNum    int          `xml:"total_num"`    // 是，红包发放总人数，即总共有多少人可以领到该组红包（包括分享者）
+	AmtType     string       `xml:"amt_type"`     // 是，红包金额设置方式，ALL_RAND—全部随机，不传默认为ALL_RAND
+	Wishing     string       `xml:"wishing"`      // 是，红包祝福语
+	ActName     string       `xml:"act_name"`     // 是，活动名称
+	Remark      string       `xml:"remark"`       // 是，备注信息
+	SceneID     RedPackScene `xml:"scene_id"`     // 否，发放红包使用场景
+	RiskInfo    string       `xml:"risk_info"`    // 否，活动信息
+}
+
+// SendGroupRedPack 发放裂变红包，返回字段同 SendRedPack
+func (wx *Wechat) SendGroupRedPack(req *SendGroupRedPackReq, apiKey, certKey, cert string) (*sendRedPackRes, error) {
+	if req.WxAppID == "" {
+		req.WxAppID = wx.AppID
+	}
+	if req.AmtType == "" {
+		req.AmtType = "ALL_RAND"
+	}
+	if req.NonceStr == "" {
+		req.NonceStr = genNonceStr()
+	}
+
+	client, certErr := wx.certClient(cert, certKey)
+	if certErr != nil {
+		return nil, certErr
+	}
+
+	var data sendRedPackRes
+	if err := wx.postPay(sendGroupRedPackUrl, req, &req.Sign, apiKey, client, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// ==================== 查询红包记录 ====================
+type GetHbInfoReq struct {
+	XMLName   xml.Name `xml:"xml"`
+	NonceStr  string   `xml:"nonce_str"`  // 是，随机字符串，不传自动生成
+	Sign      string   `xml:"sign"`       // 是，签名，自动生成
+	MchBillNo string   `xml:"mch_billno"` // 是，商户发放红包的商户订单号
+	MchID     string   `xml:"mch_id"`     // 是，微信支付分配的商户号
+	AppID     string   `xml:"appid"`      // 是，微信分配的公众账号ID，固定为Wechat.AppID
+	BillType  string   `xml:"bill_type"`  // 是，MCHT:通过商户订单号获取红包信息，不传默认为MCHT
+}
+
+// RedPackStatus 红包状态
+type RedPackStatus string
+
+const (
+	RedPackStatusSending   RedPackStatus = "SENDING"   // 发放中
+	RedPackStatusSent      RedPackStatus = "SENT"      // 已发放待领取
+	RedPackStatusFailed    RedPackStatus = "FAILED"    // 发放失败
+	RedPackStatusReceived  RedPackStatus = "RECEIVED"  // 已领取
+	RedPackStatusRefunding RedPackStatus = "RFUND_ING" // 退款中
+	RedPackStatusRefund    RedPackStatus = "REFUND"    // 已退款
+)
+
+type HbInfo struct {
+	OpenID  string    `xml:"openid"`   // 领取红包的openid
+	Amount  money.Fen `xml:"amount"`   // 领取金额
+	RcvTime string    `xml:"rcv_time"` // 领取红包的时间
+}
+
+type getHbInfoRes struct {
+	ReturnCode   string        `xml:"return_code"`
+	ReturnMsg    string        `xml:"return_msg"`
+	ResultCode   string        `xml:"result_code"`
+	ErrCode      string        `xml:"err_code"`
+	ErrCodeDes   string        `xml:"err_code_des"`
+	MchBillNo    string        `xml:"mch_billno"`    // 商户订单号
+	MchID        string        `xml:"mch_id"`        // 商户号
+	DetailID     string        `xml:"detail_id"`     // 红包单号
+	Status       RedPackStatus `xml:"status"`        // 红包状态
+	SendType     string        `xml:"send_type"`     // 发放类型，API:通过API接口发放，UPLOAD:通过上传文件方式发放，ACTIVITY:通过活动方式发放
+	HbType       string        `xml:"hb_type"`       // 红包类型，GROUP:裂变红包，NORMAL:普通红包
+	TotalNum     int           `xml:"total_num"`     // 红包个数
+	TotalAmount  money.Fen     `xml:"total_amount"`  // 红包总金额，单位分
+	Reason       string        `xml:"reason"`        // 发送失败原因
+	SendTime     string        `xml:"send_time"`     // 红包发送时间
+	RefundTime   string        `xml:"refund_time"`   // 红包的退款时间
+	RefundAmount money.Fen     `xml:"refund_amount"` // 红包退款金额
+	Wishing      string        `xml:"wishing"`       // 祝福语
+	Remark       string        `xml:"remark"`        // 活动描述
+	ActName      string        `xml:"act_name"`      // 活动名称
+	HbList       []HbInfo      `xml:"hblist>hbinfo"` // 裂变红包的领取列表
+}
+
+// GetHbInfo 查询红包记录，用于确认红包是否发放、领取或退款
+func (wx *Wechat) GetHbInfo(req *GetHbInfoReq, apiKey, certKey, cert string) (*getHbInfoRes, error) {
+	req.AppID = wx.AppID
+	if req.BillType == "" {
+		req.BillType = "MCHT"
+	}
+	if req.NonceStr == "" {
+		req.NonceStr = genNonceStr()
+	}
+
+	client, certErr := wx.certClient(cert, certKey)
+	if certErr != nil {
+		return nil, certErr
+	}
+
+	var data getHbInfoRes
+	if err := wx.postPay(getHbInfoUrl, req, &req.Sign, apiKey, client, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
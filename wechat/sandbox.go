@@ -0,0 +1,157 @@
+package wechat
+
+import (
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	mchDomain     = "https://api.mch.weixin.qq.com"
+	sandboxPath   = "/sandboxnew"
+	getSignKeyUrl = mchDomain + sandboxPath + "/pay/getsignkey"
+)
+
+// ==================== 仿真测试环境 ====================
+var ErrNoSandboxKey = errors.New("wechat pay sandbox sign key not fetched, call UseSandbox first")
+
+type Env string
+
+const (
+	OnlineEnv  = Env("online")
+	SandboxEnv = Env("sandbox") // 支付仿真测试系统，v2 支付接口请求 api.mch.weixin.qq.com/sandboxnew/，并使用沙箱密钥签名
+)
+
+// mchUrl 沙箱环境下将支付接口地址转换为 /sandboxnew/ 下的地址（需证书的 /secapi/ 接口在沙箱中去掉 secapi），
+// 设置了 MchBaseUrl 时替换接口域名
+func (wx *Wechat) mchUrl(api string) string {
+	if !strings.HasPrefix(api, mchDomain+"/") {
+		return api
+	}
+
+	path := strings.TrimPrefix(api, mchDomain)
+	if wx.Env == SandboxEnv && !strings.HasPrefix(path, sandboxPath+"/") {
+		path = sandboxPath + strings.TrimPrefix(path, "/secapi")
+	}
+	if wx.MchBaseUrl != "" {
+		return strings.TrimRight(wx.MchBaseUrl, "/") + path
+	}
+
+	return mchDomain + path
+}
+
+type GetSignKeyReq struct {
+	XMLName  xml.Name `xml:"xml"`
+	MchID    string   `xml:"mch_id"`    // 是，微信支付分配的商户号
+	NonceStr string   `xml:"nonce_str"` // 是，随机字符串，不传自动生成
+	Sign     string   `xml:"sign"`      // 是，签名，使用正式环境API密钥生成
+}
+
+type getSignKeyRes struct {
+	ReturnCode     string `xml:"return_code"`
+	ReturnMsg      string `xml:"return_msg"`
+	MchID          string `xml:"mch_id"`
+	SandboxSignKey string `xml:"sandbox_signkey"` // 沙箱密钥
+}
+
+// GetSignKey 获取仿真测试系统的沙箱密钥，apiKey 为正式环境API密钥
+func (wx *Wechat) GetSignKey(mchID, apiKey string) (*getSignKeyRes, error) {
+	req := GetSignKeyReq{MchID: mchID, NonceStr: genNonceStr()}
+	req.Sign = wx.genSign(req, apiKey)
+
+	body, xmlErr := xml.Marshal(req)
+	if xmlErr != nil {
+		return nil, xmlErr
+	}
+
+	res, httpErr := wx.postXML(nil, getSignKeyUrl, body)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	var data getSignKeyRes
+	if xmlErr := xml.Unmarshal(res, &data); xmlErr != nil {
+		return nil, xmlErr
+	}
+	if data.ReturnCode != PayReturnCodeSuccess {
+		return nil, &PayError{ReturnCode: data.ReturnCode, ReturnMsg: data.ReturnMsg}
+	}
+
+	return &data, nil
+}
+
+// sandboxKeys 按 mch_id + 正式API密钥缓存的沙箱密钥
+type sandboxKeys struct {
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func sandboxCacheKey(mchID, apiKey string) string {
+	return mchID + "\x00" + apiKey
+}
+
+// UseSandbox 切换到仿真测试环境并预先获取商户号 mchID 的沙箱密钥，之后 Sign 传入正式API密钥即使用沙箱密钥签名；
+// 使用多个商户号时需分别调用
+func (wx *Wechat) UseSandbox(mchID, apiKey string) error {
+	res, err := wx.GetSignKey(mchID, apiKey)
+	if err != nil {
+		return err
+	}
+
+	wx.sandbox.mu.Lock()
+	if wx.sandbox.keys == nil {
+		wx.sandbox.keys = make(map[string]string)
+	}
+	wx.sandbox.keys[sandboxCacheKey(mchID, apiKey)] = res.SandboxSignKey
+	wx.sandbox.mu.Unlock()
+
+	wx.Env = SandboxEnv
+
+	return nil
+}
+
+// hasSandboxKey 沙箱环境下商户号 mchID 是否已通过 UseSandbox 获取沙箱密钥，正式环境始终返回 true
+func (wx *Wechat) hasSandboxKey(mchID string) bool {
+	if wx.Env != SandboxEnv {
+		return true
+	}
+
+	wx.sandbox.mu.Lock()
+	defer wx.sandbox.mu.Unlock()
+	for k := range wx.sandbox.keys {
+		if strings.HasPrefix(k, sandboxCacheKey(mchID, "")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// signKey 沙箱环境下返回 body 中商户号对应的沙箱密钥，不发起网络请求；未通过 UseSandbox 获取时返回 ErrNoSandboxKey
+func (wx *Wechat) signKey(body interface{}, apiKey string) (string, error) {
+	if wx.Env != SandboxEnv {
+		return apiKey, nil
+	}
+
+	refVal := reflect.ValueOf(body)
+	for i := 0; i < refVal.NumField(); i++ {
+		switch refVal.Type().Field(i).Tag.Get("xml") {
+		case "mch_id", "mchid":
+			mchID := refVal.Field(i).String()
+			if mchID == "" {
+				continue
+			}
+
+			wx.sandbox.mu.Lock()
+			key, ok := wx.sandbox.keys[sandboxCacheKey(mchID, apiKey)]
+			wx.sandbox.mu.Unlock()
+			if ok {
+				return key, nil
+			}
+		}
+	}
+
+	return "", ErrNoSandboxKey
+}
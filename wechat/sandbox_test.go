@@ -0,0 +1,72 @@
+package wechat
+
+import "testing"
+
+func TestMchUrl(t *testing.T) {
+	w := NewWechat("wx1", "")
+	if got := w.mchUrl(unifiedOrderUrl); got != unifiedOrderUrl {
+		t.Fatalf("online url %s", got)
+	}
+
+	w.Env = SandboxEnv
+	cases := map[string]string{
+		unifiedOrderUrl: "https://api.mch.weixin.qq.com/sandboxnew/pay/unifiedorder",
+		refundUrl:       "https://api.mch.weixin.qq.com/sandboxnew/pay/refund",
+		getHbInfoUrl:    "https://api.mch.weixin.qq.com/sandboxnew/mmpaymkttransfers/gethbinfo",
+		getSignKeyUrl:   getSignKeyUrl,
+		accessTokenUrl:  accessTokenUrl,
+	}
+	for api, want := range cases {
+		if got := w.mchUrl(api); got != want {
+			t.Errorf("mchUrl(%s) = %s, want %s", api, got, want)
+		}
+	}
+}
+
+func TestSandboxSign(t *testing.T) {
+	w := NewWechat("wx1", "")
+	req := GetHbInfoReq{MchID: "1900000109", MchBillNo: "b1", NonceStr: "n1", BillType: "MCHT"}
+	online, _ := w.Sign(req, "key")
+
+	w.Env = SandboxEnv
+	if _, err := w.Sign(req, "key"); err != ErrNoSandboxKey {
+		t.Fatalf("got %v, want ErrNoSandboxKey", err)
+	}
+	order := UnifiedOrderReq{
+		MchID:          "1900000109",
+		NonceStr:       "n1",
+		Sign:           "s1",
+		Body:           "test",
+		OutTradeNo:     "T0001",
+		TotalFee:       100,
+		SpbillCreateIP: "127.0.0.1",
+		NotifyUrl:      "https://example.com/notify",
+		TradeType:      TradeTypeJsapi,
+		OpenID:         "o1",
+	}
+	if _, err := w.UnifiedOrder(&order); err != ErrNoSandboxKey {
+		t.Fatalf("unified order got %v, want ErrNoSandboxKey", err)
+	}
+	refund := RefundReq{MchID: "1900000109", NonceStr: "n1", Sign: "s1", OutTradeNo: "T0001", OutRefundNo: "R0001", TotalFee: 100, RefundFee: 100}
+	if _, err := w.Refund(&refund, "", ""); err != ErrNoSandboxKey {
+		t.Fatalf("refund got %v, want ErrNoSandboxKey", err)
+	}
+
+	w.sandbox.keys = map[string]string{sandboxCacheKey("1900000109", "key"): "sandboxkey"}
+	if got, err := w.Sign(req, "key"); err != nil || got != w.genSign(req, "sandboxkey") || got == online {
+		t.Fatalf("sandbox sign %s should use sandbox key, err %v", got, err)
+	}
+}
+
+func TestMchBaseUrl(t *testing.T) {
+	w := NewWechat("wx1", "")
+	w.MchBaseUrl = "http://127.0.0.1:8080/"
+	if got := w.mchUrl(refundUrl); got != "http://127.0.0.1:8080/secapi/pay/refund" {
+		t.Fatalf("mchUrl %s", got)
+	}
+
+	w.Env = SandboxEnv
+	if got := w.mchUrl(refundUrl); got != "http://127.0.0.1:8080/sandboxnew/pay/refund" {
+		t.Fatalf("sandbox mchUrl %s", got)
+	}
+}
@@ -0,0 +1,220 @@
+package wechat
+
+import (
+	"encoding/xml"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	msgSecCheckUrl     = "https://api.weixin.qq.com/wxa/msg_sec_check"
+	mediaCheckAsyncUrl = "https://api.weixin.qq.com/wxa/media_check_async"
+)
+
+// ==================== 内容安全 ====================
+// SecScene 场景枚举值
+type SecScene int
+
+const (
+	SecSceneProfile SecScene = 1 // 资料
+	SecSceneComment SecScene = 2 // 评论
+	SecSceneForum   SecScene = 3 // 论坛
+	SecSceneSocial  SecScene = 4 // 社交日志
+)
+
+// SecSuggest 建议
+type SecSuggest string
+
+const (
+	SecSuggestPass   SecSuggest = "pass"
+	SecSuggestReview SecSuggest = "review"
+	SecSuggestRisky  SecSuggest = "risky"
+)
+
+// SecResult 综合结果
+type SecResult struct {
+	Suggest SecSuggest `json:"suggest" xml:"suggest"` // 建议，有risky、pass、review三种值
+	Label   int        `json:"label" xml:"label"`     // 命中标签枚举值，100 正常；10001 广告；20001 时政；20002 色情；20003 辱骂；20006 违法犯罪；20008 欺诈；20012 低俗；20013 版权；21000 其他
+}
+
+// SecDetail 详细检测结果
+type SecDetail struct {
+	Strategy string     `json:"strategy" xml:"strategy"` // 策略类型
+	ErrCode  int        `json:"errcode" xml:"errcode"`   // 错误码，仅当该值为0时，该项结果有效
+	Suggest  SecSuggest `json:"suggest" xml:"suggest"`   // 建议
+	Label    int        `json:"label" xml:"label"`       // 命中标签枚举值
+	Prob     int        `json:"prob" xml:"prob"`         // 0-100，代表置信度，越高代表越有可能属于当前返回的标签（label）
+	Level    int        `json:"level" xml:"level"`       // 命中的自定义关键词的等级
+	Keyword  string     `json:"keyword" xml:"keyword"`   // 命中的自定义关键词
+}
+
+type MsgSecCheckReq struct {
+	Content   string   `json:"content"`             // 是 需检测的文本内容，文本字数的上限为2500字，需使用UTF-8编码
+	Version   int      `json:"version"`             // 是 接口版本号，2.0版本为固定值2，不传默认为2
+	Scene     SecScene `json:"scene"`               // 是 场景枚举值
+	OpenID    string   `json:"openid"`              // 是 用户的openid（用户需在近两小时访问过小程序）
+	Title     string   `json:"title,omitempty"`     // 否 文本标题
+	Nickname  string   `json:"nickname,omitempty"`  // 否 用户昵称
+	Signature string   `json:"signature,omitempty"` // 否 个性签名，该参数仅在资料类场景有效(scene=1)
+}
+
+type msgSecCheckRes struct {
+	ErrCode float64     `json:"errcode"`
+	ErrMsg  string      `json:"errmsg"`
+	TraceID string      `json:"trace_id"` // 唯一请求标识，标记单次请求
+	Result  SecResult   `json:"result"`   // 综合结果
+	Detail  []SecDetail `json:"detail"`   // 详细检测结果
+}
+
+// MsgSecCheck 检查一段文本是否含有违法违规内容（2.0版本）
+func (wx *Wechat) MsgSecCheck(accessToken string, req *MsgSecCheckReq) (*msgSecCheckRes, error) {
+	if req.Version == 0 {
+		req.Version = 2
+	}
+
+	var data msgSecCheckRes
+	if err := wx.postJSON(msgSecCheckUrl, accessToken, req, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// MediaType 媒体类型
+type MediaType int
+
+const (
+	MediaTypeAudio MediaType = 1 // 音频
+	MediaTypeImage MediaType = 2 // 图片
+)
+
+type MediaCheckAsyncReq struct {
+	MediaUrl  string    `json:"media_url"`  // 是 要检测的图片或音频的url，支持图片格式包括jpg, jepg, png, bmp, gif（取首帧），支持的音频格式包括mp3, aac, ac3, wma, flac, vorbis, opus, wav
+	MediaType MediaType `json:"media_type"` // 是 1:音频;2:图片
+	Version   int       `json:"version"`    // 是 接口版本号，2.0版本为固定值2，不传默认为2
+	Scene     SecScene  `json:"scene"`      // 是 场景枚举值
+	OpenID    string    `json:"openid"`     // 是 用户的openid（用户需在近两小时访问过小程序）
+}
+
+type mediaCheckAsyncRes struct {
+	ErrCode float64 `json:"errcode"`
+	ErrMsg  string  `json:"errmsg"`
+	TraceID string  `json:"trace_id"` // 唯一请求标识，标记单次请求，用于匹配异步推送结果
+}
+
+// MediaCheckAsync 异步校验图片/音频是否含有违法违规内容，检测结果在 30 分钟内以 wxa_media_check 事件推送
+func (wx *Wechat) MediaCheckAsync(accessToken string, req *MediaCheckAsyncReq) (*mediaCheckAsyncRes, error) {
+	if req.Version == 0 {
+		req.Version = 2
+	}
+
+	var data mediaCheckAsyncRes
+	if err := wx.postJSON(mediaCheckAsyncUrl, accessToken, req, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// MediaCheckEvent 异步检测结果推送（wxa_media_check 事件），支持 json 和 xml 两种数据格式
+type MediaCheckEvent struct {
+	XMLName      xml.Name    `json:"-" xml:"xml"`
+	ToUserName   string      `json:"ToUserName" xml:"ToUserName"`     // 小程序的username
+	FromUserName string      `json:"FromUserName" xml:"FromUserName"` // 平台推送服务UserName
+	CreateTime   int64       `json:"CreateTime" xml:"CreateTime"`     // 发送时间
+	MsgType      string      `json:"MsgType" xml:"MsgType"`           // 默认为：event
+	Event        string      `json:"Event" xml:"Event"`               // 默认为：wxa_media_check
+	AppID        string      `json:"appid" xml:"appid"`               // 小程序的appid
+	TraceID      string      `json:"trace_id" xml:"trace_id"`         // 任务id
+	Version      int         `json:"version" xml:"version"`           // 可用于区分接口版本
+	Detail       []SecDetail `json:"detail" xml:"detail"`             // 详细检测结果
+	ErrCode      int         `json:"errcode" xml:"errcode"`
+	ErrMsg       string      `json:"errmsg" xml:"errmsg"`
+	Result       SecResult   `json:"result" xml:"result"` // 综合结果
+}
+
+const EventMediaCheck = "wxa_media_check"
+
+// ==================== 异步检测任务关联 ====================
+// MediaCheckTask 已提交的异步检测任务
+type MediaCheckTask struct {
+	TraceID     string
+	Req         MediaCheckAsyncReq
+	SubmittedAt time.Time
+}
+
+// 结果最长 30 分钟内推送，超过该时间仍未收到推送的任务会被清理
+const mediaCheckTaskTTL = time.Hour
+
+// MediaCheckRegistry 记录已提交的异步检测任务，收到推送时按 trace_id 找回提交的媒体，可并发使用
+type MediaCheckRegistry struct {
+	// OnResult 收到检测结果时调用，task 为 nil 表示 trace_id 不是由该 registry 提交（例如进程重启）
+	OnResult func(task *MediaCheckTask, event *MediaCheckEvent)
+
+	mu    sync.Mutex
+	tasks map[string]*MediaCheckTask
+	now   func() time.Time
+}
+
+func NewMediaCheckRegistry(onResult func(task *MediaCheckTask, event *MediaCheckEvent)) *MediaCheckRegistry {
+	return &MediaCheckRegistry{
+		OnResult: onResult,
+		tasks:    make(map[string]*MediaCheckTask),
+		now:      time.Now,
+	}
+}
+
+// Submit 调用 MediaCheckAsync 并记录任务，返回 trace_id
+func (r *MediaCheckRegistry) Submit(wx *Wechat, accessToken string, req *MediaCheckAsyncReq) (string, error) {
+	res, err := wx.MediaCheckAsync(accessToken, req)
+	if err != nil {
+		return "", err
+	}
+
+	r.Track(res.TraceID, req)
+
+	return res.TraceID, nil
+}
+
+// Track 记录已提交的任务
+func (r *MediaCheckRegistry) Track(traceID string, req *MediaCheckAsyncReq) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for id, task := range r.tasks {
+		if now.Sub(task.SubmittedAt) > mediaCheckTaskTTL {
+			delete(r.tasks, id)
+		}
+	}
+
+	r.tasks[traceID] = &MediaCheckTask{TraceID: traceID, Req: *req, SubmittedAt: now}
+}
+
+// Resolve 处理检测结果推送，返回对应的任务并将其移除
+func (r *MediaCheckRegistry) Resolve(event *MediaCheckEvent) *MediaCheckTask {
+	r.mu.Lock()
+	task := r.tasks[event.TraceID]
+	delete(r.tasks, event.TraceID)
+	r.mu.Unlock()
+
+	if r.OnResult != nil {
+		r.OnResult(task, event)
+	}
+
+	return task
+}
+
+// Pending 返回尚未收到结果的任务数
+func (r *MediaCheckRegistry) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.tasks)
+}
+
+// Handler 只处理 wxa_media_check 事件的消息推送服务，其他消息直接回复 success
+func (r *MediaCheckRegistry) Handler(token string) http.Handler {
+	return &PushServer{Token: token, MediaCheck: r}
+}
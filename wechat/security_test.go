@@ -0,0 +1,56 @@
+package wechat
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMediaCheckRegistry(t *testing.T) {
+	token := "push-token"
+
+	var (
+		gotTask  *MediaCheckTask
+		gotEvent *MediaCheckEvent
+	)
+	registry := NewMediaCheckRegistry(func(task *MediaCheckTask, event *MediaCheckEvent) {
+		gotTask, gotEvent = task, event
+	})
+	registry.Track("trace-1", &MediaCheckAsyncReq{MediaUrl: "https://example.com/a.png", MediaType: MediaTypeImage, OpenID: "o1"})
+
+	query := url.Values{"timestamp": {"1600000000"}, "nonce": {"n1"}}
+	query.Set("signature", pushSignature(token, "1600000000", "n1"))
+	handler := registry.Handler(token)
+
+	body := `{"ToUserName":"gh_1","FromUserName":"o1","CreateTime":1600000000,"MsgType":"event","Event":"wxa_media_check","appid":"wx1","trace_id":"trace-1","version":2,"detail":[{"strategy":"content_model","errcode":0,"suggest":"risky","label":20002,"prob":90}],"errcode":0,"errmsg":"ok","result":{"suggest":"risky","label":20002}}`
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/push?"+query.Encode(), strings.NewReader(body)))
+	if w.Code != http.StatusOK || w.Body.String() != "success" {
+		t.Fatalf("response %d %s", w.Code, w.Body.String())
+	}
+	if gotTask == nil || gotTask.Req.MediaUrl != "https://example.com/a.png" {
+		t.Fatalf("task %+v", gotTask)
+	}
+	if gotEvent.Result.Suggest != SecSuggestRisky || len(gotEvent.Detail) != 1 || gotEvent.Detail[0].Label != 20002 {
+		t.Fatalf("event %+v", gotEvent)
+	}
+	if registry.Pending() != 0 {
+		t.Fatal("resolved task should be removed")
+	}
+
+	xmlBody := `<xml><ToUserName>gh_1</ToUserName><MsgType>event</MsgType><Event>wxa_media_check</Event><trace_id>trace-2</trace_id><result><suggest>pass</suggest><label>100</label></result></xml>`
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/push?"+query.Encode(), strings.NewReader(xmlBody)))
+	if w.Code != http.StatusOK || gotTask != nil || gotEvent.TraceID != "trace-2" || gotEvent.Result.Suggest != SecSuggestPass {
+		t.Fatalf("unknown trace_id: task %+v event %+v", gotTask, gotEvent)
+	}
+
+	query.Set("signature", "forged")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/push?"+query.Encode(), strings.NewReader(body)))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("forged signature should be rejected, got %d", w.Code)
+	}
+}
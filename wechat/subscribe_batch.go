@@ -0,0 +1,208 @@
+package wechat
+
+import (
+	"context"
+	"github.com/MangoMilk/go-sdk/miniprogram"
+	"golang.org/x/time/rate"
+	"sync"
+)
+
+// ==================== 批量发送订阅通知 ====================
+// SubscribeSendStatus 单条订阅通知的发送结果
+type SubscribeSendStatus string
+
+const (
+	SubscribeSendSuccess       SubscribeSendStatus = "success"        // 发送成功
+	SubscribeSendUserRefused   SubscribeSendStatus = "user_refused"   // 43101 用户拒绝接受消息或未订阅
+	SubscribeSendInvalidData   SubscribeSendStatus = "invalid_data"   // 47003 模板参数不准确
+	SubscribeSendQuotaExceeded SubscribeSendStatus = "quota_exceeded" // 45009 接口调用超过限额，后续消息不再发送
+	SubscribeSendFailed        SubscribeSendStatus = "failed"         // 其他错误
+	SubscribeSendCanceled      SubscribeSendStatus = "canceled"       // ctx 取消，未发送
+)
+
+const (
+	errCodeInvalidCredential = 40001
+	errCodeTokenExpired      = 42001
+	errCodeUserRefused       = 43101
+	errCodeQuotaExceeded     = 45009
+	errCodeInvalidData       = 47003
+)
+
+// SubscribeSendResult 单条订阅通知的发送结果
+type SubscribeSendResult struct {
+	Index   int // 请求在输入流中的序号，从 0 开始
+	Req     *SubscribeSendReq
+	Status  SubscribeSendStatus
+	ErrCode float64 // 接口返回的 errcode
+	ErrMsg  string  // 接口返回的 errmsg
+	Err     error   // 网络等非业务错误
+}
+
+// SubscribeSendReport 批量发送结果，Results 按 Index 排序
+type SubscribeSendReport struct {
+	Results []SubscribeSendResult
+	Counts  map[SubscribeSendStatus]int
+}
+
+// SubscribeSender 批量发送订阅通知：按 Limiter 限速，Workers 个协程并发发送；
+// access_token 失效时刷新后重试一次，遇到 45009 后剩余消息直接标记为 quota_exceeded
+type SubscribeSender struct {
+	Limiter *rate.Limiter
+	Workers int
+
+	token func() (string, error)
+	send  func(accessToken string, req *SubscribeSendReq) (*miniprogram.Result, error)
+	renew func(accessToken string) // 丢弃失效的 accessToken，其他协程已刷新时不处理
+}
+
+// NewSubscribeSender 每秒最多发送 rps 条，突发 burst 条，workers 个协程并发；access_token 使用 wx.AccessToken 缓存
+func NewSubscribeSender(wx *Wechat, rps float64, burst int, workers int) *SubscribeSender {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	return &SubscribeSender{
+		Limiter: rate.NewLimiter(rate.Limit(rps), burst),
+		Workers: workers,
+		token:   wx.AccessToken,
+		send:    wx.SubscribeSend,
+		renew:   wx.invalidateAccessToken,
+	}
+}
+
+// SendAll 批量发送并返回结果报告
+func (s *SubscribeSender) SendAll(ctx context.Context, reqs []*SubscribeSendReq) *SubscribeSendReport {
+	ch := make(chan *SubscribeSendReq)
+	go func() {
+		defer close(ch)
+		for _, req := range reqs {
+			ch <- req
+		}
+	}()
+
+	return s.Send(ctx, ch)
+}
+
+// Send 从 reqs 读取待发送的消息直到 reqs 关闭，返回结果报告；ctx 取消后剩余消息标记为 canceled
+func (s *SubscribeSender) Send(ctx context.Context, reqs <-chan *SubscribeSendReq) *SubscribeSendReport {
+	type job struct {
+		index int
+		req   *SubscribeSendReq
+	}
+
+	var (
+		jobs    = make(chan job)
+		mu      sync.Mutex
+		results []SubscribeSendResult
+		quota   bool
+		wg      sync.WaitGroup
+	)
+
+	record := func(result SubscribeSendResult) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if result.Status == SubscribeSendQuotaExceeded {
+			quota = true
+		}
+		results = append(results, result)
+	}
+	quotaExceeded := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return quota
+	}
+
+	workers := s.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				result := SubscribeSendResult{Index: j.index, Req: j.req}
+				if quotaExceeded() {
+					result.Status = SubscribeSendQuotaExceeded
+				} else if waitErr := s.wait(ctx); waitErr != nil {
+					result.Status = SubscribeSendCanceled
+					result.Err = waitErr
+				} else {
+					s.sendOne(&result)
+				}
+				record(result)
+			}
+		}()
+	}
+
+	index := 0
+	for req := range reqs {
+		jobs <- job{index: index, req: req}
+		index++
+	}
+	close(jobs)
+	wg.Wait()
+
+	report := &SubscribeSendReport{
+		Results: make([]SubscribeSendResult, len(results)),
+		Counts:  make(map[SubscribeSendStatus]int),
+	}
+	for _, result := range results {
+		report.Results[result.Index] = result
+		report.Counts[result.Status]++
+	}
+
+	return report
+}
+
+func (s *SubscribeSender) wait(ctx context.Context) error {
+	if s.Limiter == nil {
+		return ctx.Err()
+	}
+
+	return s.Limiter.Wait(ctx)
+}
+
+func (s *SubscribeSender) sendOne(result *SubscribeSendResult) {
+	for attempt := 0; attempt < 2; attempt++ {
+		accessToken, tokenErr := s.token()
+		if tokenErr != nil {
+			result.Status = SubscribeSendFailed
+			result.Err = tokenErr
+			return
+		}
+
+		res, sendErr := s.send(accessToken, result.Req)
+		if sendErr != nil {
+			result.Status = SubscribeSendFailed
+			result.Err = sendErr
+			return
+		}
+
+		result.ErrCode, result.ErrMsg = res.ErrCode, res.ErrMsg
+		if (res.ErrCode == errCodeInvalidCredential || res.ErrCode == errCodeTokenExpired) && attempt == 0 && s.renew != nil {
+			s.renew(accessToken)
+			continue
+		}
+		break
+	}
+
+	result.Status = classifySubscribeSend(result.ErrCode)
+}
+
+func classifySubscribeSend(errCode float64) SubscribeSendStatus {
+	switch errCode {
+	case 0:
+		return SubscribeSendSuccess
+	case errCodeUserRefused:
+		return SubscribeSendUserRefused
+	case errCodeInvalidData:
+		return SubscribeSendInvalidData
+	case errCodeQuotaExceeded:
+		return SubscribeSendQuotaExceeded
+	default:
+		return SubscribeSendFailed
+	}
+}
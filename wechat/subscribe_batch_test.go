@@ -0,0 +1,100 @@
+package wechat
+
+import (
+	"context"
+	"github.com/MangoMilk/go-sdk/miniprogram"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSubscribeSender(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		renewed int
+		token   = "expired"
+	)
+	sender := &SubscribeSender{
+		Workers: 4,
+		token: func() (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return token, nil
+		},
+		renew: func(accessToken string) {
+			mu.Lock()
+			defer mu.Unlock()
+			if token == accessToken {
+				renewed++
+				token = "fresh"
+			}
+		},
+		send: func(accessToken string, req *SubscribeSendReq) (*miniprogram.Result, error) {
+			if accessToken != "fresh" {
+				return &miniprogram.Result{ErrCode: errCodeTokenExpired, ErrMsg: "access_token expired"}, nil
+			}
+			switch req.ToUser {
+			case "refused":
+				return &miniprogram.Result{ErrCode: errCodeUserRefused, ErrMsg: "user refuse to accept the msg"}, nil
+			case "bad":
+				return &miniprogram.Result{ErrCode: errCodeInvalidData, ErrMsg: "argument invalid"}, nil
+			}
+			return &miniprogram.Result{}, nil
+		},
+	}
+
+	var reqs []*SubscribeSendReq
+	for i := 0; i < 20; i++ {
+		reqs = append(reqs, &SubscribeSendReq{ToUser: "o" + strconv.Itoa(i)})
+	}
+	reqs = append(reqs, &SubscribeSendReq{ToUser: "refused"}, &SubscribeSendReq{ToUser: "bad"})
+
+	report := sender.SendAll(context.Background(), reqs)
+	if len(report.Results) != len(reqs) {
+		t.Fatalf("got %d results", len(report.Results))
+	}
+	for i, result := range report.Results {
+		if result.Index != i || result.Req != reqs[i] {
+			t.Fatalf("result %d out of order: %+v", i, result)
+		}
+	}
+	if report.Counts[SubscribeSendSuccess] != 20 || report.Counts[SubscribeSendUserRefused] != 1 || report.Counts[SubscribeSendInvalidData] != 1 {
+		t.Fatalf("counts %v", report.Counts)
+	}
+	if renewed != 1 {
+		t.Fatalf("expired access_token renewed %d times, want 1", renewed)
+	}
+}
+
+func TestSubscribeSenderQuota(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	sender := &SubscribeSender{
+		Workers: 1,
+		token:   func() (string, error) { return "token", nil },
+		send: func(accessToken string, req *SubscribeSendReq) (*miniprogram.Result, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			if calls >= 3 {
+				return &miniprogram.Result{ErrCode: errCodeQuotaExceeded, ErrMsg: "reach max api daily quota limit"}, nil
+			}
+			return &miniprogram.Result{}, nil
+		},
+	}
+
+	reqs := make([]*SubscribeSendReq, 10)
+	for i := range reqs {
+		reqs[i] = &SubscribeSendReq{ToUser: "o" + strconv.Itoa(i)}
+	}
+
+	report := sender.SendAll(context.Background(), reqs)
+	if calls != 3 {
+		t.Fatalf("sending should stop after quota exceeded, got %d calls", calls)
+	}
+	if report.Counts[SubscribeSendSuccess] != 2 || report.Counts[SubscribeSendQuotaExceeded] != 8 {
+		t.Fatalf("counts %v", report.Counts)
+	}
+}
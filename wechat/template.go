@@ -0,0 +1,169 @@
+package wechat
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	getTemplateListUrl         = "https://api.weixin.qq.com/wxaapi/newtmpl/gettemplate"
+	addTemplateUrl             = "https://api.weixin.qq.com/wxaapi/newtmpl/addtemplate"
+	deleteTemplateUrl          = "https://api.weixin.qq.com/wxaapi/newtmpl/deltemplate"
+	getCategoryUrl             = "https://api.weixin.qq.com/wxaapi/newtmpl/getcategory"
+	getPubTemplateTitleListUrl = "https://api.weixin.qq.com/wxaapi/newtmpl/getpubtemplatetitles"
+	getPubTemplateKeyWordsUrl  = "https://api.weixin.qq.com/wxaapi/newtmpl/getpubtemplatekeywords"
+)
+
+// ==================== 订阅消息模板管理 ====================
+// TemplateType 模版类型
+type TemplateType int
+
+const (
+	TemplateTypeOnce     TemplateType = 2 // 一次性订阅
+	TemplateTypeLongTerm TemplateType = 3 // 长期订阅
+)
+
+type Template struct {
+	PriTmplID string       `json:"priTmplId"` // 添加至账号下的模板 id，发送小程序订阅消息时所需
+	Title     string       `json:"title"`     // 模版标题
+	Content   string       `json:"content"`   // 模版内容
+	Example   string       `json:"example"`   // 模板内容示例
+	Type      TemplateType `json:"type"`      // 模版类型，2 为一次性订阅，3 为长期订阅
+}
+
+type getTemplateListRes struct {
+	ErrCode float64    `json:"errcode"`
+	ErrMsg  string     `json:"errmsg"`
+	Data    []Template `json:"data"`
+}
+
+// GetTemplateList 获取当前帐号下的个人模板列表
+func (wx *Wechat) GetTemplateList(accessToken string) (*getTemplateListRes, error) {
+	var data getTemplateListRes
+	if err := wx.getJSON(getTemplateListUrl, accessToken, nil, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+type AddTemplateReq struct {
+	Tid       string `json:"tid"`                 // 是 模板标题 id，可通过 GetPubTemplateTitleList 获取
+	KidList   []int  `json:"kidList"`             // 是 开发者自行组合好的模板关键词列表，关键词顺序可以自由搭配，最多支持5个，最少2个关键词组合
+	SceneDesc string `json:"sceneDesc,omitempty"` // 否 服务场景描述，15个字以内
+}
+
+type addTemplateRes struct {
+	ErrCode   float64 `json:"errcode"`
+	ErrMsg    string  `json:"errmsg"`
+	PriTmplID string  `json:"priTmplId"` // 添加至账号下的模板id，发送小程序订阅消息时所需
+}
+
+// AddTemplate 组合模板并添加至帐号下的个人模板库
+func (wx *Wechat) AddTemplate(accessToken string, req *AddTemplateReq) (*addTemplateRes, error) {
+	var data addTemplateRes
+	if err := wx.postJSON(addTemplateUrl, accessToken, req, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+type deleteTemplateReq struct {
+	PriTmplID string `json:"priTmplId"`
+}
+
+type deleteTemplateRes struct {
+	ErrCode float64 `json:"errcode"`
+	ErrMsg  string  `json:"errmsg"`
+}
+
+// DeleteTemplate 删除帐号下的个人模板
+func (wx *Wechat) DeleteTemplate(accessToken, priTmplID string) (*deleteTemplateRes, error) {
+	var data deleteTemplateRes
+	if err := wx.postJSON(deleteTemplateUrl, accessToken, deleteTemplateReq{PriTmplID: priTmplID}, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+type Category struct {
+	ID   int    `json:"id"`   // 类目id，查询公共库模版时需要
+	Name string `json:"name"` // 类目的中文名
+}
+
+type getCategoryRes struct {
+	ErrCode float64    `json:"errcode"`
+	ErrMsg  string     `json:"errmsg"`
+	Data    []Category `json:"data"`
+}
+
+// GetCategory 获取小程序账号的类目
+func (wx *Wechat) GetCategory(accessToken string) (*getCategoryRes, error) {
+	var data getCategoryRes
+	if err := wx.getJSON(getCategoryUrl, accessToken, nil, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+type PubTemplateTitle struct {
+	Tid        int          `json:"tid"`        // 模版标题 id
+	Title      string       `json:"title"`      // 模版标题
+	Type       TemplateType `json:"type"`       // 模版类型，2 为一次性订阅，3 为长期订阅
+	CategoryID string       `json:"categoryId"` // 模版所属类目 id
+}
+
+type getPubTemplateTitleListRes struct {
+	ErrCode float64            `json:"errcode"`
+	ErrMsg  string             `json:"errmsg"`
+	Count   int                `json:"count"` // 模版标题列表总数
+	Data    []PubTemplateTitle `json:"data"`
+}
+
+// GetPubTemplateTitleList 获取帐号所属类目下的公共模板标题，limit 最大为30
+func (wx *Wechat) GetPubTemplateTitleList(accessToken string, categoryIDs []int, start, limit int) (*getPubTemplateTitleListRes, error) {
+	ids := make([]string, 0, len(categoryIDs))
+	for _, id := range categoryIDs {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	query := url.Values{
+		"ids":   {strings.Join(ids, ",")},
+		"start": {strconv.Itoa(start)},
+		"limit": {strconv.Itoa(limit)},
+	}
+
+	var data getPubTemplateTitleListRes
+	if err := wx.getJSON(getPubTemplateTitleListUrl, accessToken, query, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+type PubTemplateKeyWord struct {
+	Kid     int    `json:"kid"`     // 关键词 id，选用模板时需要
+	Name    string `json:"name"`    // 关键词内容
+	Example string `json:"example"` // 关键词内容对应的示例
+	Rule    string `json:"rule"`    // 参数类型
+}
+
+type getPubTemplateKeyWordsRes struct {
+	ErrCode float64              `json:"errcode"`
+	ErrMsg  string               `json:"errmsg"`
+	Count   int                  `json:"count"` // 模版标题列表总数
+	Data    []PubTemplateKeyWord `json:"data"`
+}
+
+// GetPubTemplateKeyWords 获取模板标题下的关键词列表
+func (wx *Wechat) GetPubTemplateKeyWords(accessToken, tid string) (*getPubTemplateKeyWordsRes, error) {
+	var data getPubTemplateKeyWordsRes
+	if err := wx.getJSON(getPubTemplateKeyWordsUrl, accessToken, url.Values{"tid": {tid}}, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
@@ -0,0 +1,135 @@
+package wechat
+
+import (
+	"encoding/xml"
+	"github.com/MangoMilk/go-sdk/money"
+)
+
+const (
+	transfersUrl       = "https://api.mch.weixin.qq.com/mmpaymkttransfers/promotion/transfers"
+	getTransferInfoUrl = "https://api.mch.weixin.qq.com/mmpaymkttransfers/gettransferinfo"
+)
+
+// ==================== 企业付款到零钱 ====================
+// CheckName 校验用户姓名选项
+type CheckName string
+
+const (
+	CheckNameNo    CheckName = "NO_CHECK"    // 不校验真实姓名
+	CheckNameForce CheckName = "FORCE_CHECK" // 强校验真实姓名
+)
+
+type TransfersReq struct {
+	XMLName        xml.Name  `xml:"xml"`
+	MchAppID       string    `xml:"mch_appid"`        // 是，商户账号appid，不传默认为Wechat.AppID
+	MchID          string    `xml:"mchid"`            // 是，微信支付分配的商户号
+	DeviceInfo     string    `xml:"device_info"`      // 否，微信支付分配的终端设备号
+	NonceStr       string    `xml:"nonce_str"`        // 是，随机字符串，不传自动生成
+	Sign           string    `xml:"sign"`             // 是，签名，自动生成
+	PartnerTradeNo string    `xml:"partner_trade_no"` // 是，商户订单号，需保持唯一性
+	OpenID         string    `xml:"openid"`           // 是，商户appid下，某用户的openid
+	CheckName      CheckName `xml:"check_name"`       // 是，校验用户姓名选项，不传默认为NO_CHECK
+	ReUserName     string    `xml:"re_user_name"`     // 否，收款用户真实姓名，check_name为FORCE_CHECK时必填
+	Amount         money.Fen `xml:"amount"`           // 是，企业付款金额，单位为分
+	Desc           string    `xml:"desc"`             // 是，企业付款备注
+	SpbillCreateIP string    `xml:"spbill_create_ip"` // 否，该IP同在商户平台设置的IP白名单中的IP没有关联
+}
+
+type transfersRes struct {
+	ReturnCode     string `xml:"return_code"`
+	ReturnMsg      string `xml:"return_msg"`
+	MchAppID       string `xml:"mch_appid"`
+	MchID          string `xml:"mchid"`
+	DeviceInfo     string `xml:"device_info"`
+	NonceStr       string `xml:"nonce_str"`
+	ResultCode     string `xml:"result_code"`
+	ErrCode        string `xml:"err_code"`
+	ErrCodeDes     string `xml:"err_code_des"`
+	PartnerTradeNo string `xml:"partner_trade_no"` // 商户订单号
+	PaymentNo      string `xml:"payment_no"`       // 微信付款单号
+	PaymentTime    string `xml:"payment_time"`     // 付款成功时间
+}
+
+// Transfers 企业付款到零钱；err_code 为 SYSTEMERROR 等时付款结果未知，需使用原商户订单号重试或调用 GetTransferInfo 查询
+func (wx *Wechat) Transfers(req *TransfersReq, apiKey, certKey, cert string) (*transfersRes, error) {
+	if req.MchAppID == "" {
+		req.MchAppID = wx.AppID
+	}
+	if req.CheckName == "" {
+		req.CheckName = CheckNameNo
+	}
+	if req.NonceStr == "" {
+		req.NonceStr = genNonceStr()
+	}
+
+	client, certErr := wx.certClient(cert, certKey)
+	if certErr != nil {
+		return nil, certErr
+	}
+
+	var data transfersRes
+	if err := wx.postPay(transfersUrl, req, &req.Sign, apiKey, client, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// ==================== 查询企业付款 ====================
+type GetTransferInfoReq struct {
+	XMLName        xml.Name `xml:"xml"`
+	NonceStr       string   `xml:"nonce_str"`        // 是，随机字符串，不传自动生成
+	Sign           string   `xml:"sign"`             // 是，签名，自动生成
+	PartnerTradeNo string   `xml:"partner_trade_no"` // 是，商户调用企业付款API时使用的商户订单号
+	MchID          string   `xml:"mch_id"`           // 是，微信支付分配的商户号
+	AppID          string   `xml:"appid"`            // 是，商户号的appid，固定为Wechat.AppID
+}
+
+// TransferStatus 转账状态
+type TransferStatus string
+
+const (
+	TransferStatusSuccess    TransferStatus = "SUCCESS"    // 转账成功
+	TransferStatusFailed     TransferStatus = "FAILED"     // 转账失败
+	TransferStatusProcessing TransferStatus = "PROCESSING" // 处理中
+)
+
+type getTransferInfoRes struct {
+	ReturnCode     string         `xml:"return_code"`
+	ReturnMsg      string         `xml:"return_msg"`
+	ResultCode     string         `xml:"result_code"`
+	ErrCode        string         `xml:"err_code"`
+	ErrCodeDes     string         `xml:"err_code_des"`
+	PartnerTradeNo string         `xml:"partner_trade_no"` // 商户单号
+	AppID          string         `xml:"appid"`            // 商户号的appid
+	MchID          string         `xml:"mch_id"`           // 商户号
+	DetailID       string         `xml:"detail_id"`        // 付款单号
+	Status         TransferStatus `xml:"status"`           // 转账状态
+	Reason         string         `xml:"reason"`           // 失败原因
+	OpenID         string         `xml:"openid"`           // 收款用户openid
+	TransferName   string         `xml:"transfer_name"`    // 收款用户姓名
+	PaymentAmount  money.Fen      `xml:"payment_amount"`   // 付款金额，单位为分
+	TransferTime   string         `xml:"transfer_time"`    // 转账时间
+	PaymentTime    string         `xml:"payment_time"`     // 付款成功时间
+	Desc           string         `xml:"desc"`             // 企业付款备注
+}
+
+// GetTransferInfo 查询企业付款结果
+func (wx *Wechat) GetTransferInfo(req *GetTransferInfoReq, apiKey, certKey, cert string) (*getTransferInfoRes, error) {
+	req.AppID = wx.AppID
+	if req.NonceStr == "" {
+		req.NonceStr = genNonceStr()
+	}
+
+	client, certErr := wx.certClient(cert, certKey)
+	if certErr != nil {
+		return nil, certErr
+	}
+
+	var data getTransferInfoRes
+	if err := wx.postPay(getTransferInfoUrl, req, &req.Sign, apiKey, client, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
@@ -2,17 +2,25 @@ package wechat
 
 import (
 	"crypto/md5"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"github.com/MangoMilk/go-kit/encode"
 	"github.com/MangoMilk/go-kit/encrypt"
 	"github.com/MangoMilk/go-kit/net"
+	"github.com/MangoMilk/go-sdk/mchcert"
+	"github.com/MangoMilk/go-sdk/miniprogram"
+	"github.com/MangoMilk/go-sdk/money"
+	"github.com/MangoMilk/go-sdk/validate"
 	"reflect"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -20,16 +28,29 @@ const (
 	jsCode2SessionUrl = "https://api.weixin.qq.com/sns/jscode2session"
 	refundUrl         = "https://api.mch.weixin.qq.com/secapi/pay/refund"
 	accessTokenUrl    = "https://api.weixin.qq.com/cgi-bin/token"
-	wxACodeUnLimitUrl = "https://api.weixin.qq.com/wxa/getwxacodeunlimit"
 	subscribeSendUrl  = "https://api.weixin.qq.com/cgi-bin/message/subscribe/send"
+	phoneNumberUrl    = "https://api.weixin.qq.com/wxa/business/getuserphonenumber"
 )
 
 type Wechat struct {
 	AppID        string
 	AppSecret    string
 	ZeroValueMap map[string]interface{} // use for gen sign
+	Cert         *mchcert.Certificate   // 商户API证书，需要证书的接口未传入证书文件路径时使用
+	Env          Env                    // 支付接口环境，默认为正式环境
+	MchBaseUrl   string                 // 支付接口地址，默认为 https://api.mch.weixin.qq.com，可指向本地测试服务（如 wechattest.Server.URL）
+
+	WatermarkMaxAge time.Duration // 解密数据水印的有效期，为 0 时使用 miniprogram.DefaultWatermarkMaxAge
+
+	sandbox   sandboxKeys
+	certFiles mchcert.Files
+
+	tokenOnce  sync.Once
+	tokenCache *miniprogram.TokenCache
 }
 
+var _ miniprogram.Client = (*Wechat)(nil)
+
 func NewWechat(appID, appSecret string) *Wechat {
 	return &Wechat{
 		AppID:        appID,
@@ -58,7 +79,25 @@ const (
 	SignTypeHmacSha256 = SignType("HMAC-SHA256")
 )
 
+// GenSign 生成 v2 支付接口签名，沙箱密钥未获取时返回空字符串
+//
+// Deprecated: 沙箱环境下会吞掉 ErrNoSandboxKey，使用 Sign
 func (wx *Wechat) GenSign(body interface{}, apiKey string) string {
+	sign, _ := wx.Sign(body, apiKey)
+	return sign
+}
+
+// Sign 生成 v2 支付接口签名，沙箱环境下使用 UseSandbox 获取的沙箱密钥，未获取时返回 ErrNoSandboxKey
+func (wx *Wechat) Sign(body interface{}, apiKey string) (string, error) {
+	key, keyErr := wx.signKey(body, apiKey)
+	if keyErr != nil {
+		return "", keyErr
+	}
+
+	return wx.genSign(body, key), nil
+}
+
+func (wx *Wechat) genSign(body interface{}, apiKey string) string {
 	var data = make(map[string]reflect.Value)
 	refVal := reflect.ValueOf(body)
 	for i := 0; i < refVal.NumField(); i++ {
@@ -121,22 +160,22 @@ const (
 )
 
 type UnifiedOrderReq struct {
-	XMLName        xml.Name `xml:"xml"`
-	AppID          string   `xml:"appid"`            // 是，微信分配的小程序ID
-	MchID          string   `xml:"mch_id"`           // 是，微信支付分配的商户号
-	DeviceInfo     string   `xml:"device_info"`      // 否，自定义参数，可以为终端设备号(门店号或收银设备ID)，PC网页或公众号内支付可以传"WEB"
-	NonceStr       string   `xml:"nonce_str"`        // 是，随机字符串，长度要求在32位以内。推荐随机数生成算法
-	Sign           string   `xml:"sign"`             // 是，通过签名算法计算得出的签名值，详见签名生成算法
-	SignType       SignType `xml:"sign_type"`        // 否，通过签名算法计算得出的签名值，详见签名生成算法
-	Body           string   `xml:"body"`             // 是，通过签名算法计算得出的签名值，详见签名生成算法
-	Detail         string   `xml:"detail"`           // 否，商品详细描述，对于使用单品优惠的商户，该字段必须按照规范上传，详见“单品优惠参数说明”
-	Attach         string   `xml:"attach"`           // 否，附加数据，在查询API和支付通知中原样返回，可作为自定义参数使用。
-	OutTradeNo     string   `xml:"out_trade_no"`     // 是，商户系统内部订单号，要求32个字符内，只能是数字、大小写字母_-|*且在同一个商户号下唯一。详见商户订单号
-	FeeType        string   `xml:"fee_type"`         // 否，符合ISO 4217标准的三位字母代码，默认人民币：CNY，详细列表请参见货币类型
-	TotalFee       string   `xml:"total_fee"`        // 是，订单总金额，单位为分，详见支付金额
-	SpbillCreateIP string   `xml:"spbill_create_ip"` // 是，支持IPV4和IPV6两种格式的IP地址。调用微信支付API的机器IP
-	TimeStart      string   `xml:"time_start"`       // 否，订单生成时间，格式为yyyyMMddHHmmss，如2009年12月25日9点10分10秒表示为20091225091010。其他详见时间规则
-	TimeExpire     string   `xml:"time_expire"`
+	XMLName        xml.Name  `xml:"xml"`
+	AppID          string    `xml:"appid"`                                   // 是，微信分配的小程序ID
+	MchID          string    `xml:"mch_id" validate:"required"`              // 是，微信支付分配的商户号
+	DeviceInfo     string    `xml:"device_info"`                             // 否，自定义参数，可以为终端设备号(门店号或收银设备ID)，PC网页或公众号内支付可以传"WEB"
+	NonceStr       string    `xml:"nonce_str" validate:"required,max=32"`    // 是，随机字符串，长度要求在32位以内。推荐随机数生成算法
+	Sign           string    `xml:"sign" validate:"required"`                // 是，通过签名算法计算得出的签名值，详见签名生成算法
+	SignType       SignType  `xml:"sign_type"`                               // 否，通过签名算法计算得出的签名值，详见签名生成算法
+	Body           string    `xml:"body" validate:"required"`                // 是，通过签名算法计算得出的签名值，详见签名生成算法
+	Detail         string    `xml:"detail"`                                  // 否，商品详细描述，对于使用单品优惠的商户，该字段必须按照规范上传，详见“单品优惠参数说明”
+	Attach         string    `xml:"attach"`                                  // 否，附加数据，在查询API和支付通知中原样返回，可作为自定义参数使用。
+	OutTradeNo     string    `xml:"out_trade_no" validate:"required,max=32"` // 是，商户系统内部订单号，要求32个字符内，只能是数字、大小写字母_-|*且在同一个商户号下唯一。详见商户订单号
+	FeeType        string    `xml:"fee_type"`                                // 否，符合ISO 4217标准的三位字母代码，默认人民币：CNY，详细列表请参见货币类型
+	TotalFee       money.Fen `xml:"total_fee" validate:"required"`           // 是，订单总金额，单位为分，详见支付金额
+	SpbillCreateIP string    `xml:"spbill_create_ip" validate:"required"`    // 是，支持IPV4和IPV6两种格式的IP地址。调用微信支付API的机器IP
+	TimeStart      string    `xml:"time_start"`                              // 否，订单生成时间，格式为yyyyMMddHHmmss，如2009年12月25日9点10分10秒表示为20091225091010。其他详见时间规则
+	TimeExpire     string    `xml:"time_expire"`
 	/* 否，订单失效时间，
 	格式为yyyyMMddHHmmss，
 	如2009年12月27日9点10分10秒表示为20091227091010。
@@ -145,13 +184,13 @@ type UnifiedOrderReq struct {
 	所以在重入时间超过2小时的时候需要重新请求下单接口获取新的prepay_id。
 	其他详见时间规则 建议：最短失效时间间隔大于1分钟
 	*/
-	GoodsTag      string    `xml:"goods_tag"`  // 否，订单优惠标记，使用代金券或立减优惠功能时需要的参数，说明详见代金券或立减优惠
-	NotifyUrl     string    `xml:"notify_url"` // 是，异步接收微信支付结果通知的回调地址，通知url必须为外网可访问的url，不能携带参数。公网域名必须为https，如果是走专线接入，使用专线NAT IP或者私有回调域名可使用http。
-	TradeType     TradeType `xml:"trade_type"` // 是，小程序取值如下：JSAPI，详细说明见参数规定
-	ProductID     string    `xml:"product_id"` // 否，trade_type=NATIVE时，此参数必传。此参数为二维码中包含的商品ID，商户自行定义。
-	LimitPay      string    `xml:"limit_pay"`  // 否，上传此参数no_credit--可限制用户不能使用信用卡支付
-	OpenID        string    `xml:"openid"`     // 否，trade_type=JSAPI，此参数必传，用户在商户appid下的唯一标识。openid如何获取，可参考【获取openid】。
-	Receipt       string    `xml:"receipt"`    // 否，Y，传入Y时，支付成功消息和支付详情页将出现开票入口。需要在微信支付商户平台或微信公众平台开通电子发票功能，传此字段才可生效
+	GoodsTag      string    `xml:"goods_tag"`                      // 否，订单优惠标记，使用代金券或立减优惠功能时需要的参数，说明详见代金券或立减优惠
+	NotifyUrl     string    `xml:"notify_url" validate:"required"` // 是，异步接收微信支付结果通知的回调地址，通知url必须为外网可访问的url，不能携带参数。公网域名必须为https，如果是走专线接入，使用专线NAT IP或者私有回调域名可使用http。
+	TradeType     TradeType `xml:"trade_type" validate:"required"` // 是，小程序取值如下：JSAPI，详细说明见参数规定
+	ProductID     string    `xml:"product_id"`                     // 否，trade_type=NATIVE时，此参数必传。此参数为二维码中包含的商品ID，商户自行定义。
+	LimitPay      string    `xml:"limit_pay"`                      // 否，上传此参数no_credit--可限制用户不能使用信用卡支付
+	OpenID        string    `xml:"openid"`                         // 否，trade_type=JSAPI，此参数必传，用户在商户appid下的唯一标识。openid如何获取，可参考【获取openid】。
+	Receipt       string    `xml:"receipt"`                        // 否，Y，传入Y时，支付成功消息和支付详情页将出现开票入口。需要在微信支付商户平台或微信公众平台开通电子发票功能，传此字段才可生效
 	ProfitSharing string    `xml:"profit_sharing"`
 	/* 否，Y-是，需要分账
 	N-否，不分账
@@ -174,6 +213,26 @@ type UnifiedOrderReq struct {
 	*/
 }
 
+// Validate 校验下单的必填参数：JSAPI 支付必须传 openid，NATIVE 支付必须传 product_id
+func (req *UnifiedOrderReq) Validate() error {
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	switch req.TradeType {
+	case TradeTypeJsapi:
+		if req.OpenID == "" {
+			return &validate.FieldError{Field: "openid", Rule: "required"}
+		}
+	case TradeTypeNative:
+		if req.ProductID == "" {
+			return &validate.FieldError{Field: "product_id", Rule: "required"}
+		}
+	}
+
+	return nil
+}
+
 type unifiedOrderRes struct {
 	ReturnCode string    `xml:"return_code"`
 	ReturnMsg  string    `xml:"return_msg"`
@@ -192,13 +251,19 @@ type unifiedOrderRes struct {
 
 func (wx *Wechat) UnifiedOrder(req *UnifiedOrderReq) (*unifiedOrderRes, error) {
 	req.AppID = wx.AppID
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if !wx.hasSandboxKey(req.MchID) {
+		return nil, ErrNoSandboxKey
+	}
 
 	xmlParamsByte, xmlErr := xml.Marshal(req)
 	if xmlErr != nil {
 		return nil, xmlErr
 	}
 
-	res, httpErr := net.HttpPost(unifiedOrderUrl, string(xmlParamsByte), nil, false, "", "")
+	res, httpErr := wx.postXML(nil, unifiedOrderUrl, xmlParamsByte)
 	if httpErr != nil {
 		return nil, httpErr
 	}
@@ -228,12 +293,12 @@ type PaymentNotifyReq struct {
 	IsSubscribe        string    `xml:"is_subscribe" validate:"required"`
 	TradeType          TradeType `xml:"trade_type" validate:"required"`
 	BankType           string    `xml:"bank_type" validate:"required"`
-	TotalFee           int64     `xml:"total_fee" validate:"required"`
-	SettlementTotalFee int64     `xml:"settlement_total_fee"`
+	TotalFee           money.Fen `xml:"total_fee" validate:"required"`
+	SettlementTotalFee money.Fen `xml:"settlement_total_fee"`
 	FeeType            string    `xml:"fee_type"`
-	CashFee            int64     `xml:"cash_fee"`
+	CashFee            money.Fen `xml:"cash_fee"`
 	CashFeeType        string    `xml:"cash_fee_type"`
-	CouponFee          int64     `xml:"coupon_fee"`
+	CouponFee          money.Fen `xml:"coupon_fee"`
 	CouponCount        int64     `xml:"coupon_count"`
 	TransactionID      string    `xml:"transaction_id" validate:"required"`
 	OutTradeNo         string    `xml:"out_trade_no"  validate:"required"`
@@ -241,6 +306,48 @@ type PaymentNotifyReq struct {
 	TimeEnd            string    `xml:"time_end" validate:"required"`
 }
 
+// Validate 校验通知的必填参数，return_code 不为 SUCCESS 时通知只包含 return_code 和 return_msg
+func (req *PaymentNotifyReq) Validate() error {
+	return validateNotify(req.ReturnCode, req)
+}
+
+func validateNotify(returnCode string, req interface{}) error {
+	if returnCode == "" {
+		return &validate.FieldError{Field: "return_code", Rule: "required"}
+	}
+	if returnCode != string(NotifySuccessReturnCode) {
+		return nil
+	}
+
+	return validate.Struct(req)
+}
+
+// ErrPaymentNotifySignature 支付结果通知签名校验失败
+var ErrPaymentNotifySignature = errors.New("check payment notify signature fail")
+
+// DecodePaymentNotify 解析并校验支付结果通知，return_code 为 SUCCESS 时使用 apiKey（沙箱环境下为沙箱密钥）校验签名，
+// 签名错误时返回 ErrPaymentNotifySignature，缺少必填参数时返回 *validate.FieldError
+func (wx *Wechat) DecodePaymentNotify(body []byte, apiKey string) (*PaymentNotifyReq, error) {
+	var data PaymentNotifyReq
+	if xmlErr := xml.Unmarshal(body, &data); xmlErr != nil {
+		return nil, xmlErr
+	}
+	if data.ReturnCode == string(NotifySuccessReturnCode) {
+		sign, signErr := wx.Sign(data, apiKey)
+		if signErr != nil {
+			return nil, signErr
+		}
+		if subtle.ConstantTimeCompare([]byte(sign), []byte(data.Sign)) != 1 {
+			return nil, ErrPaymentNotifySignature
+		}
+	}
+	if err := data.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
 type PaymentNotifyCode string
 
 const (
@@ -257,22 +364,14 @@ type PaymentNotifyRes struct {
 }
 
 // ==================== 授权 ====================
-type jsCode2SessionRes struct {
-	ErrCode    float64 `json:"errcode"`
-	ErrMsg     string  `json:"errmsg"`
-	SessionKey string  `json:"session_key"`
-	OpenID     string  `json:"openid"`
-	UnionID    string  `json:"unionid"`
-}
-
-func (wx *Wechat) JsCode2Session(code string) (*jsCode2SessionRes, error) {
+func (wx *Wechat) JsCode2Session(code string) (*miniprogram.Session, error) {
 	queryParam := "?appid=" + wx.AppID + "&secret=" + wx.AppSecret + "&js_code=" + code + "&grant_type=authorization_code"
 	res, httpErr := net.HttpGet(jsCode2SessionUrl+queryParam, nil)
 	if httpErr != nil {
 		return nil, httpErr
 	}
 
-	var data jsCode2SessionRes
+	var data miniprogram.Session
 
 	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
 		return nil, jsonErr
@@ -281,26 +380,97 @@ func (wx *Wechat) JsCode2Session(code string) (*jsCode2SessionRes, error) {
 	return &data, nil
 }
 
+// ==================== 用户数据 ====================
+// CheckUserInfoSignature 校验 getUserInfo 返回的 rawData 与 signature
+func (wx *Wechat) CheckUserInfoSignature(rawData, sessionKey, signature string) error {
+	return miniprogram.CheckSignature(rawData, sessionKey, signature)
+}
+
+// DecryptPhoneNumber 解密 getPhoneNumber 返回的 encryptedData，并校验水印
+func (wx *Wechat) DecryptPhoneNumber(sessionKey, encryptedData, iv string) (*miniprogram.PhoneNumber, error) {
+	var data miniprogram.PhoneNumber
+	if err := wx.decrypt(sessionKey, encryptedData, iv, &data, &data.Watermark); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// DecryptUserInfo 解密 getUserInfo 返回的 encryptedData，并校验水印
+func (wx *Wechat) DecryptUserInfo(sessionKey, encryptedData, iv string) (*miniprogram.UserInfo, error) {
+	var data miniprogram.UserInfo
+	if err := wx.decrypt(sessionKey, encryptedData, iv, &data, &data.Watermark); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// DecryptShareInfo 解密 getShareInfo 返回的 encryptedData，并校验水印
+func (wx *Wechat) DecryptShareInfo(sessionKey, encryptedData, iv string) (*miniprogram.ShareInfo, error) {
+	var data miniprogram.ShareInfo
+	if err := wx.decrypt(sessionKey, encryptedData, iv, &data, &data.Watermark); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+func (wx *Wechat) decrypt(sessionKey, encryptedData, iv string, v interface{}, watermark *miniprogram.Watermark) error {
+	if err := miniprogram.Decrypt(sessionKey, encryptedData, iv, v); err != nil {
+		return err
+	}
+
+	return watermark.Verify(wx.AppID, wx.WatermarkMaxAge)
+}
+
+// ==================== 手机号快速验证 ====================
+type getUserPhoneNumberReq struct {
+	Code string `json:"code"`
+}
+
+type getUserPhoneNumberRes struct {
+	ErrCode   float64                 `json:"errcode"`
+	ErrMsg    string                  `json:"errmsg"`
+	PhoneInfo miniprogram.PhoneNumber `json:"phone_info"`
+}
+
+// GetUserPhoneNumber 使用手机号获取凭证 code 换取用户手机号
+func (wx *Wechat) GetUserPhoneNumber(accessToken, code string) (*getUserPhoneNumberRes, error) {
+	queryParam := "?access_token=" + accessToken
+	res, httpErr := net.HttpPost(phoneNumberUrl+queryParam, getUserPhoneNumberReq{Code: code}, nil, false, "", "")
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	var data getUserPhoneNumberRes
+	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	return &data, nil
+}
+
 // ==================== 退款 ====================
 type RefundReq struct {
 	XMLName  xml.Name `xml:"xml"`
-	AppID    string   `xml:"appid"`     // 是，微信分配的小程序ID
-	MchID    string   `xml:"mch_id"`    // 是，微信支付分配的商户号
-	NonceStr string   `xml:"nonce_str"` // 是，随机字符串，长度要求在32位以内。推荐随机数生成算法
-	Sign     string   `xml:"sign"`      // 是，通过签名算法计算得出的签名值，详见签名生成算法
-	SignType SignType `xml:"sign_type"` // 否，通过签名算法计算得出的签名值，详见签名生成算法
+	AppID    string   `xml:"appid"`                                // 是，微信分配的小程序ID
+	MchID    string   `xml:"mch_id" validate:"required"`           // 是，微信支付分配的商户号
+	NonceStr string   `xml:"nonce_str" validate:"required,max=32"` // 是，随机字符串，长度要求在32位以内。推荐随机数生成算法
+	Sign     string   `xml:"sign" validate:"required"`             // 是，通过签名算法计算得出的签名值，详见签名生成算法
+	SignType SignType `xml:"sign_type"`                            // 否，通过签名算法计算得出的签名值，详见签名生成算法
 	//TransactionID string   `xml:"transaction_id"` // 微信生成的订单号，在支付通知中有返回
-	OutTradeNo string `xml:"out_trade_no"`
+	OutTradeNo string `xml:"out_trade_no" validate:"required,max=32"`
 	/* 是，商户系统内部订单号，要求32个字符内，
 	只能是数字、大小写字母_-|*@ ，且在同一个商户号下唯一。
 	transaction_id、out_trade_no二选一，
 	如果同时存在优先级：transaction_id > out_trade_no
 	*/
-	OutRefundNo   string `xml:"out_refund_no"`   // 是，商户系统内部的退款单号，商户系统内部唯一，只能是数字、大小写字母_-|*@ ，同一退款单号多次请求只退一笔。
-	TotalFee      int64  `xml:"total_fee"`       // 是，订单总金额，单位为分，只能为整数，详见支付金额
-	RefundFee     int64  `xml:"refund_fee"`      // 是，退款总金额，订单总金额，单位为分，只能为整数，详见支付金额
-	RefundFeeType string `xml:"refund_fee_type"` // 否，货币类型，符合ISO 4217标准的三位字母代码，默认人民币：CNY，其他值列表详见货币类型
-	RefundDesc    string `xml:"refund_desc"`
+	OutRefundNo   string    `xml:"out_refund_no" validate:"required,max=64"` // 是，商户系统内部的退款单号，商户系统内部唯一，只能是数字、大小写字母_-|*@ ，同一退款单号多次请求只退一笔。
+	TotalFee      money.Fen `xml:"total_fee" validate:"required"`            // 是，订单总金额，单位为分，只能为整数，详见支付金额
+	RefundFee     money.Fen `xml:"refund_fee" validate:"required"`           // 是，退款总金额，订单总金额，单位为分，只能为整数，详见支付金额
+	RefundFeeType string    `xml:"refund_fee_type"`                          // 否，货币类型，符合ISO 4217标准的三位字母代码，默认人民币：CNY，其他值列表详见货币类型
+	RefundDesc    string    `xml:"refund_desc"`
 	/* 否，若商户传入，会在下发给用户的退款消息中体现退款原因
 	注意：若订单退款金额≤1元，且属于部分退款，则不会在退款消息中体现退款原因
 	*/
@@ -317,6 +487,18 @@ type RefundReq struct {
 	*/
 }
 
+// Validate 校验退款的必填参数，退款金额不能超过订单金额
+func (req *RefundReq) Validate() error {
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+	if req.RefundFee > req.TotalFee {
+		return &validate.FieldError{Field: "refund_fee", Rule: "max=total_fee"}
+	}
+
+	return nil
+}
+
 type refundRes struct {
 	ReturnCode PaymentNotifyCode `xml:"return_code"` //SUCCESS/FAIL
 	ReturnMsg  string            `xml:"return_msg"`
@@ -330,44 +512,52 @@ type refundRes struct {
 	SUCCESS退款申请接收成功，结果通过退款查询接口查询
 	FAIL 提交业务失败
 	*/
-	ErrCode             string `xml:"err_code"`
-	ErrCodeDes          string `xml:"err_code_des"`
-	AppID               string `xml:"appid"`
-	MchID               string `xml:"mch_id"`
-	NonceStr            string `xml:"nonce_str"`
-	Sign                string `xml:"sign"`
-	TransactionID       string `xml:"transaction_id"`
-	OutTradeNo          string `xml:"out_trade_no"`
-	OutRefundNo         string `xml:"out_refund_no"`
-	RefundID            string `xml:"refund_id"`
-	RefundFee           int64  `xml:"refund_fee"`
-	SettlementRefundFee int64  `xml:"settlement_refund_fee"`
-	TotalFee            int64  `xml:"total_fee"`
-	SettlementTotalFee  int64  `xml:"settlement_total_fee"`
-	FeeType             string `xml:"fee_type"`
-	CashFee             int64  `xml:"cash_fee"`
-	CashFeeType         string `xml:"cash_fee_type"`
-	CashRefundFee       int64  `xml:"cash_refund_fee"`
-	CouponRefundFee     int64  `xml:"coupon_refund_fee"`
-	CouponRefundCount   int64  `xml:"coupon_refund_count"`
+	ErrCode             string    `xml:"err_code"`
+	ErrCodeDes          string    `xml:"err_code_des"`
+	AppID               string    `xml:"appid"`
+	MchID               string    `xml:"mch_id"`
+	NonceStr            string    `xml:"nonce_str"`
+	Sign                string    `xml:"sign"`
+	TransactionID       string    `xml:"transaction_id"`
+	OutTradeNo          string    `xml:"out_trade_no"`
+	OutRefundNo         string    `xml:"out_refund_no"`
+	RefundID            string    `xml:"refund_id"`
+	RefundFee           money.Fen `xml:"refund_fee"`
+	SettlementRefundFee money.Fen `xml:"settlement_refund_fee"`
+	TotalFee            money.Fen `xml:"total_fee"`
+	SettlementTotalFee  money.Fen `xml:"settlement_total_fee"`
+	FeeType             string    `xml:"fee_type"`
+	CashFee             money.Fen `xml:"cash_fee"`
+	CashFeeType         string    `xml:"cash_fee_type"`
+	CashRefundFee       money.Fen `xml:"cash_refund_fee"`
+	CouponRefundFee     money.Fen `xml:"coupon_refund_fee"`
+	CouponRefundCount   int64     `xml:"coupon_refund_count"`
 }
 
 func (wx *Wechat) Refund(req *RefundReq, certKey, cert string) (*refundRes, error) {
 	req.AppID = wx.AppID
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if !wx.hasSandboxKey(req.MchID) {
+		return nil, ErrNoSandboxKey
+	}
 
 	xmlParamsByte, xmlErr := xml.Marshal(req)
 	if xmlErr != nil {
 		return nil, xmlErr
 	}
 
-	res, httpErr := net.HttpPost(refundUrl, string(xmlParamsByte), nil, true, cert, certKey)
+	client, certErr := wx.certClient(cert, certKey)
+	if certErr != nil {
+		return nil, certErr
+	}
+
+	res, httpErr := wx.postXML(client, refundUrl, xmlParamsByte)
 	if httpErr != nil {
 		return nil, httpErr
 	}
 
-	fmt.Println(string(res))
-	fmt.Println(fmt.Sprintf("%+v", string(res)))
-
 	wx.ZeroValueProcess(res)
 
 	var data refundRes
@@ -388,6 +578,24 @@ type RefundNotifyReq struct {
 	ReqInfo    string `xml:"req_info" validate:"required"`
 }
 
+// Validate 校验通知的必填参数，return_code 不为 SUCCESS 时通知只包含 return_code 和 return_msg
+func (req *RefundNotifyReq) Validate() error {
+	return validateNotify(req.ReturnCode, req)
+}
+
+// DecodeRefundNotify 解析并校验退款结果通知，req_info 使用 DecodeRefundReqInfo 解密
+func (wx *Wechat) DecodeRefundNotify(body []byte) (*RefundNotifyReq, error) {
+	var data RefundNotifyReq
+	if xmlErr := xml.Unmarshal(body, &data); xmlErr != nil {
+		return nil, xmlErr
+	}
+	if err := data.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
 type RefundStatus string
 
 const (
@@ -401,10 +609,10 @@ type refundReqInfo struct {
 	OutTradeNo          string       `xml:"out_trade_no"  validate:"required"`
 	RefundID            string       `xml:"refund_id" validate:"required"`
 	OutRefundNo         string       `xml:"out_refund_no"  validate:"required"`
-	TotalFee            int64        `xml:"total_fee" validate:"required"`
-	SettlementTotalFee  int64        `xml:"settlement_total_fee"` //当该订单有使用非充值券时，返回此字段。应结订单金额=订单金额-非充值代金券金额，应结订单金额<=订单金额。
-	RefundFee           int64        `xml:"refund_fee" validate:"required"`
-	SettlementRefundFee int64        `xml:"settlement_refund_fee" validate:"required"` //退款金额=申请退款金额-非充值代金券退款金额，退款金额<=申请退款金额
+	TotalFee            money.Fen    `xml:"total_fee" validate:"required"`
+	SettlementTotalFee  money.Fen    `xml:"settlement_total_fee"` //当该订单有使用非充值券时，返回此字段。应结订单金额=订单金额-非充值代金券金额，应结订单金额<=订单金额。
+	RefundFee           money.Fen    `xml:"refund_fee" validate:"required"`
+	SettlementRefundFee money.Fen    `xml:"settlement_refund_fee"` //退款金额=申请退款金额-非充值代金券退款金额，退款金额<=申请退款金额，全部由代金券支付的退款为0
 	RefundStatus        RefundStatus `xml:"refund_status" validate:"required"`
 	SuccessTime         string       `xml:"success_time"` //资金退款至用户账号的时间，格式2017-12-15 09:46:01
 	RefundRecvAccout    string       `xml:"refund_recv_accout" validate:"required"`
@@ -467,26 +675,22 @@ func (wx *Wechat) DecodeRefundReqInfo(reqInfo, apiKey string) (*refundReqInfo, e
 	if xmlErr := xml.Unmarshal(res, &data); xmlErr != nil {
 		return nil, xmlErr
 	}
+	if err := validate.Struct(&data); err != nil {
+		return nil, err
+	}
 
 	return &data, nil
 }
 
 // ==================== 获取access_token ====================
-type accessTokenRes struct {
-	ErrCode     float64 `json:"errcode"`
-	ErrMsg      string  `json:"errmsg"`
-	ExpiresIn   float64 `json:"expires_in"`   // 凭证有效时间，单位：秒。目前是7200秒之内的值。
-	AccessToken string  `json:"access_token"` // 获取到的凭证
-}
-
-func (wx *Wechat) GetAccessToken() (*accessTokenRes, error) {
+func (wx *Wechat) GetAccessToken() (*miniprogram.AccessToken, error) {
 	queryParam := "?grant_type=client_credential&appid=" + wx.AppID + "&secret=" + wx.AppSecret
 	res, httpErr := net.HttpGet(accessTokenUrl+queryParam, nil)
 	if httpErr != nil {
 		return nil, httpErr
 	}
 
-	var data accessTokenRes
+	var data miniprogram.AccessToken
 
 	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
 		return nil, jsonErr
@@ -495,84 +699,36 @@ func (wx *Wechat) GetAccessToken() (*accessTokenRes, error) {
 	return &data, nil
 }
 
-// ==================== 获取二维码 ====================
-type GetWxACodeUnLimitReq struct {
-	Scene string `json:"scene"`
-	/* 是
-	最大32个可见字符，只支持数字，
-	大小写英文以及部分特殊字符：!#$&'()*+,/:;=?@-._~，
-	其它字符请自行编码为合法字符（因不支持%，中文无法使用 urlencode 处理，
-	请使用其他编码方式）
-	*/
-	Page string `json:"page"`
-	/* 否
-	必须是已经发布的小程序存在的页面（否则报错），
-	例如 pages/index/index, 根路径前不要填加 /,
-	不能携带参数（参数请放在scene字段里），
-	如果不填写这个字段，默认跳主页面
-	*/
-	Width     int64 `json:"width"`      // 否 二维码的宽度，单位 px，最小 280px，最大 1280px
-	AutoColor bool  `json:"auto_color"` // 否 自动配置线条颜色，如果颜色依然是黑色，则说明不建议配置主色调，默认 false
-	LineColor Color `json:"line_color"` // 否 auto_color 为 false 时生效，使用 rgb 设置颜色 例如 {"r":"xxx","g":"xxx","b":"xxx"} 十进制表示
-	IsHyaline bool  `json:"is_hyaline"` // 否 是否需要透明底色，为 true 时，生成透明底色的小程序
-}
+// AccessToken 返回缓存的 access_token，过期前自动调用 GetAccessToken 刷新
+func (wx *Wechat) AccessToken() (string, error) {
+	wx.tokenOnce.Do(func() {
+		wx.tokenCache = miniprogram.NewTokenCache(wx)
+	})
 
-type Color struct {
-	R string `json:"r"`
-	G string `json:"g"`
-	B string `json:"b"`
+	return wx.tokenCache.Token()
 }
 
-type getWxACodeUnLimitRes struct {
-	ErrCode     float64 `json:"errcode"`
-	ErrMsg      string  `json:"errmsg"`
-	ContentType string  `json:"contentType"`
-	Buffer      []byte  `json:"buffer"`
-}
-
-func (wx *Wechat) GetWxACodeUnLimit(accessToken string, req *GetWxACodeUnLimitReq) (*getWxACodeUnLimitRes, error) {
-	queryParam := "?access_token=" + accessToken
-	res, httpErr := net.HttpPost(wxACodeUnLimitUrl+queryParam, *req, nil, false, "", "")
-	if httpErr != nil {
-		return nil, httpErr
-	}
-
-	var data getWxACodeUnLimitRes
-	// 不是json格式证明成功，返回图片buff
-	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
-		data.Buffer = res
-	}
-
-	return &data, nil
+// invalidateAccessToken 接口使用 accessToken 返回凭证失效时丢弃缓存，缓存已被刷新时不处理
+func (wx *Wechat) invalidateAccessToken(accessToken string) {
+	wx.tokenOnce.Do(func() {
+		wx.tokenCache = miniprogram.NewTokenCache(wx)
+	})
+	wx.tokenCache.InvalidateIf(accessToken)
 }
 
 // ==================== 发送订阅通知 ====================
-type SubscribeSendReq struct {
-	ToUser           string                           `json:"touser"`            // 是	接收者（用户）的 openid
-	TemplateID       string                           `json:"template_id"`       // 是	所需下发的订阅模板id
-	Page             string                           `json:"page"`              // 否	点击模板卡片后的跳转页面，仅限本小程序内的页面。支持带参数,（示例index?foo=bar）。该字段不填则模板无跳转。
-	Data             map[string]SubscribeSendDataItem `json:"data"`              // 是	模板内容，格式形如 { "key1": { "value": any }, "key2": { "value": any } }
-	MiniprogramState string                           `json:"miniprogram_state"` // 否	跳转小程序类型：developer为开发版；trial为体验版；formal为正式版；默认为正式版
-	Lang             string                           `json:"lang"`              // 否	进入小程序查看”的语言类型，支持zh_CN(简体中文)、en_US(英文)、zh_HK(繁体中文)、zh_TW(繁体中文)，默认为zh_CN
-}
+type SubscribeSendReq = miniprogram.SubscribeSendReq
 
-type SubscribeSendDataItem struct {
-	Value string `json:"value"`
-}
-
-type subscribeSendRes struct {
-	ErrCode float64 `json:"errcode"`
-	ErrMsg  string  `json:"errmsg"`
-}
+type SubscribeSendDataItem = miniprogram.SubscribeSendDataItem
 
-func (wx *Wechat) SubscribeSend(accessToken string, req *SubscribeSendReq) (*subscribeSendRes, error) {
+func (wx *Wechat) SubscribeSend(accessToken string, req *SubscribeSendReq) (*miniprogram.Result, error) {
 	queryParam := "?access_token=" + accessToken
 	res, httpErr := net.HttpPost(subscribeSendUrl+queryParam, *req, nil, false, "", "")
 	if httpErr != nil {
 		return nil, httpErr
 	}
 
-	var data subscribeSendRes
+	var data miniprogram.Result
 	if jsonErr := json.Unmarshal(res, &data); jsonErr != nil {
 		return nil, jsonErr
 	}
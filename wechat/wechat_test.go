@@ -1,10 +1,17 @@
 package wechat
 
 import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"github.com/MangoMilk/go-kit/encrypt"
 	"github.com/MangoMilk/go-sdk/aliyun"
+	"github.com/MangoMilk/go-sdk/miniprogram"
+	"github.com/MangoMilk/go-sdk/validate"
 	"strconv"
 	"testing"
 	"time"
@@ -44,7 +51,7 @@ func TestUnifiedOrder(t *testing.T) {
 		NonceStr:       nonceStr,
 		Body:           "xx",
 		OutTradeNo:     "12312",
-		TotalFee:       "1",
+		TotalFee:       1,
 		SpbillCreateIP: "127.0.0.1",
 		//TimeStart : time.Now().Format(util.SecondSeamlessDateFormat),
 		//TimeExpire : time.Now().Add(time.Minute * 16).Format(util.SecondSeamlessDateFormat),
@@ -54,7 +61,7 @@ func TestUnifiedOrder(t *testing.T) {
 	}
 
 	apiKey := ""
-	req.Sign = wx.GenSign(req, apiKey)
+	req.Sign, _ = wx.Sign(req, apiKey)
 	res, err := wx.UnifiedOrder(&req)
 	if err != nil {
 		t.Log(err)
@@ -86,7 +93,7 @@ func TestRefund(t *testing.T) {
 		//RefundDesc:"主动退款"
 	}
 
-	req.Sign = wx.GenSign(req, apiKey)
+	req.Sign, _ = wx.Sign(req, apiKey)
 	res, err := wx.Refund(&req, certKey, cert)
 	if err != nil {
 		t.Log(err)
@@ -95,10 +102,10 @@ func TestRefund(t *testing.T) {
 	t.Log(res)
 	fmt.Println(fmt.Sprintf("%+v", res))
 	fmt.Println(res.Sign)
-	fmt.Println(wx.GenSign(*res, apiKey))
+	fmt.Println(wx.Sign(*res, apiKey))
 }
 
-func TestGenSign(t *testing.T) {
+func TestSign(t *testing.T) {
 	refundXml := []byte(`<xml>
 <return_code><![CDATA[SUCCESS]]></return_code>
 <return_msg><![CDATA[OK]]></return_msg>
@@ -126,7 +133,112 @@ func TestGenSign(t *testing.T) {
 		t.Error(xmlUnmarshalErr)
 	}
 
-	fmt.Println(wx.GenSign(data, apiKey))
+	fmt.Println(wx.Sign(data, apiKey))
+}
+
+func TestPaymentNotifyValidate(t *testing.T) {
+	fail := PaymentNotifyReq{ReturnCode: "FAIL", ReturnMsg: "签名失败"}
+	if err := fail.Validate(); err != nil {
+		t.Fatalf("FAIL notify only carries return_code: %v", err)
+	}
+
+	var paid PaymentNotifyReq
+	paidXml := []byte(`<xml>
+<return_code><![CDATA[SUCCESS]]></return_code>
+<appid><![CDATA[wx2421b1c4370ec43b]]></appid>
+<mch_id><![CDATA[10000100]]></mch_id>
+<nonce_str><![CDATA[5d2b6c2a8db53831f7eda20af46e531c]]></nonce_str>
+<sign><![CDATA[B552ED6B279343CB493C5DD0D78AB241]]></sign>
+<result_code><![CDATA[SUCCESS]]></result_code>
+<openid><![CDATA[oUpF8uMEb4qRXf22hE3X68TekukE]]></openid>
+<is_subscribe><![CDATA[Y]]></is_subscribe>
+<trade_type><![CDATA[JSAPI]]></trade_type>
+<bank_type><![CDATA[CFT]]></bank_type>
+<total_fee>1</total_fee>
+<out_trade_no><![CDATA[1409811653]]></out_trade_no>
+<time_end><![CDATA[20140903131540]]></time_end>
+</xml>`)
+	if xmlErr := xml.Unmarshal(paidXml, &paid); xmlErr != nil {
+		t.Fatal(xmlErr)
+	}
+
+	var fieldErr *validate.FieldError
+	if err := paid.Validate(); !errors.As(err, &fieldErr) || fieldErr.Field != "transaction_id" {
+		t.Fatalf("want transaction_id error, got %v", err)
+	}
+
+	paid.TransactionID = "1004400740201409030005092168"
+	if err := paid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPayReqValidate(t *testing.T) {
+	order := UnifiedOrderReq{
+		MchID:          "10000100",
+		NonceStr:       "n1",
+		Sign:           "sign",
+		Body:           "test",
+		OutTradeNo:     "T0001",
+		TotalFee:       100,
+		SpbillCreateIP: "127.0.0.1",
+		NotifyUrl:      "https://example.com/notify",
+		TradeType:      TradeTypeJsapi,
+	}
+
+	var fieldErr *validate.FieldError
+	if err := order.Validate(); !errors.As(err, &fieldErr) || fieldErr.Field != "openid" {
+		t.Fatalf("want openid error, got %v", err)
+	}
+	order.OpenID = "o1"
+	if err := order.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	order.TotalFee = 0
+	if _, err := wx.UnifiedOrder(&order); !errors.As(err, &fieldErr) || fieldErr.Field != "total_fee" {
+		t.Fatalf("want total_fee error before request, got %v", err)
+	}
+
+	refund := RefundReq{
+		MchID:       "10000100",
+		NonceStr:    "n2",
+		Sign:        "sign",
+		OutTradeNo:  "T0001",
+		OutRefundNo: "R0001",
+		TotalFee:    100,
+		RefundFee:   101,
+	}
+	if _, err := wx.Refund(&refund, "", ""); !errors.As(err, &fieldErr) || fieldErr.Field != "refund_fee" {
+		t.Fatalf("want refund_fee error before request, got %v", err)
+	}
+	refund.RefundFee = 100
+	if err := refund.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecryptWatermarkMaxAge(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	iv := []byte("fedcba9876543210")
+	plaintext := fmt.Sprintf(`{"purePhoneNumber":"13800138000","watermark":{"appid":"wx1","timestamp":%d}}`, time.Now().Add(-time.Hour).Unix())
+
+	block, _ := aes.NewCipher(key)
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	data := append([]byte(plaintext), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+
+	sessionKey, encryptedData, ivStr := base64.StdEncoding.EncodeToString(key), base64.StdEncoding.EncodeToString(data), base64.StdEncoding.EncodeToString(iv)
+
+	w := NewWechat("wx1", "")
+	if _, err := w.DecryptPhoneNumber(sessionKey, encryptedData, ivStr); !errors.Is(err, miniprogram.ErrWatermarkExpired) {
+		t.Fatalf("want expired with default max age, got %v", err)
+	}
+
+	w.WatermarkMaxAge = 2 * time.Hour
+	phone, err := w.DecryptPhoneNumber(sessionKey, encryptedData, ivStr)
+	if err != nil || phone.PurePhoneNumber != "13800138000" {
+		t.Fatalf("got %+v %v", phone, err)
+	}
 }
 
 func TestGetWxACodeUnLimit(t *testing.T) {
@@ -148,7 +260,7 @@ func TestGetWxACodeUnLimit(t *testing.T) {
 	}
 	qrcodeInfo, getQrcodeErr := wx.GetWxACodeUnLimit(accessToken, &req)
 	if getQrcodeErr != nil {
-		t.Error(getQrcodeErr)
+		t.Fatal(getQrcodeErr)
 	}
 
 	//t.Log(qrcodeInfo)
@@ -0,0 +1,269 @@
+// Package wechattest 提供微信支付 v2/v3 接口的本地模拟服务，用于离线集成测试：
+// 校验请求签名、保存订单状态，并向 notify_url 发送签名（v2）或签名加密（v3）的回调通知
+package wechattest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/MangoMilk/go-sdk/money"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrOrderNotExist  = errors.New("order not exist")
+	ErrRefundNotExist = errors.New("refund not exist")
+	ErrOrderState     = errors.New("order state does not allow this operation")
+)
+
+// OrderState 订单状态，v2/v3 通用
+type OrderState string
+
+const (
+	OrderStateNotPay  = OrderState("NOTPAY")
+	OrderStateSuccess = OrderState("SUCCESS")
+	OrderStateRefund  = OrderState("REFUND")
+	OrderStateClosed  = OrderState("CLOSED")
+)
+
+// Order 模拟服务中的订单快照
+type Order struct {
+	V3            bool       // 是否为 v3 接口下单
+	SubMchID      string     // 二级商户号，仅 v3
+	OutTradeNo    string     // 商户订单号
+	TransactionID string     // 微信支付订单号，支付后生成
+	PrepayID      string     // 预支付交易会话标识
+	OpenID        string     // 支付者
+	TradeType     string     // 交易类型
+	Attach        string     // 附加数据
+	TotalFee      money.Fen  // 订单金额
+	RefundFee     money.Fen  // 已申请退款金额
+	State         OrderState // 订单状态
+	SuccessTime   time.Time  // 支付完成时间
+	ProfitSharing bool       // 是否分账，仅 v3
+	UnsplitAmount money.Fen  // 剩余待分账金额，仅 v3
+	NotifyUrl     string     // 支付结果回调地址
+	Sandbox       bool       // 是否在 /sandboxnew 下单，支付结果回调使用沙箱密钥签名
+}
+
+// Refund 模拟服务中的退款单快照
+type Refund struct {
+	V3          bool
+	OutTradeNo  string
+	OutRefundNo string
+	RefundID    string
+	RefundFee   money.Fen
+	Status      string // PROCESSING、SUCCESS
+	CreateTime  time.Time
+	SuccessTime time.Time
+	NotifyUrl   string
+}
+
+type balance struct {
+	available money.Fen
+	pending   money.Fen
+}
+
+type Server struct {
+	*httptest.Server
+
+	AppID      string // 小程序/服务商 appid
+	MchID      string // 商户号/服务商户号
+	ApiKey     string // v2 API密钥
+	SandboxKey string // 沙箱密钥，由 getsignkey 返回，/sandboxnew 下的 v2 请求需使用该密钥签名
+	ApiV3Key   string // APIv3 密钥，32 字节
+	NotifyUrl  string // 请求未携带 notify_url 时使用的回调地址
+
+	MchPublicKey     *rsa.PublicKey  // 商户API证书公钥，设置后校验 v3 请求的 Authorization 签名
+	PlatformKey      *rsa.PrivateKey // 平台证书私钥，用于 v3 应答和回调签名
+	PlatformSerialNo string          // 平台证书序列号
+	NotifyClient     *http.Client    // 发送回调使用的客户端
+
+	mu        sync.Mutex
+	seq       int
+	orders    map[string]*Order // out_trade_no
+	refunds   map[string]*Refund
+	sharings  map[string]*profitSharing // out_order_no
+	withdraws map[string]*withdraw      // out_request_no
+	balances  map[string]*balance       // sub_mchid，服务商自身使用 MchID
+}
+
+// NewServer 创建并启动模拟服务，使用 Close 关闭
+func NewServer(appID, mchID, apiKey, apiV3Key string) *Server {
+	platformKey, keyErr := rsa.GenerateKey(rand.Reader, 2048)
+	if keyErr != nil {
+		panic(keyErr)
+	}
+	sandboxKey := make([]byte, 16)
+	if _, randErr := rand.Read(sandboxKey); randErr != nil {
+		panic(randErr)
+	}
+
+	s := &Server{
+		AppID:            appID,
+		MchID:            mchID,
+		ApiKey:           apiKey,
+		SandboxKey:       hex.EncodeToString(sandboxKey),
+		ApiV3Key:         apiV3Key,
+		PlatformKey:      platformKey,
+		PlatformSerialNo: "5157F09EFDC096DE15EBE81A47057A7232F1B8E1",
+		NotifyClient:     &http.Client{Timeout: 10 * time.Second},
+		orders:           make(map[string]*Order),
+		refunds:          make(map[string]*Refund),
+		sharings:         make(map[string]*profitSharing),
+		withdraws:        make(map[string]*withdraw),
+		balances:         make(map[string]*balance),
+	}
+	s.Server = httptest.NewServer(s)
+
+	return s
+}
+
+// PlatformPublicKey 平台证书公钥，用于验证应答和回调签名
+func (s *Server) PlatformPublicKey() *rsa.PublicKey {
+	return &s.PlatformKey.PublicKey
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/sandboxnew")
+	if strings.HasPrefix(path, "/v3/") {
+		s.serveV3(w, r)
+		return
+	}
+
+	switch path {
+	case "/pay/unifiedorder":
+		s.unifiedOrder(w, r)
+	case "/secapi/pay/refund", "/pay/refund":
+		s.refundV2(w, r)
+	case "/pay/getsignkey":
+		s.getSignKey(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) nextID(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s%s%08d", prefix, time.Now().Format("20060102"), s.seq)
+}
+
+func (s *Server) balance(mchID string) *balance {
+	b, ok := s.balances[mchID]
+	if !ok {
+		b = &balance{}
+		s.balances[mchID] = b
+	}
+
+	return b
+}
+
+// SetBalance 设置二级商户（或服务商 MchID）的可用余额，用于提现等场景
+func (s *Server) SetBalance(mchID string, available money.Fen) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.balance(mchID).available = available
+}
+
+// Order 返回订单快照
+func (s *Server) Order(outTradeNo string) (Order, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[outTradeNo]
+	if !ok {
+		return Order{}, false
+	}
+
+	return *order, true
+}
+
+// Refund 返回退款单快照
+func (s *Server) Refund(outRefundNo string) (Refund, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refund, ok := s.refunds[outRefundNo]
+	if !ok {
+		return Refund{}, false
+	}
+
+	return *refund, true
+}
+
+// Pay 模拟用户完成支付，订单转为 SUCCESS 并同步发送支付成功回调，返回回调的错误
+func (s *Server) Pay(outTradeNo, openID string) error {
+	s.mu.Lock()
+	order, ok := s.orders[outTradeNo]
+	if !ok {
+		s.mu.Unlock()
+		return ErrOrderNotExist
+	}
+	if order.State != OrderStateNotPay {
+		s.mu.Unlock()
+		return ErrOrderState
+	}
+
+	order.State = OrderStateSuccess
+	order.TransactionID = s.nextID("4200")
+	order.SuccessTime = time.Now()
+	if openID != "" {
+		order.OpenID = openID
+	}
+	if order.V3 {
+		b := s.balance(order.SubMchID)
+		if order.ProfitSharing {
+			order.UnsplitAmount = order.TotalFee
+			b.pending += order.TotalFee
+		} else {
+			b.available += order.TotalFee
+		}
+	}
+	snapshot := *order
+	s.mu.Unlock()
+
+	if snapshot.NotifyUrl == "" {
+		return nil
+	}
+	if snapshot.V3 {
+		return s.notifyV3(snapshot.NotifyUrl, "TRANSACTION.SUCCESS", "transaction", "支付成功", s.transactionJSON(&snapshot))
+	}
+
+	return s.notifyPaymentV2(&snapshot)
+}
+
+// CompleteRefund 模拟退款到账，退款单转为 SUCCESS 并同步发送退款结果回调，返回回调的错误
+func (s *Server) CompleteRefund(outRefundNo string) error {
+	s.mu.Lock()
+	refund, ok := s.refunds[outRefundNo]
+	if !ok {
+		s.mu.Unlock()
+		return ErrRefundNotExist
+	}
+	if refund.Status != "PROCESSING" {
+		s.mu.Unlock()
+		return ErrOrderState
+	}
+
+	refund.Status = "SUCCESS"
+	refund.SuccessTime = time.Now()
+	refundSnapshot := *refund
+	orderSnapshot := *s.orders[refund.OutTradeNo]
+	s.mu.Unlock()
+
+	if refundSnapshot.NotifyUrl == "" {
+		return nil
+	}
+	if refundSnapshot.V3 {
+		return s.notifyV3(refundSnapshot.NotifyUrl, "REFUND.SUCCESS", "refund", "退款成功", refundNotifyJSON(s.MchID, &orderSnapshot, &refundSnapshot))
+	}
+
+	return s.notifyRefundV2(&orderSnapshot, &refundSnapshot)
+}
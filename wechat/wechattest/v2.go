@@ -0,0 +1,384 @@
+package wechattest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"github.com/MangoMilk/go-kit/encrypt"
+	"github.com/MangoMilk/go-sdk/money"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==================== v2 xml 与签名 ====================
+func parseXML(body []byte) (map[string]string, error) {
+	params := make(map[string]string)
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	var key string
+	var depth int
+	for {
+		token, tokenErr := decoder.Token()
+		if tokenErr == io.EOF {
+			return params, nil
+		}
+		if tokenErr != nil {
+			return nil, tokenErr
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				key = t.Name.Local
+				params[key] = ""
+			}
+		case xml.CharData:
+			if depth == 2 {
+				params[key] += string(t)
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+func encodeXML(root string, params map[string]string) []byte {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString("<" + root + ">")
+	for _, k := range keys {
+		buf.WriteString("<" + k + ">")
+		xml.EscapeText(&buf, []byte(params[k]))
+		buf.WriteString("</" + k + ">")
+	}
+	buf.WriteString("</" + root + ">")
+
+	return buf.Bytes()
+}
+
+// signV2 参数按 key 升序以 k=v& 拼接（忽略空值和 sign），末尾拼接 key=apiKey 后取 md5 并转大写
+func signV2(params map[string]string, apiKey string) string {
+	var keys []string
+	for k, v := range params {
+		if v != "" && k != "sign" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var signStr strings.Builder
+	for _, k := range keys {
+		signStr.WriteString(k + "=" + params[k] + "&")
+	}
+	signStr.WriteString("key=" + apiKey)
+
+	sum := md5.Sum([]byte(signStr.String()))
+
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// keyV2 /sandboxnew 下的请求使用沙箱密钥签名
+func (s *Server) keyV2(r *http.Request) string {
+	if strings.HasPrefix(r.URL.Path, "/sandboxnew/") {
+		return s.SandboxKey
+	}
+
+	return s.ApiKey
+}
+
+func (s *Server) writeV2(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	params["return_code"] = "SUCCESS"
+	params["appid"] = s.AppID
+	params["mch_id"] = s.MchID
+	params["nonce_str"] = s.nextNonce()
+	params["sign"] = signV2(params, s.keyV2(r))
+
+	w.Header().Set("Content-Type", "text/xml")
+	w.Write(encodeXML("xml", params))
+}
+
+func (s *Server) failV2(w http.ResponseWriter, returnMsg string) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.Write(encodeXML("xml", map[string]string{"return_code": "FAIL", "return_msg": returnMsg}))
+}
+
+func (s *Server) errV2(w http.ResponseWriter, r *http.Request, errCode, errCodeDes string) {
+	s.writeV2(w, r, map[string]string{"result_code": "FAIL", "err_code": errCode, "err_code_des": errCodeDes})
+}
+
+func (s *Server) nextNonce() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
+
+// readV2 解析请求并校验 appid、mch_id 和签名（/sandboxnew 下使用沙箱密钥），失败时已写入应答
+func (s *Server) readV2(w http.ResponseWriter, r *http.Request, required ...string) (map[string]string, bool) {
+	body, readErr := ioutil.ReadAll(r.Body)
+	if readErr != nil {
+		s.failV2(w, readErr.Error())
+		return nil, false
+	}
+
+	params, xmlErr := parseXML(body)
+	if xmlErr != nil {
+		s.failV2(w, "XML格式错误")
+		return nil, false
+	}
+	if params["sign"] == "" || params["sign"] != signV2(params, s.keyV2(r)) {
+		s.failV2(w, "签名错误")
+		return nil, false
+	}
+	if params["appid"] != s.AppID || params["mch_id"] != s.MchID {
+		s.failV2(w, "appid和mch_id不匹配")
+		return nil, false
+	}
+	for _, k := range required {
+		if params[k] == "" {
+			s.failV2(w, "缺少参数"+k)
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// ==================== v2 统一下单 ====================
+func (s *Server) unifiedOrder(w http.ResponseWriter, r *http.Request) {
+	params, ok := s.readV2(w, r, "nonce_str", "body", "out_trade_no", "total_fee", "spbill_create_ip", "trade_type")
+	if !ok {
+		return
+	}
+
+	totalFee, feeErr := strconv.ParseInt(params["total_fee"], 10, 64)
+	if feeErr != nil || totalFee <= 0 {
+		s.failV2(w, "total_fee参数错误")
+		return
+	}
+	notifyUrl := params["notify_url"]
+	if notifyUrl == "" {
+		notifyUrl = s.NotifyUrl
+	}
+
+	s.mu.Lock()
+	order, exist := s.orders[params["out_trade_no"]]
+	if exist && order.State != OrderStateNotPay {
+		s.mu.Unlock()
+		s.errV2(w, r, "ORDERPAID", "该订单已支付")
+		return
+	}
+	if exist && order.TotalFee != money.Fen(totalFee) {
+		s.mu.Unlock()
+		s.errV2(w, r, "INVALID_REQUEST", "201 商户订单号重复")
+		return
+	}
+	if !exist {
+		order = &Order{
+			OutTradeNo: params["out_trade_no"],
+			PrepayID:   s.nextID("wx"),
+			OpenID:     params["openid"],
+			TradeType:  params["trade_type"],
+			Attach:     params["attach"],
+			TotalFee:   money.Fen(totalFee),
+			State:      OrderStateNotPay,
+			NotifyUrl:  notifyUrl,
+			Sandbox:    strings.HasPrefix(r.URL.Path, "/sandboxnew/"),
+		}
+		s.orders[order.OutTradeNo] = order
+	}
+	prepayID := order.PrepayID
+	s.mu.Unlock()
+
+	s.writeV2(w, r, map[string]string{
+		"result_code": "SUCCESS",
+		"trade_type":  params["trade_type"],
+		"prepay_id":   prepayID,
+	})
+}
+
+// ==================== v2 退款 ====================
+func (s *Server) refundV2(w http.ResponseWriter, r *http.Request) {
+	params, ok := s.readV2(w, r, "nonce_str", "out_refund_no", "total_fee", "refund_fee")
+	if !ok {
+		return
+	}
+
+	totalFee, _ := strconv.ParseInt(params["total_fee"], 10, 64)
+	refundFee, feeErr := strconv.ParseInt(params["refund_fee"], 10, 64)
+	if feeErr != nil || refundFee <= 0 {
+		s.failV2(w, "refund_fee参数错误")
+		return
+	}
+	notifyUrl := params["notify_url"]
+	if notifyUrl == "" {
+		notifyUrl = s.NotifyUrl
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order := s.orders[params["out_trade_no"]]
+	if order == nil && params["transaction_id"] != "" {
+		order = s.orderByTransactionID(params["transaction_id"])
+	}
+	if order == nil || order.V3 {
+		s.errV2(w, r, "ORDERNOTEXIST", "订单不存在")
+		return
+	}
+
+	refund, exist := s.refunds[params["out_refund_no"]]
+	if !exist {
+		if order.State != OrderStateSuccess && order.State != OrderStateRefund {
+			s.errV2(w, r, "TRADE_STATE_ERROR", "订单状态错误")
+			return
+		}
+		if money.Fen(totalFee) != order.TotalFee {
+			s.errV2(w, r, "INVALID_REQUEST", "订单金额或退款金额与之前请求不一致")
+			return
+		}
+		if order.RefundFee+money.Fen(refundFee) > order.TotalFee {
+			s.errV2(w, r, "NOTENOUGH", "订单可退金额不足")
+			return
+		}
+
+		refund = &Refund{
+			OutTradeNo:  order.OutTradeNo,
+			OutRefundNo: params["out_refund_no"],
+			RefundID:    s.nextID("5030"),
+			RefundFee:   money.Fen(refundFee),
+			Status:      "PROCESSING",
+			CreateTime:  time.Now(),
+			NotifyUrl:   notifyUrl,
+		}
+		s.refunds[refund.OutRefundNo] = refund
+		order.RefundFee += refund.RefundFee
+		order.State = OrderStateRefund
+	}
+
+	s.writeV2(w, r, map[string]string{
+		"result_code":    "SUCCESS",
+		"transaction_id": order.TransactionID,
+		"out_trade_no":   order.OutTradeNo,
+		"out_refund_no":  refund.OutRefundNo,
+		"refund_id":      refund.RefundID,
+		"refund_fee":     strconv.FormatInt(int64(refund.RefundFee), 10),
+		"total_fee":      strconv.FormatInt(int64(order.TotalFee), 10),
+		"cash_fee":       strconv.FormatInt(int64(order.TotalFee), 10),
+	})
+}
+
+func (s *Server) orderByTransactionID(transactionID string) *Order {
+	for _, order := range s.orders {
+		if order.TransactionID == transactionID {
+			return order
+		}
+	}
+
+	return nil
+}
+
+// ==================== 沙箱密钥 ====================
+// getSignKey 校验使用 ApiKey 生成的签名并返回 SandboxKey
+func (s *Server) getSignKey(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	params, xmlErr := parseXML(body)
+	if xmlErr != nil || params["sign"] != signV2(params, s.ApiKey) || params["mch_id"] != s.MchID {
+		s.failV2(w, "签名错误")
+		return
+	}
+
+	s.writeV2(w, r, map[string]string{"sandbox_signkey": s.SandboxKey})
+}
+
+// ==================== v2 回调 ====================
+func (s *Server) notifyPaymentV2(order *Order) error {
+	params := map[string]string{
+		"return_code":    "SUCCESS",
+		"result_code":    "SUCCESS",
+		"appid":          s.AppID,
+		"mch_id":         s.MchID,
+		"nonce_str":      s.nextNonce(),
+		"openid":         order.OpenID,
+		"is_subscribe":   "N",
+		"trade_type":     order.TradeType,
+		"bank_type":      "OTHERS",
+		"total_fee":      strconv.FormatInt(int64(order.TotalFee), 10),
+		"cash_fee":       strconv.FormatInt(int64(order.TotalFee), 10),
+		"transaction_id": order.TransactionID,
+		"out_trade_no":   order.OutTradeNo,
+		"attach":         order.Attach,
+		"time_end":       order.SuccessTime.Format("20060102150405"),
+	}
+	key := s.ApiKey
+	if order.Sandbox {
+		key = s.SandboxKey
+	}
+	params["sign"] = signV2(params, key)
+
+	return s.postNotifyV2(order.NotifyUrl, encodeXML("xml", params))
+}
+
+// notifyRefundV2 退款结果通知，req_info 为 AES-256-ECB(key=md5(ApiKey)) 加密的退款信息
+func (s *Server) notifyRefundV2(order *Order, refund *Refund) error {
+	reqInfo := encodeXML("root", map[string]string{
+		"transaction_id":        order.TransactionID,
+		"out_trade_no":          order.OutTradeNo,
+		"refund_id":             refund.RefundID,
+		"out_refund_no":         refund.OutRefundNo,
+		"total_fee":             strconv.FormatInt(int64(order.TotalFee), 10),
+		"refund_fee":            strconv.FormatInt(int64(refund.RefundFee), 10),
+		"settlement_refund_fee": strconv.FormatInt(int64(refund.RefundFee), 10),
+		"refund_status":         "SUCCESS",
+		"success_time":          refund.SuccessTime.Format("2006-01-02 15:04:05"),
+		"refund_recv_accout":    "支付用户零钱",
+		"refund_account":        "REFUND_SOURCE_RECHARGE_FUNDS",
+		"refund_request_source": "API",
+	})
+
+	secret, md5Err := encrypt.MD5(s.ApiKey)
+	if md5Err != nil {
+		return md5Err
+	}
+	ciphertext, aesErr := encrypt.NewAES(encrypt.ECB).Encrypt(reqInfo, secret)
+	if aesErr != nil {
+		return aesErr
+	}
+
+	return s.postNotifyV2(refund.NotifyUrl, encodeXML("xml", map[string]string{
+		"return_code": "SUCCESS",
+		"appid":       s.AppID,
+		"mch_id":      s.MchID,
+		"nonce_str":   s.nextNonce(),
+		"req_info":    base64.StdEncoding.EncodeToString(ciphertext),
+	}))
+}
+
+func (s *Server) postNotifyV2(notifyUrl string, body []byte) error {
+	resp, httpErr := s.NotifyClient.Post(notifyUrl, "text/xml", bytes.NewReader(body))
+	if httpErr != nil {
+		return httpErr
+	}
+	defer resp.Body.Close()
+
+	res, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		return readErr
+	}
+	params, _ := parseXML(res)
+	if resp.StatusCode != http.StatusOK || params["return_code"] != "SUCCESS" {
+		return fmt.Errorf("notify %s fail: http status %d, body %s", notifyUrl, resp.StatusCode, res)
+	}
+
+	return nil
+}
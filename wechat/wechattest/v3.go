@@ -0,0 +1,735 @@
+package wechattest
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/MangoMilk/go-sdk/money"
+	"github.com/MangoMilk/go-sdk/wechat/ecommerce"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type profitSharing struct {
+	SubMchID          string
+	TransactionID     string
+	OutOrderNo        string
+	OrderID           string
+	Status            string
+	Receivers         []map[string]interface{}
+	FinishAmount      money.Fen
+	FinishDescription string
+}
+
+type withdraw struct {
+	SubMchID     string
+	WithdrawID   string
+	OutRequestNo string
+	Amount       money.Fen
+	Status       string
+	Remark       string
+	BankMemo     string
+	AccountType  string
+	CreateTime   time.Time
+}
+
+// ==================== v3 签名 ====================
+func (s *Server) sign(message []byte) string {
+	hashed := sha256.Sum256(message)
+	signature, signErr := rsa.SignPKCS1v15(rand.Reader, s.PlatformKey, crypto.SHA256, hashed[:])
+	if signErr != nil {
+		panic(signErr)
+	}
+
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+// signHeader 设置 Wechatpay-* 签名头
+func (s *Server) signHeader(header http.Header, body []byte) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := randomString(32)
+
+	header.Set("Wechatpay-Timestamp", timestamp)
+	header.Set("Wechatpay-Nonce", nonce)
+	header.Set("Wechatpay-Serial", s.PlatformSerialNo)
+	header.Set("Wechatpay-Signature", s.sign(ecommerce.SignMessage(timestamp, nonce, string(body))))
+}
+
+// verifyAuthorization 校验 Authorization 头：WECHATPAY2-SHA256-RSA2048 mchid="",nonce_str="",signature="",timestamp="",serial_no=""
+func (s *Server) verifyAuthorization(r *http.Request, body []byte) bool {
+	if s.MchPublicKey == nil {
+		return true
+	}
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, ecommerce.AuthorizationSchema+" ") {
+		return false
+	}
+
+	fields := make(map[string]string)
+	for _, kv := range strings.Split(strings.TrimPrefix(auth, ecommerce.AuthorizationSchema+" "), ",") {
+		if i := strings.Index(kv, "="); i > 0 {
+			fields[strings.TrimSpace(kv[:i])] = strings.Trim(kv[i+1:], `"`)
+		}
+	}
+	if fields["mchid"] != s.MchID {
+		return false
+	}
+
+	signature, decodeErr := base64.StdEncoding.DecodeString(fields["signature"])
+	if decodeErr != nil {
+		return false
+	}
+	hashed := sha256.Sum256(ecommerce.SignMessage(r.Method, r.URL.RequestURI(), fields["timestamp"], fields["nonce_str"], string(body)))
+
+	return rsa.VerifyPKCS1v15(s.MchPublicKey, crypto.SHA256, hashed[:], signature) == nil
+}
+
+func (s *Server) writeV3(w http.ResponseWriter, status int, v interface{}) {
+	body, _ := json.Marshal(v)
+	s.signHeader(w.Header(), body)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func (s *Server) errV3(w http.ResponseWriter, status int, code, message string) {
+	s.writeV3(w, status, map[string]string{"code": code, "message": message})
+}
+
+func randomString(n int) string {
+	b := make([]byte, (n+1)/2)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)[:n]
+}
+
+func rfc3339(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// ==================== v3 路由 ====================
+func (s *Server) serveV3(w http.ResponseWriter, r *http.Request) {
+	body, readErr := ioutil.ReadAll(r.Body)
+	if readErr != nil {
+		s.errV3(w, http.StatusBadRequest, "PARAM_ERROR", readErr.Error())
+		return
+	}
+	if !s.verifyAuthorization(r, body) {
+		s.errV3(w, http.StatusUnauthorized, "SIGN_ERROR", "签名错误")
+		return
+	}
+
+	path := r.URL.Path
+	query := r.URL.Query()
+	post := r.Method == http.MethodPost
+	switch {
+	case post && path == "/v3/pay/partner/transactions/jsapi":
+		s.jsapi(w, body)
+	case !post && strings.HasPrefix(path, "/v3/pay/partner/transactions/out-trade-no/"):
+		s.queryOrderV3(w, query.Get("sub_mchid"), func(order *Order) bool {
+			return order.OutTradeNo == strings.TrimPrefix(path, "/v3/pay/partner/transactions/out-trade-no/")
+		})
+	case !post && strings.HasPrefix(path, "/v3/pay/partner/transactions/id/"):
+		s.queryOrderV3(w, query.Get("sub_mchid"), func(order *Order) bool {
+			return order.TransactionID == strings.TrimPrefix(path, "/v3/pay/partner/transactions/id/")
+		})
+	case post && path == "/v3/ecommerce/refunds/apply":
+		s.refundV3(w, body)
+	case !post && strings.HasPrefix(path, "/v3/ecommerce/refunds/id/"):
+		s.queryRefundV3(w, func(refund *Refund) bool {
+			return refund.RefundID == strings.TrimPrefix(path, "/v3/ecommerce/refunds/id/")
+		})
+	case !post && strings.HasPrefix(path, "/v3/ecommerce/refunds/out-refund-no/"):
+		s.queryRefundV3(w, func(refund *Refund) bool {
+			return refund.OutRefundNo == strings.TrimPrefix(path, "/v3/ecommerce/refunds/out-refund-no/")
+		})
+	case post && path == "/v3/ecommerce/profitsharing/orders":
+		s.profitSharing(w, body)
+	case !post && path == "/v3/ecommerce/profitsharing/orders":
+		s.queryProfitSharing(w, query.Get("out_order_no"))
+	case !post && strings.HasPrefix(path, "/v3/ecommerce/profitsharing/orders/") && strings.HasSuffix(path, "/amounts"):
+		s.profitSharingAmounts(w, strings.TrimSuffix(strings.TrimPrefix(path, "/v3/ecommerce/profitsharing/orders/"), "/amounts"))
+	case post && path == "/v3/ecommerce/profitsharing/finish-order":
+		s.finishProfitSharing(w, body)
+	case !post && strings.HasPrefix(path, "/v3/ecommerce/fund/balance/"):
+		s.subMchBalance(w, strings.TrimPrefix(path, "/v3/ecommerce/fund/balance/"), query.Get("account_type"))
+	case !post && strings.HasPrefix(path, "/v3/ecommerce/fund/enddaybalance/"):
+		s.subMchBalance(w, strings.TrimPrefix(path, "/v3/ecommerce/fund/enddaybalance/"), "")
+	case !post && (strings.HasPrefix(path, "/v3/merchant/fund/balance/") || strings.HasPrefix(path, "/v3/merchant/fund/dayendbalance/")):
+		s.merchantBalance(w)
+	case post && path == "/v3/ecommerce/fund/withdraw":
+		s.withdraw(w, body)
+	case !post && strings.HasPrefix(path, "/v3/ecommerce/fund/withdraw/out-request-no/"):
+		s.queryWithdraw(w, func(wd *withdraw) bool {
+			return wd.OutRequestNo == strings.TrimPrefix(path, "/v3/ecommerce/fund/withdraw/out-request-no/")
+		})
+	case !post && strings.HasPrefix(path, "/v3/ecommerce/fund/withdraw/"):
+		s.queryWithdraw(w, func(wd *withdraw) bool {
+			return wd.WithdrawID == strings.TrimPrefix(path, "/v3/ecommerce/fund/withdraw/")
+		})
+	default:
+		s.errV3(w, http.StatusNotFound, "NOT_FOUND", "接口不存在")
+	}
+}
+
+// ==================== v3 下单与查单 ====================
+func (s *Server) jsapi(w http.ResponseWriter, body []byte) {
+	var req ecommerce.MiniProgramPayReq
+	if jsonErr := json.Unmarshal(body, &req); jsonErr != nil {
+		s.errV3(w, http.StatusBadRequest, "PARAM_ERROR", jsonErr.Error())
+		return
+	}
+	if req.SpMchID != s.MchID || req.SpAppID != s.AppID {
+		s.errV3(w, http.StatusBadRequest, "APPID_MCHID_NOT_MATCH", "服务商appid和mchid不匹配")
+		return
+	}
+	if req.SubMchID == "" || req.Description == "" || req.OutTradeNo == "" || req.NotifyUrl == "" || req.Amount.Total <= 0 {
+		s.errV3(w, http.StatusBadRequest, "PARAM_ERROR", "缺少必填参数或金额错误")
+		return
+	}
+
+	s.mu.Lock()
+	order, exist := s.orders[req.OutTradeNo]
+	if exist && (!order.V3 || order.State != OrderStateNotPay) {
+		s.mu.Unlock()
+		s.errV3(w, http.StatusBadRequest, "ORDERPAID", "该订单已支付或商户订单号重复")
+		return
+	}
+	if !exist {
+		order = &Order{
+			V3:            true,
+			SubMchID:      req.SubMchID,
+			OutTradeNo:    req.OutTradeNo,
+			PrepayID:      s.nextID("wx"),
+			OpenID:        req.Payer.SpOpenid,
+			TradeType:     string(ecommerce.TradeTypeJsapi),
+			Attach:        req.Attach,
+			TotalFee:      req.Amount.Total,
+			State:         OrderStateNotPay,
+			ProfitSharing: req.SettleInfo.ProfitSharing,
+			NotifyUrl:     req.NotifyUrl,
+		}
+		if order.OpenID == "" {
+			order.OpenID = req.Payer.SubOpenid
+		}
+		s.orders[order.OutTradeNo] = order
+	}
+	prepayID := order.PrepayID
+	s.mu.Unlock()
+
+	s.writeV3(w, http.StatusOK, map[string]string{"prepay_id": prepayID})
+}
+
+func (s *Server) transactionJSON(order *Order) map[string]interface{} {
+	return map[string]interface{}{
+		"sp_appid":         s.AppID,
+		"sp_mchid":         s.MchID,
+		"sub_mchid":        order.SubMchID,
+		"out_trade_no":     order.OutTradeNo,
+		"transaction_id":   order.TransactionID,
+		"trade_type":       order.TradeType,
+		"trade_state":      order.State,
+		"trade_state_desc": string(order.State),
+		"attach":           order.Attach,
+		"success_time":     rfc3339(order.SuccessTime),
+		"payer":            map[string]string{"sp_openid": order.OpenID},
+		"amount": map[string]interface{}{
+			"total":          order.TotalFee,
+			"currency":       "CNY",
+			"payer_total":    order.TotalFee,
+			"payer_currency": "CNY",
+		},
+	}
+}
+
+func (s *Server) queryOrderV3(w http.ResponseWriter, subMchID string, match func(*Order) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, order := range s.orders {
+		if order.V3 && order.SubMchID == subMchID && match(order) {
+			s.writeV3(w, http.StatusOK, s.transactionJSON(order))
+			return
+		}
+	}
+
+	s.errV3(w, http.StatusNotFound, "ORDER_NOT_EXIST", "订单不存在")
+}
+
+// ==================== v3 退款 ====================
+func (s *Server) refundV3(w http.ResponseWriter, body []byte) {
+	var req ecommerce.RefundReq
+	if jsonErr := json.Unmarshal(body, &req); jsonErr != nil {
+		s.errV3(w, http.StatusBadRequest, "PARAM_ERROR", jsonErr.Error())
+		return
+	}
+	if req.SubMchID == "" || req.OutRefundNo == "" || req.Amount.Refund <= 0 {
+		s.errV3(w, http.StatusBadRequest, "PARAM_ERROR", "缺少必填参数或金额错误")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order := s.orders[req.OutTradeNo]
+	if order == nil && req.TransactionID != "" {
+		order = s.orderByTransactionID(req.TransactionID)
+	}
+	if order == nil || !order.V3 || order.SubMchID != req.SubMchID {
+		s.errV3(w, http.StatusNotFound, "RESOURCE_NOT_EXISTS", "订单不存在")
+		return
+	}
+
+	refund, exist := s.refunds[req.OutRefundNo]
+	if !exist {
+		if order.State != OrderStateSuccess && order.State != OrderStateRefund {
+			s.errV3(w, http.StatusForbidden, "TRADE_STATE_ERROR", "订单状态错误")
+			return
+		}
+		if req.Amount.Total != order.TotalFee {
+			s.errV3(w, http.StatusBadRequest, "PARAM_ERROR", "订单金额与原订单不一致")
+			return
+		}
+		if order.RefundFee+req.Amount.Refund > order.TotalFee {
+			s.errV3(w, http.StatusForbidden, "NOT_ENOUGH", "订单可退金额不足")
+			return
+		}
+
+		b := s.balance(order.SubMchID)
+		if order.UnsplitAmount >= req.Amount.Refund {
+			order.UnsplitAmount -= req.Amount.Refund
+			b.pending -= req.Amount.Refund
+		} else if b.available >= req.Amount.Refund {
+			b.available -= req.Amount.Refund
+		} else {
+			s.errV3(w, http.StatusForbidden, "NOT_ENOUGH", "二级商户余额不足")
+			return
+		}
+
+		refund = &Refund{
+			V3:          true,
+			OutTradeNo:  order.OutTradeNo,
+			OutRefundNo: req.OutRefundNo,
+			RefundID:    s.nextID("5030"),
+			RefundFee:   req.Amount.Refund,
+			Status:      "PROCESSING",
+			CreateTime:  time.Now(),
+			NotifyUrl:   req.NotifyUrl,
+		}
+		if refund.NotifyUrl == "" {
+			refund.NotifyUrl = s.NotifyUrl
+		}
+		s.refunds[refund.OutRefundNo] = refund
+		order.RefundFee += refund.RefundFee
+		order.State = OrderStateRefund
+	}
+
+	s.writeV3(w, http.StatusOK, map[string]interface{}{
+		"refund_id":     refund.RefundID,
+		"out_refund_no": refund.OutRefundNo,
+		"create_time":   rfc3339(refund.CreateTime),
+		"amount":        refundAmountJSON(order, refund),
+	})
+}
+
+func refundAmountJSON(order *Order, refund *Refund) map[string]interface{} {
+	return map[string]interface{}{
+		"total":           order.TotalFee,
+		"refund":          refund.RefundFee,
+		"payer_total":     order.TotalFee,
+		"payer_refund":    refund.RefundFee,
+		"discount_refund": 0,
+		"currency":        "CNY",
+	}
+}
+
+func (s *Server) queryRefundV3(w http.ResponseWriter, match func(*Refund) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, refund := range s.refunds {
+		if refund.V3 && match(refund) {
+			order := s.orders[refund.OutTradeNo]
+			s.writeV3(w, http.StatusOK, map[string]interface{}{
+				"refund_id":             refund.RefundID,
+				"out_refund_no":         refund.OutRefundNo,
+				"transaction_id":        order.TransactionID,
+				"out_trade_no":          order.OutTradeNo,
+				"channel":               "ORIGINAL",
+				"user_received_account": "支付用户零钱",
+				"success_time":          rfc3339(refund.SuccessTime),
+				"create_time":           rfc3339(refund.CreateTime),
+				"status":                refund.Status,
+				"amount":                refundAmountJSON(order, refund),
+			})
+			return
+		}
+	}
+
+	s.errV3(w, http.StatusNotFound, "RESOURCE_NOT_EXISTS", "退款单不存在")
+}
+
+func refundNotifyJSON(spMchID string, order *Order, refund *Refund) map[string]interface{} {
+	return map[string]interface{}{
+		"sp_mchid":              spMchID,
+		"sub_mchid":             order.SubMchID,
+		"out_trade_no":          order.OutTradeNo,
+		"transaction_id":        order.TransactionID,
+		"refund_id":             refund.RefundID,
+		"out_refund_no":         refund.OutRefundNo,
+		"refund_status":         refund.Status,
+		"success_time":          rfc3339(refund.SuccessTime),
+		"user_received_account": "支付用户零钱",
+		"amount":                refundAmountJSON(order, refund),
+	}
+}
+
+// ==================== v3 分账 ====================
+func (s *Server) profitSharingJSON(ps *profitSharing) map[string]interface{} {
+	return map[string]interface{}{
+		"sub_mchid":          ps.SubMchID,
+		"transaction_id":     ps.TransactionID,
+		"out_order_no":       ps.OutOrderNo,
+		"order_id":           ps.OrderID,
+		"status":             ps.Status,
+		"receivers":          ps.Receivers,
+		"finish_amount":      ps.FinishAmount,
+		"finish_description": ps.FinishDescription,
+	}
+}
+
+// unfreeze 完结分账，剩余待分账金额解冻给二级商户
+func (s *Server) unfreeze(order *Order) money.Fen {
+	rest := order.UnsplitAmount
+	b := s.balance(order.SubMchID)
+	b.pending -= rest
+	b.available += rest
+	order.UnsplitAmount = 0
+
+	return rest
+}
+
+func (s *Server) profitSharing(w http.ResponseWriter, body []byte) {
+	var req ecommerce.ProfitSharingReq
+	if jsonErr := json.Unmarshal(body, &req); jsonErr != nil {
+		s.errV3(w, http.StatusBadRequest, "PARAM_ERROR", jsonErr.Error())
+		return
+	}
+	if req.SubMchID == "" || req.TransactionID == "" || req.OutOrderNo == "" || len(req.Receivers) == 0 || len(req.Receivers) > 5 {
+		s.errV3(w, http.StatusBadRequest, "PARAM_ERROR", "缺少必填参数或分账接收方数量错误")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ps, exist := s.sharings[req.OutOrderNo]; exist {
+		s.writeV3(w, http.StatusOK, s.profitSharingJSON(ps))
+		return
+	}
+
+	order := s.orderByTransactionID(req.TransactionID)
+	if order == nil || !order.V3 || order.SubMchID != req.SubMchID {
+		s.errV3(w, http.StatusNotFound, "RESOURCE_NOT_EXISTS", "订单不存在")
+		return
+	}
+	if !order.ProfitSharing {
+		s.errV3(w, http.StatusForbidden, "INVALID_REQUEST", "订单未指定分账")
+		return
+	}
+
+	var total money.Fen
+	for _, receiver := range req.Receivers {
+		if receiver.Amount <= 0 {
+			s.errV3(w, http.StatusBadRequest, "PARAM_ERROR", "分账金额错误")
+			return
+		}
+		total += receiver.Amount
+	}
+	if total > order.UnsplitAmount {
+		s.errV3(w, http.StatusForbidden, "NOT_ENOUGH", "分账金额超出剩余待分账金额")
+		return
+	}
+
+	ps := &profitSharing{
+		SubMchID:      req.SubMchID,
+		TransactionID: req.TransactionID,
+		OutOrderNo:    req.OutOrderNo,
+		OrderID:       s.nextID("3008"),
+		Status:        string(ecommerce.ProfitSharingStatusFinished),
+	}
+	order.UnsplitAmount -= total
+	s.balance(order.SubMchID).pending -= total
+	for _, receiver := range req.Receivers {
+		receiverMchID := ""
+		if receiver.Type == ecommerce.ReceiverTypeMerchantID {
+			receiverMchID = receiver.ReceiverAccount
+			s.balance(receiverMchID).available += receiver.Amount
+		}
+		ps.Receivers = append(ps.Receivers, map[string]interface{}{
+			"type":             receiver.Type,
+			"receiver_account": receiver.ReceiverAccount,
+			"receiver_mchid":   receiverMchID,
+			"amount":           receiver.Amount,
+			"description":      receiver.Description,
+			"result":           ecommerce.ProfitSharingResultSuccess,
+			"detail_id":        s.nextID("3601"),
+			"finish_time":      rfc3339(time.Now()),
+		})
+	}
+	if req.Finish {
+		s.unfreeze(order)
+	}
+	s.sharings[ps.OutOrderNo] = ps
+
+	s.writeV3(w, http.StatusOK, s.profitSharingJSON(ps))
+}
+
+func (s *Server) queryProfitSharing(w http.ResponseWriter, outOrderNo string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ps, exist := s.sharings[outOrderNo]
+	if !exist {
+		s.errV3(w, http.StatusNotFound, "RESOURCE_NOT_EXISTS", "分账单不存在")
+		return
+	}
+
+	s.writeV3(w, http.StatusOK, s.profitSharingJSON(ps))
+}
+
+func (s *Server) profitSharingAmounts(w http.ResponseWriter, transactionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order := s.orderByTransactionID(transactionID)
+	if order == nil || !order.V3 {
+		s.errV3(w, http.StatusNotFound, "RESOURCE_NOT_EXISTS", "订单不存在")
+		return
+	}
+
+	s.writeV3(w, http.StatusOK, map[string]interface{}{"transaction_id": transactionID, "unsplit_amount": order.UnsplitAmount})
+}
+
+func (s *Server) finishProfitSharing(w http.ResponseWriter, body []byte) {
+	var req ecommerce.FinishProfitSharingReq
+	if jsonErr := json.Unmarshal(body, &req); jsonErr != nil {
+		s.errV3(w, http.StatusBadRequest, "PARAM_ERROR", jsonErr.Error())
+		return
+	}
+	if req.SubMchID == "" || req.TransactionID == "" || req.OutOrderNo == "" || req.Description == "" {
+		s.errV3(w, http.StatusBadRequest, "PARAM_ERROR", "缺少必填参数")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ps, exist := s.sharings[req.OutOrderNo]
+	if !exist {
+		order := s.orderByTransactionID(req.TransactionID)
+		if order == nil || !order.V3 || order.SubMchID != req.SubMchID {
+			s.errV3(w, http.StatusNotFound, "RESOURCE_NOT_EXISTS", "订单不存在")
+			return
+		}
+
+		ps = &profitSharing{
+			SubMchID:          req.SubMchID,
+			TransactionID:     req.TransactionID,
+			OutOrderNo:        req.OutOrderNo,
+			OrderID:           s.nextID("3008"),
+			Status:            string(ecommerce.ProfitSharingStatusFinished),
+			FinishAmount:      s.unfreeze(order),
+			FinishDescription: req.Description,
+		}
+		s.sharings[ps.OutOrderNo] = ps
+	}
+
+	s.writeV3(w, http.StatusOK, map[string]interface{}{
+		"sub_mchid":      ps.SubMchID,
+		"transaction_id": ps.TransactionID,
+		"out_order_no":   ps.OutOrderNo,
+		"order_id":       ps.OrderID,
+	})
+}
+
+// ==================== v3 余额与提现 ====================
+func (s *Server) subMchBalance(w http.ResponseWriter, subMchID, accountType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.balance(subMchID)
+	if accountType == "" {
+		accountType = string(ecommerce.AccountTypeBasic)
+	}
+
+	s.writeV3(w, http.StatusOK, map[string]interface{}{
+		"sub_mchid":        subMchID,
+		"account_type":     accountType,
+		"available_amount": b.available,
+		"pending_amount":   b.pending,
+	})
+}
+
+func (s *Server) merchantBalance(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.balance(s.MchID)
+	s.writeV3(w, http.StatusOK, map[string]interface{}{"available_amount": b.available, "pending_amount": b.pending})
+}
+
+func (s *Server) withdrawJSON(wd *withdraw) map[string]interface{} {
+	return map[string]interface{}{
+		"sp_mchid":       s.MchID,
+		"sub_mchid":      wd.SubMchID,
+		"withdraw_id":    wd.WithdrawID,
+		"out_request_no": wd.OutRequestNo,
+		"amount":         wd.Amount,
+		"status":         wd.Status,
+		"create_time":    rfc3339(wd.CreateTime),
+		"update_time":    rfc3339(wd.CreateTime),
+		"remark":         wd.Remark,
+		"bank_memo":      wd.BankMemo,
+		"account_type":   wd.AccountType,
+		"account_number": "0403",
+		"account_bank":   "招商银行",
+	}
+}
+
+func (s *Server) withdraw(w http.ResponseWriter, body []byte) {
+	var req ecommerce.WithdrawReq
+	if jsonErr := json.Unmarshal(body, &req); jsonErr != nil {
+		s.errV3(w, http.StatusBadRequest, "PARAM_ERROR", jsonErr.Error())
+		return
+	}
+	if req.SubMchID == "" || req.OutRequestNo == "" || req.Amount <= 0 {
+		s.errV3(w, http.StatusBadRequest, "PARAM_ERROR", "缺少必填参数或金额错误")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wd, exist := s.withdraws[req.OutRequestNo]
+	if !exist {
+		b := s.balance(req.SubMchID)
+		if b.available < req.Amount {
+			s.errV3(w, http.StatusForbidden, "NOT_ENOUGH", "可用余额不足")
+			return
+		}
+		b.available -= req.Amount
+
+		wd = &withdraw{
+			SubMchID:     req.SubMchID,
+			WithdrawID:   s.nextID("1232"),
+			OutRequestNo: req.OutRequestNo,
+			Amount:       req.Amount,
+			Status:       string(ecommerce.WithdrawStatusSuccess),
+			Remark:       req.Remark,
+			BankMemo:     req.BankMemo,
+			AccountType:  string(req.AccountType),
+			CreateTime:   time.Now(),
+		}
+		s.withdraws[wd.OutRequestNo] = wd
+	}
+
+	s.writeV3(w, http.StatusOK, map[string]string{
+		"sub_mchid":      wd.SubMchID,
+		"withdraw_id":    wd.WithdrawID,
+		"out_request_no": wd.OutRequestNo,
+	})
+}
+
+func (s *Server) queryWithdraw(w http.ResponseWriter, match func(*withdraw) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, wd := range s.withdraws {
+		if match(wd) {
+			s.writeV3(w, http.StatusOK, s.withdrawJSON(wd))
+			return
+		}
+	}
+
+	s.errV3(w, http.StatusNotFound, "RESOURCE_NOT_EXISTS", "提现单不存在")
+}
+
+// ==================== v3 回调 ====================
+// encryptResource 使用 APIv3 密钥以 AEAD_AES_256_GCM 加密通知资源
+func (s *Server) encryptResource(plaintext []byte, associatedData string) (map[string]string, error) {
+	block, aesErr := aes.NewCipher([]byte(s.ApiV3Key))
+	if aesErr != nil {
+		return nil, aesErr
+	}
+	gcm, gcmErr := cipher.NewGCM(block)
+	if gcmErr != nil {
+		return nil, gcmErr
+	}
+
+	nonce := randomString(gcm.NonceSize())
+	ciphertext := gcm.Seal(nil, []byte(nonce), plaintext, []byte(associatedData))
+
+	return map[string]string{
+		"algorithm":       ecommerce.AlgorithmAEADAES256GCM,
+		"ciphertext":      base64.StdEncoding.EncodeToString(ciphertext),
+		"associated_data": associatedData,
+		"original_type":   associatedData,
+		"nonce":           nonce,
+	}, nil
+}
+
+func (s *Server) notifyV3(notifyUrl, eventType, associatedData, summary string, data map[string]interface{}) error {
+	plaintext, jsonErr := json.Marshal(data)
+	if jsonErr != nil {
+		return jsonErr
+	}
+	resource, encryptErr := s.encryptResource(plaintext, associatedData)
+	if encryptErr != nil {
+		return encryptErr
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"id":            "EV-" + randomString(16),
+		"create_time":   rfc3339(time.Now()),
+		"event_type":    eventType,
+		"resource_type": "encrypt-resource",
+		"resource":      resource,
+		"summary":       summary,
+	})
+
+	req, reqErr := http.NewRequest(http.MethodPost, notifyUrl, strings.NewReader(string(body)))
+	if reqErr != nil {
+		return reqErr
+	}
+	req.Header.Set("Content-Type", "application/json")
+	s.signHeader(req.Header, body)
+
+	resp, httpErr := s.NotifyClient.Do(req)
+	if httpErr != nil {
+		return httpErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		res, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("notify %s fail: http status %d, body %s", notifyUrl, resp.StatusCode, res)
+	}
+
+	return nil
+}
@@ -0,0 +1,299 @@
+package wechattest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"github.com/MangoMilk/go-sdk/mchcert"
+	"github.com/MangoMilk/go-sdk/wechat"
+	"github.com/MangoMilk/go-sdk/wechat/ecommerce"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	appID    = "wx8888888888888888"
+	mchID    = "1230000109"
+	subMchID = "1900000109"
+	apiKey   = "192006250b4c09247ec02edce69f6a2d"
+	apiV3Key = "0123456789abcdef0123456789abcdef"
+)
+
+// notifyRecorder 记录回调请求体和请求头
+type notifyRecorder struct {
+	*httptest.Server
+	bodies  [][]byte
+	headers []http.Header
+	reply   []byte
+}
+
+func newNotifyRecorder(reply string) *notifyRecorder {
+	n := &notifyRecorder{reply: []byte(reply)}
+	n.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		n.bodies = append(n.bodies, body)
+		n.headers = append(n.headers, r.Header)
+		w.Write(n.reply)
+	}))
+
+	return n
+}
+
+func TestV2PayAndRefund(t *testing.T) {
+	srv := NewServer(appID, mchID, apiKey, apiV3Key)
+	defer srv.Close()
+	notify := newNotifyRecorder(`<xml><return_code>SUCCESS</return_code><return_msg>OK</return_msg></xml>`)
+	defer notify.Close()
+
+	wx := wechat.NewWechat(appID, "")
+	wx.MchBaseUrl = srv.URL
+	wx.Cert = mchcert.New(tls.Certificate{})
+
+	order := wechat.UnifiedOrderReq{
+		MchID:          mchID,
+		NonceStr:       "n1",
+		Body:           "test",
+		OutTradeNo:     "T0001",
+		TotalFee:       100,
+		SpbillCreateIP: "127.0.0.1",
+		NotifyUrl:      notify.URL,
+		TradeType:      wechat.TradeTypeJsapi,
+		OpenID:         "o1",
+	}
+	sign := func(body interface{}, key string) string {
+		s, err := wx.Sign(body, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	order.Sign = sign(order, "wrong")
+	if res, err := wx.UnifiedOrder(&order); err != nil || res.ReturnCode != "FAIL" {
+		t.Fatalf("wrong sign should fail, got %+v %v", res, err)
+	}
+
+	order.Sign = sign(order, apiKey)
+	res, err := wx.UnifiedOrder(&order)
+	if err != nil || res.ResultCode != "SUCCESS" || res.PrepayID == "" {
+		t.Fatalf("unified order %+v %v", res, err)
+	}
+
+	if err := srv.Pay("T0001", ""); err != nil {
+		t.Fatal(err)
+	}
+	paid, err := wx.DecodePaymentNotify(notify.bodies[0], apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paid.OutTradeNo != "T0001" || paid.TotalFee != 100 {
+		t.Fatalf("payment notify %+v", paid)
+	}
+	if _, err := wx.DecodePaymentNotify(notify.bodies[0], "wrong key"); err != wechat.ErrPaymentNotifySignature {
+		t.Fatalf("payment notify with wrong key: %v", err)
+	}
+
+	refund := wechat.RefundReq{
+		MchID:       mchID,
+		NonceStr:    "n2",
+		OutTradeNo:  "T0001",
+		OutRefundNo: "R0001",
+		TotalFee:    100,
+		RefundFee:   60,
+		NotifyUrl:   notify.URL,
+	}
+	refund.Sign = sign(refund, apiKey)
+	refundRes, err := wx.Refund(&refund, "", "")
+	if err != nil || refundRes.ResultCode != "SUCCESS" || refundRes.RefundID == "" {
+		t.Fatalf("refund %+v %v", refundRes, err)
+	}
+
+	over := refund
+	over.OutRefundNo, over.RefundFee = "R0002", 50
+	over.Sign = sign(over, apiKey)
+	if overRes, _ := wx.Refund(&over, "", ""); overRes == nil || overRes.ErrCode != "NOTENOUGH" {
+		t.Fatalf("refund over total should fail, got %+v", overRes)
+	}
+
+	if err := srv.CompleteRefund("R0001"); err != nil {
+		t.Fatal(err)
+	}
+	refundNotify, err := wx.DecodeRefundNotify(notify.bodies[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := wx.DecodeRefundReqInfo(refundNotify.ReqInfo, apiKey)
+	if err != nil || info.OutRefundNo != "R0001" || info.RefundFee != 60 || info.RefundStatus != wechat.RefundStatusSuccess {
+		t.Fatalf("refund notify %+v %v", info, err)
+	}
+}
+
+func TestV2Sandbox(t *testing.T) {
+	srv := NewServer(appID, mchID, apiKey, apiV3Key)
+	defer srv.Close()
+	notify := newNotifyRecorder(`<xml><return_code>SUCCESS</return_code><return_msg>OK</return_msg></xml>`)
+	defer notify.Close()
+
+	online := wechat.NewWechat(appID, "")
+	online.MchBaseUrl = srv.URL
+	wx := wechat.NewWechat(appID, "")
+	wx.MchBaseUrl = srv.URL
+	if err := wx.UseSandbox(mchID, apiKey); err != nil {
+		t.Fatal(err)
+	}
+
+	order := wechat.UnifiedOrderReq{
+		MchID:          mchID,
+		NonceStr:       "n1",
+		Body:           "test",
+		OutTradeNo:     "S0001",
+		TotalFee:       100,
+		SpbillCreateIP: "127.0.0.1",
+		NotifyUrl:      notify.URL,
+		TradeType:      wechat.TradeTypeJsapi,
+		OpenID:         "o1",
+	}
+	order.Sign, _ = online.Sign(order, apiKey)
+	if res, err := wx.UnifiedOrder(&order); err != nil || res.ReturnCode != "FAIL" {
+		t.Fatalf("sandbox order signed with ApiKey should fail, got %+v %v", res, err)
+	}
+
+	sign, signErr := wx.Sign(order, apiKey)
+	if signErr != nil {
+		t.Fatal(signErr)
+	}
+	order.Sign = sign
+	res, err := wx.UnifiedOrder(&order)
+	if err != nil || res.ResultCode != "SUCCESS" {
+		t.Fatalf("sandbox unified order %+v %v", res, err)
+	}
+
+	if err := srv.Pay("S0001", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wx.DecodePaymentNotify(notify.bodies[0], apiKey); err != nil {
+		t.Fatalf("sandbox payment notify: %v", err)
+	}
+	if _, err := online.DecodePaymentNotify(notify.bodies[0], apiKey); err != wechat.ErrPaymentNotifySignature {
+		t.Fatalf("sandbox payment notify checked with ApiKey: %v", err)
+	}
+}
+
+func TestV3PaymentFlow(t *testing.T) {
+	srv := NewServer(appID, mchID, apiKey, apiV3Key)
+	defer srv.Close()
+	notify := newNotifyRecorder(`{"code":"SUCCESS","message":"OK"}`)
+	defer notify.Close()
+
+	mchKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	srv.MchPublicKey = &mchKey.PublicKey
+
+	client := ecommerce.NewClient(nil)
+	client.BaseUrl = srv.URL
+	if _, err := client.QueryBalance(subMchID, ecommerce.AccountTypeBasic); err != ecommerce.ErrNoCredential {
+		t.Fatalf("got %v, want ErrNoCredential", err)
+	}
+
+	var apiErr *ecommerce.Error
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	client.Credential = &ecommerce.Credential{MchID: mchID, SerialNo: "1DDE55AD98ED71D6EDD4A4A16996DE7B47773A8C", PrivateKey: otherKey}
+	if _, err := client.QueryBalance(subMchID, ecommerce.AccountTypeBasic); !errors.As(err, &apiErr) || apiErr.Code != "SIGN_ERROR" {
+		t.Fatalf("wrongly signed request should fail, got %v", err)
+	}
+	client.Credential.PrivateKey = mchKey
+
+	client.PlatformPublicKey = &otherKey.PublicKey
+	if _, err := client.QueryBalance(subMchID, ecommerce.AccountTypeBasic); err != ecommerce.ErrSignature {
+		t.Fatalf("response signed by another platform key: got %v, want ErrSignature", err)
+	}
+	client.PlatformPublicKey = srv.PlatformPublicKey()
+
+	pay := ecommerce.MiniProgramPayReq{
+		SpAppID:     appID,
+		SpMchID:     mchID,
+		SubMchID:    subMchID,
+		Description: "test",
+		OutTradeNo:  "T1217752501",
+		NotifyUrl:   notify.URL,
+	}
+	pay.SettleInfo.ProfitSharing = true
+	pay.Amount.Total = 1000
+	pay.Payer.SpOpenid = "o1"
+	payRes, err := client.MiniProgramPay(&pay)
+	if err != nil || payRes.PrepayID == "" {
+		t.Fatalf("jsapi %+v %v", payRes, err)
+	}
+
+	if err := srv.Pay(pay.OutTradeNo, ""); err != nil {
+		t.Fatal(err)
+	}
+	header := notify.headers[0]
+	if err := ecommerce.VerifySignature(srv.PlatformPublicKey(), header.Get("Wechatpay-Timestamp"), header.Get("Wechatpay-Nonce"), notify.bodies[0], header.Get("Wechatpay-Signature")); err != nil {
+		t.Fatal(err)
+	}
+	var paid ecommerce.NotifyReq
+	json.Unmarshal(notify.bodies[0], &paid)
+	transaction, err := paid.DecodeTransaction(apiV3Key)
+	if err != nil || paid.EventType != ecommerce.EventTypeTransactionSuccess || transaction.TradeState != ecommerce.TradeStateSuccess || transaction.Amount.Total != 1000 {
+		t.Fatalf("transaction notify %+v %v", transaction, err)
+	}
+
+	detail, err := client.QueryOrderByOutTradeNo(mchID, subMchID, pay.OutTradeNo)
+	if err != nil || detail.TransactionID != transaction.TransactionID {
+		t.Fatalf("query order %+v %v", detail, err)
+	}
+
+	sharing := ecommerce.ProfitSharingReq{
+		AppID:         appID,
+		SubMchID:      subMchID,
+		TransactionID: transaction.TransactionID,
+		OutOrderNo:    "P20150806125346",
+		Receivers: []ecommerce.ProfitSharingReceiver{
+			{Type: ecommerce.ReceiverTypeMerchantID, ReceiverAccount: mchID, Amount: 100, Description: "平台抽成"},
+		},
+	}
+	if res, err := client.ProfitSharing(&sharing); err != nil || res.Status != ecommerce.ProfitSharingStatusFinished {
+		t.Fatalf("profit sharing %+v %v", res, err)
+	}
+	if amounts, err := client.QueryProfitSharingOrderAmounts(transaction.TransactionID); err != nil || amounts.UnSplitAmount != 900 {
+		t.Fatalf("unsplit amounts %+v %v", amounts, err)
+	}
+
+	finish := ecommerce.FinishProfitSharingReq{SubMchID: subMchID, TransactionID: transaction.TransactionID, OutOrderNo: "P20150806125347", Description: "分账完结"}
+	if _, err := client.FinishProfitSharing(&finish); err != nil {
+		t.Fatal(err)
+	}
+	if balance, err := client.QueryBalance(subMchID, ecommerce.AccountTypeBasic); err != nil || balance.AvailableAmount != 900 || balance.PendingAmount != 0 {
+		t.Fatalf("balance %+v %v", balance, err)
+	}
+
+	refund := ecommerce.RefundReq{SubMchID: subMchID, OutTradeNo: pay.OutTradeNo, OutRefundNo: "R1217752501", NotifyUrl: notify.URL}
+	refund.Amount.Total, refund.Amount.Refund, refund.Amount.Currency = 1000, 300, "CNY"
+	if res, err := client.Refund(&refund); err != nil || res.RefundID == "" {
+		t.Fatalf("refund %+v %v", res, err)
+	}
+	if err := srv.CompleteRefund(refund.OutRefundNo); err != nil {
+		t.Fatal(err)
+	}
+	var refunded ecommerce.NotifyReq
+	json.Unmarshal(notify.bodies[1], &refunded)
+	if info, err := refunded.DecodeRefund(apiV3Key); err != nil || info.RefundStatus != ecommerce.RefundStatusSuccess || info.Amount.Refund != 300 {
+		t.Fatalf("refund notify %+v %v", info, err)
+	}
+
+	withdraw := ecommerce.WithdrawReq{SubMchID: subMchID, OutRequestNo: "W20190611", Amount: 700}
+	if _, err := client.Withdraw(&withdraw); !errors.As(err, &apiErr) || apiErr.Code != "NOT_ENOUGH" {
+		t.Fatalf("withdraw over balance should fail, got %v", err)
+	}
+	withdraw.Amount = 600
+	if _, err := client.Withdraw(&withdraw); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := client.QueryWithdrawByOutRequestNo(withdraw.OutRequestNo, subMchID); err != nil || res.Status != ecommerce.WithdrawStatusSuccess || res.Amount != 600 {
+		t.Fatalf("query withdraw %+v %v", res, err)
+	}
+}
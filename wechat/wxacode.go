@@ -0,0 +1,212 @@
+package wechat
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/MangoMilk/go-sdk/miniprogram"
+	"io"
+	"mime"
+	"strings"
+)
+
+const (
+	wxACodeUrl        = "https://api.weixin.qq.com/wxa/getwxacode"
+	wxACodeUnLimitUrl = "https://api.weixin.qq.com/wxa/getwxacodeunlimit"
+	wxAQrcodeUrl      = "https://api.weixin.qq.com/cgi-bin/wxaapp/createwxaqrcode"
+	generateSchemeUrl = "https://api.weixin.qq.com/wxa/generatescheme"
+	generateUrlLink   = "https://api.weixin.qq.com/wxa/generate_urllink"
+	generateShortLink = "https://api.weixin.qq.com/wxa/genwxashortlink"
+)
+
+// ==================== 获取二维码 ====================
+// Image 二维码/小程序码图片
+type Image struct {
+	ContentType string // 图片 MIME 类型，如 image/jpeg
+	Buffer      []byte
+}
+
+func (img *Image) Reader() io.Reader {
+	return bytes.NewReader(img.Buffer)
+}
+
+// postImage 接口成功时返回图片，失败时返回 json 格式的错误信息，以 Content-Type 区分
+func (wx *Wechat) postImage(api, accessToken string, req interface{}) (*Image, error) {
+	contentType, body, httpErr := postJSON(api+"?access_token="+accessToken, req)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if strings.HasPrefix(mediaType, "image/") {
+		return &Image{ContentType: mediaType, Buffer: body}, nil
+	}
+
+	if jsonErr := decodeJSON(body, &miniprogram.Result{}); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	return nil, fmt.Errorf("unexpected content type %q", contentType)
+}
+
+type Color struct {
+	R uint8 `json:"r"`
+	G uint8 `json:"g"`
+	B uint8 `json:"b"`
+}
+
+// EnvVersion 要打开的小程序版本
+type EnvVersion string
+
+const (
+	EnvVersionRelease EnvVersion = "release" // 正式版
+	EnvVersionTrial   EnvVersion = "trial"   // 体验版
+	EnvVersionDevelop EnvVersion = "develop" // 开发版
+)
+
+type GetWxACodeReq struct {
+	Path       string     `json:"path"`                  // 是 扫码进入的小程序页面路径，最大长度 1024 个字符，可携带参数
+	EnvVersion EnvVersion `json:"env_version,omitempty"` // 否 要打开的小程序版本，默认 release
+	Width      int64      `json:"width,omitempty"`       // 否 二维码的宽度，单位 px，最小 280px，最大 1280px，默认 430
+	AutoColor  bool       `json:"auto_color"`            // 否 自动配置线条颜色，默认 false
+	LineColor  *Color     `json:"line_color,omitempty"`  // 否 auto_color 为 false 时生效，使用 rgb 设置颜色，十进制表示
+	IsHyaline  bool       `json:"is_hyaline"`            // 否 是否需要透明底色，默认 false
+}
+
+// GetWxACode 获取小程序码，适用于需要的码数量较少的业务场景，与 createwxaqrcode 总共生成的码数量限制为 100,000
+func (wx *Wechat) GetWxACode(accessToken string, req *GetWxACodeReq) (*Image, error) {
+	return wx.postImage(wxACodeUrl, accessToken, req)
+}
+
+type GetWxACodeUnLimitReq struct {
+	Scene string `json:"scene"`
+	/* 是
+	最大32个可见字符，只支持数字，
+	大小写英文以及部分特殊字符：!#$&'()*+,/:;=?@-._~，
+	其它字符请自行编码为合法字符（因不支持%，中文无法使用 urlencode 处理，
+	请使用其他编码方式）
+	*/
+	Page string `json:"page"`
+	/* 否
+	必须是已经发布的小程序存在的页面（否则报错），
+	例如 pages/index/index, 根路径前不要填加 /,
+	不能携带参数（参数请放在scene字段里），
+	如果不填写这个字段，默认跳主页面
+	*/
+	CheckPath  *bool      `json:"check_path,omitempty"`  // 否 检查 page 是否存在，默认 true，为 false 时允许小程序未发布或者 page 不存在
+	EnvVersion EnvVersion `json:"env_version,omitempty"` // 否 要打开的小程序版本，默认 release
+	Width      int64      `json:"width,omitempty"`       // 否 二维码的宽度，单位 px，最小 280px，最大 1280px
+	AutoColor  bool       `json:"auto_color"`            // 否 自动配置线条颜色，如果颜色依然是黑色，则说明不建议配置主色调，默认 false
+	LineColor  *Color     `json:"line_color,omitempty"`  // 否 auto_color 为 false 时生效，使用 rgb 设置颜色，十进制表示
+	IsHyaline  bool       `json:"is_hyaline"`            // 否 是否需要透明底色，为 true 时，生成透明底色的小程序
+}
+
+// GetWxACodeUnLimit 获取小程序码，适用于需要的码数量极多的业务场景，生成的码永久有效，数量暂无限制
+func (wx *Wechat) GetWxACodeUnLimit(accessToken string, req *GetWxACodeUnLimitReq) (*Image, error) {
+	return wx.postImage(wxACodeUnLimitUrl, accessToken, req)
+}
+
+type CreateWxAQrcodeReq struct {
+	Path  string `json:"path"`            // 是 扫码进入的小程序页面路径，最大长度 128 字节，不能为空
+	Width int64  `json:"width,omitempty"` // 否 二维码的宽度，单位 px，最小 280px，最大 1280px，默认 430
+}
+
+// CreateWxAQrcode 获取小程序二维码，适用于需要的码数量较少的业务场景
+func (wx *Wechat) CreateWxAQrcode(accessToken string, req *CreateWxAQrcodeReq) (*Image, error) {
+	return wx.postImage(wxAQrcodeUrl, accessToken, req)
+}
+
+// ==================== URL Scheme/URL Link/Short Link ====================
+// ExpireType 失效类型
+type ExpireType int
+
+const (
+	ExpireTypeTime     ExpireType = 0 // 指定失效时间
+	ExpireTypeInterval ExpireType = 1 // 指定失效间隔天数
+)
+
+type JumpWxa struct {
+	Path       string     `json:"path"`                  // 通过 scheme 码进入的小程序页面路径，必须是已经发布的小程序存在的页面，不可携带 query，path 为空时会跳转小程序主页
+	Query      string     `json:"query"`                 // 通过 scheme 码进入小程序时的 query，最大1024个字符
+	EnvVersion EnvVersion `json:"env_version,omitempty"` // 要打开的小程序版本，默认 release
+}
+
+type GenerateSchemeReq struct {
+	JumpWxa        *JumpWxa   `json:"jump_wxa,omitempty"`        // 否 跳转到的目标小程序信息
+	IsExpire       bool       `json:"is_expire"`                 // 否 到期失效：true，永久有效：false
+	ExpireType     ExpireType `json:"expire_type"`               // 否 到期失效的 scheme 码失效类型
+	ExpireTime     int64      `json:"expire_time,omitempty"`     // 否 到期失效的 scheme 码的失效时间，为 Unix 时间戳，最长有效期为30天
+	ExpireInterval int64      `json:"expire_interval,omitempty"` // 否 到期失效的 scheme 码的失效间隔天数，最长间隔天数为30天
+}
+
+type generateSchemeRes struct {
+	ErrCode  float64 `json:"errcode"`
+	ErrMsg   string  `json:"errmsg"`
+	OpenLink string  `json:"openlink"` // 生成的小程序 scheme 码
+}
+
+// GenerateScheme 获取小程序 scheme 码，适用于短信、邮件、外部网页、微信内等拉起小程序的业务场景
+func (wx *Wechat) GenerateScheme(accessToken string, req *GenerateSchemeReq) (*generateSchemeRes, error) {
+	var data generateSchemeRes
+	if err := wx.postJSON(generateSchemeUrl, accessToken, req, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+type CloudBase struct {
+	Env           string `json:"env"`                      // 是 云开发环境
+	Domain        string `json:"domain,omitempty"`         // 否 静态网站自定义域名，不填则使用默认域名
+	Path          string `json:"path,omitempty"`           // 否 云开发静态网站 H5 页面路径，不可携带 query，默认 /
+	Query         string `json:"query,omitempty"`          // 否 云开发静态网站 H5 页面 query 参数
+	ResourceAppID string `json:"resource_appid,omitempty"` // 否 第三方批量代云开发时必填，表示创建该 env 的 appid
+}
+
+type GenerateUrlLinkReq struct {
+	Path           string     `json:"path"`                      // 否 通过 URL Link 进入的小程序页面路径，必须是已经发布的小程序存在的页面，不可携带 query
+	Query          string     `json:"query"`                     // 否 通过 URL Link 进入小程序时的 query，最大1024个字符
+	EnvVersion     EnvVersion `json:"env_version,omitempty"`     // 否 要打开的小程序版本，默认 release
+	IsExpire       bool       `json:"is_expire"`                 // 否 到期失效：true，永久有效：false
+	ExpireType     ExpireType `json:"expire_type"`               // 否 小程序 URL Link 失效类型
+	ExpireTime     int64      `json:"expire_time,omitempty"`     // 否 到期失效的 URL Link 的失效时间，为 Unix 时间戳
+	ExpireInterval int64      `json:"expire_interval,omitempty"` // 否 到期失效的 URL Link 的失效间隔天数
+	CloudBase      *CloudBase `json:"cloud_base,omitempty"`      // 否 云开发静态网站自定义 H5 配置参数
+}
+
+type generateUrlLinkRes struct {
+	ErrCode float64 `json:"errcode"`
+	ErrMsg  string  `json:"errmsg"`
+	UrlLink string  `json:"url_link"` // 生成的小程序 URL Link
+}
+
+// GenerateUrlLink 获取小程序 URL Link，适用于短信、邮件、网页、微信内等拉起小程序的业务场景
+func (wx *Wechat) GenerateUrlLink(accessToken string, req *GenerateUrlLinkReq) (*generateUrlLinkRes, error) {
+	var data generateUrlLinkRes
+	if err := wx.postJSON(generateUrlLink, accessToken, req, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+type GenerateShortLinkReq struct {
+	PageUrl     string `json:"page_url"`     // 是 通过 Short Link 进入的小程序页面路径，必须是已经发布的小程序存在的页面，可携带 query，最大1024个字符
+	PageTitle   string `json:"page_title"`   // 否 页面标题，不能包含违法信息，超过20字符会用... 截断代替
+	IsPermanent bool   `json:"is_permanent"` // 否 生成的 Short Link 类型，短期有效：false，永久有效：true
+}
+
+type generateShortLinkRes struct {
+	ErrCode float64 `json:"errcode"`
+	ErrMsg  string  `json:"errmsg"`
+	Link    string  `json:"link"` // 生成的小程序 Short Link
+}
+
+// GenerateShortLink 获取小程序 Short Link，适用于微信内拉起小程序的业务场景
+func (wx *Wechat) GenerateShortLink(accessToken string, req *GenerateShortLinkReq) (*generateShortLinkRes, error) {
+	var data generateShortLinkRes
+	if err := wx.postJSON(generateShortLink, accessToken, req, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
@@ -0,0 +1,52 @@
+package wechat
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPostImage(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("access_token") != "ok" {
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.Write([]byte(`{"errcode":40001,"errmsg":"invalid credential"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer ts.Close()
+
+	img, err := wx.postImage(ts.URL, "ok", &GetWxACodeUnLimitReq{Scene: "a=1", LineColor: &Color{R: 255}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(img.Reader())
+	if img.ContentType != "image/png" || string(b) != string(png) {
+		t.Fatalf("image %s %q", img.ContentType, b)
+	}
+
+	var wxErr *Error
+	if _, err := wx.postImage(ts.URL, "expired", &GetWxACodeReq{Path: "pages/index/index"}); !errors.As(err, &wxErr) || wxErr.ErrCode != 40001 {
+		t.Fatalf("want Error, got %v", err)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	var res generateSchemeRes
+	if err := decodeJSON([]byte(`{"errcode":0,"errmsg":"ok","openlink":"weixin://dl/business/?t=abc"}`), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.OpenLink != "weixin://dl/business/?t=abc" {
+		t.Fatalf("decoded %+v", res)
+	}
+
+	var wxErr *Error
+	if err := decodeJSON([]byte(`{"errcode":85079,"errmsg":"miniprogram has no online release"}`), &res); !errors.As(err, &wxErr) || wxErr.ErrCode != 85079 {
+		t.Fatalf("want Error, got %v", err)
+	}
+}